@@ -0,0 +1,189 @@
+package fallback_prover
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// StorageTarget is a storage slot of a contract on the source L2 to prove
+type StorageTarget struct {
+	Address     common.Address `json:"address"`
+	StorageSlot common.Hash    `json:"storageSlot"`
+}
+
+// NativeProof is the proveNative calldata generated for a single storage slot
+type NativeProof struct {
+	StorageTarget
+	StorageValue common.Hash `json:"storageValue"`
+	Calldata     string      `json:"calldata"`
+	// Bundle holds the calldata with every input it was generated from
+	Bundle *types.ProofBundle `json:"-"`
+}
+
+// GenerateProveNativeCalldataBatch generates proveNative calldata for every target against the
+// same L1 origin and settled state. The L1 header, settled state proof and L2 config update
+// args are generated once, and the storage proofs of each contract are fetched with a single
+// multi-key eth_getProof. The proofs are returned in the order of targets; the Address and
+// StorageSlot of params are ignored.
+func (p *Prover) GenerateProveNativeCalldataBatch(
+	ctx context.Context,
+	params *ProveParams,
+	targets []StorageTarget,
+) ([]*NativeProof, *types.SettledState, error) {
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("no storage targets to prove")
+	}
+
+	inputs, err := p.settledInputs(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	l2BlockNumber := inputs.l2Header.Number
+
+	// Group the distinct slots by contract, keeping the order they were first requested in
+	var addresses []common.Address
+	slots := make(map[common.Address][]common.Hash)
+	seen := make(map[StorageTarget]bool)
+	for _, target := range targets {
+		if seen[target] {
+			continue
+		}
+		seen[target] = true
+		if _, ok := slots[target.Address]; !ok {
+			addresses = append(addresses, target.Address)
+		}
+		slots[target.Address] = append(slots[target.Address], target.StorageSlot)
+	}
+
+	proofs := make(map[StorageTarget]*NativeProof, len(seen))
+	for _, address := range addresses {
+		result, err := p.l2StorageProver.GetStorageProofs(ctx, address, slots[address], l2BlockNumber)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get storage proofs: %w", err)
+		}
+		if result == nil || len(result.StorageProof) != len(slots[address]) {
+			return nil, nil, fmt.Errorf("incomplete storage proofs for %s", address.Hex())
+		}
+		for i, slot := range slots[address] {
+			target := StorageTarget{Address: address, StorageSlot: slot}
+			if result.StorageProof[i].Key != slot {
+				return nil, nil, fmt.Errorf(
+					"storage proof %d of %s is for slot %s, expected %s",
+					i,
+					address.Hex(),
+					result.StorageProof[i].Key.Hex(),
+					slot.Hex(),
+				)
+			}
+			var storageValue common.Hash
+			if value := result.StorageProof[i].Value; value != nil {
+				storageValue = common.BigToHash(value.ToInt())
+			}
+
+			l2StorageProof, rlpEncodedContractAccount, l2AccountProof, err := provers.StorageProofBytes(result, i)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to generate storage proof: %w", err)
+			}
+			bundle, err := p.encodeProveNative(
+				inputs,
+				target,
+				storageValue,
+				l2StorageProof,
+				rlpEncodedContractAccount,
+				l2AccountProof,
+			)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to prove slot %s of %s: %w", slot.Hex(), address.Hex(), err)
+			}
+			proofs[target] = &NativeProof{
+				StorageTarget: target,
+				StorageValue:  storageValue,
+				Calldata:      bundle.Calldata.String(),
+				Bundle:        bundle,
+			}
+		}
+		log.Debug("Generated storage proofs", "address", address, "slots", len(slots[address]), "l2Block", l2BlockNumber)
+	}
+
+	result := make([]*NativeProof, len(targets))
+	for i, target := range targets {
+		result[i] = proofs[target]
+	}
+	return result, inputs.settled, nil
+}
+
+// ParseStorageTarget parses a storage target given as <address>=<slot>
+func ParseStorageTarget(entry string) (StorageTarget, error) {
+	address, slot, ok := strings.Cut(entry, "=")
+	if !ok {
+		return StorageTarget{}, fmt.Errorf("invalid storage target %q: expected <address>=<slot>", entry)
+	}
+	return newStorageTarget(address, slot)
+}
+
+// LoadStorageTargets reads storage targets from a file. A .json file holds an array of
+// {"address", "storageSlot"} objects; any other file is read as CSV with one address,slot
+// pair per line and an optional header line.
+func LoadStorageTargets(path string) ([]StorageTarget, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage targets file: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var targets []StorageTarget
+		if err := json.NewDecoder(file).Decode(&targets); err != nil {
+			return nil, fmt.Errorf("failed to decode storage targets file %s: %w", path, err)
+		}
+		return targets, nil
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	var targets []StorageTarget
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read storage targets file %s: %w", path, err)
+		}
+		if line == 1 && !common.IsHexAddress(record[0]) {
+			// Header line
+			continue
+		}
+		target, err := newStorageTarget(record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", line, path, err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+func newStorageTarget(address, slot string) (StorageTarget, error) {
+	address, slot = strings.TrimSpace(address), strings.TrimSpace(slot)
+	if !common.IsHexAddress(address) {
+		return StorageTarget{}, fmt.Errorf("invalid contract address %q", address)
+	}
+	if slot == "" {
+		return StorageTarget{}, fmt.Errorf("missing storage slot for %s", address)
+	}
+	return StorageTarget{Address: common.HexToAddress(address), StorageSlot: common.HexToHash(slot)}, nil
+}
@@ -0,0 +1,141 @@
+package fallback_prover
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProver_GenerateProveNativeCalldataBatch(t *testing.T) {
+	tokenA := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	tokenB := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	targets := []StorageTarget{
+		{Address: tokenA, StorageSlot: common.HexToHash("0x01")},
+		{Address: tokenB, StorageSlot: common.HexToHash("0x05")},
+		{Address: tokenA, StorageSlot: common.HexToHash("0x02")},
+		// Absent slots are proven as zero
+		{Address: tokenB, StorageSlot: common.HexToHash("0x06")},
+		{Address: tokenA, StorageSlot: common.HexToHash("0x01")},
+	}
+
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(tokenA, common.HexToHash("0x01"), common.HexToHash("0x11"))
+	l2State.SetStorage(tokenA, common.HexToHash("0x02"), common.HexToHash("0x22"))
+	l2State.SetStorage(tokenB, common.HexToHash("0x05"), common.HexToHash("0x55"))
+	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Root = l2State.Root(t)
+
+	prover, settledStateProver := newMockProver(t, tokenA, common.HexToHash("0x01"))
+	var lookups, settledProofs atomic.Int64
+	settledStateProver.FindLatestResolvedFunc = func(ctx context.Context, config *types2.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+		lookups.Add(1)
+		return big.NewInt(9), common.HexToAddress("0x9999"), nil
+	}
+	settledStateProver.GenerateSettledStateProofFunc = func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *types2.L2ConfigInfo) ([]byte, *types.Header, error) {
+		settledProofs.Add(1)
+		return []byte("settled-state-proof"), l2Header, nil
+	}
+	getProofs := make(map[common.Address][]common.Hash)
+	prover.l2StorageProver = &testutil.MockStorageProver{
+		GetStorageProofsFunc: func(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*types2.StorageProofResult, error) {
+			require.Equal(t, l2Header.Number, blockNumber)
+			require.NotContains(t, getProofs, address, "storage proofs of a contract must be fetched once")
+			getProofs[address] = slots
+			result := l2State.GetProofResult(t, address, slots...)
+			return &result, nil
+		},
+		GetStorageAtFunc: func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (string, error) {
+			result := l2State.GetProofResult(t, address, slot)
+			return common.BigToHash(result.StorageProof[0].Value.ToInt()).Hex(), nil
+		},
+		GenerateStorageProofFunc: func(ctx context.Context, contractAddr common.Address, storageSlot common.Hash, blockNumber *big.Int) ([][]byte, []byte, [][]byte, error) {
+			storageProof, account, accountProof := l2State.ProofBytes(t, contractAddr, storageSlot)
+			return storageProof, account, accountProof, nil
+		},
+	}
+
+	proofs, settled, err := prover.GenerateProveNativeCalldataBatch(context.Background(), &ProveParams{}, targets)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lookups.Load())
+	assert.Equal(t, int64(1), settledProofs.Load())
+	assert.Equal(t, big.NewInt(9), settled.Index)
+	assert.Equal(t, l2Header.Hash(), settled.L2BlockHash)
+	assert.Equal(t, map[common.Address][]common.Hash{
+		tokenA: {common.HexToHash("0x01"), common.HexToHash("0x02")},
+		tokenB: {common.HexToHash("0x05"), common.HexToHash("0x06")},
+	}, getProofs)
+
+	// Every calldata matches the one proven for its slot on its own
+	require.Len(t, proofs, len(targets))
+	values := []string{"0x11", "0x55", "0x22", "0x00", "0x11"}
+	for i, proof := range proofs {
+		assert.Equal(t, targets[i], proof.StorageTarget)
+		assert.Equal(t, common.HexToHash(values[i]), proof.StorageValue)
+
+		calldata, _, err := prover.GenerateProveNativeCalldata(context.Background(), &ProveParams{
+			Address:     targets[i].Address,
+			StorageSlot: targets[i].StorageSlot,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, calldata, proof.Calldata)
+	}
+
+	// A proof that does not match the settled L2 state root fails the batch
+	l2Header.Root = common.HexToHash("0xbad")
+	getProofs = make(map[common.Address][]common.Hash)
+	_, _, err = prover.GenerateProveNativeCalldataBatch(context.Background(), &ProveParams{}, targets)
+	require.ErrorContains(t, err, "failed to verify L2 storage proof")
+
+	_, _, err = prover.GenerateProveNativeCalldataBatch(context.Background(), &ProveParams{}, nil)
+	require.ErrorContains(t, err, "no storage targets")
+}
+
+func TestLoadStorageTargets(t *testing.T) {
+	tokenA := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	tokenB := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	expected := []StorageTarget{
+		{Address: tokenA, StorageSlot: common.HexToHash("0x01")},
+		{Address: tokenB, StorageSlot: common.HexToHash("0x05")},
+	}
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "targets.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`[
+		{"address": "0x1234567890abcdef1234567890abcdef12345678", "storageSlot": "0x0000000000000000000000000000000000000000000000000000000000000001"},
+		{"address": "0xabcdef1234567890abcdef1234567890abcdef12", "storageSlot": "0x0000000000000000000000000000000000000000000000000000000000000005"}
+	]`), 0o600))
+	targets, err := LoadStorageTargets(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, targets)
+
+	csvPath := filepath.Join(dir, "targets.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(
+		"address,slot\n"+
+			"0x1234567890abcdef1234567890abcdef12345678, 0x01\n"+
+			"# comment\n"+
+			"0xabcdef1234567890abcdef1234567890abcdef12,0x05\n",
+	), 0o600))
+	targets, err = LoadStorageTargets(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, targets)
+
+	require.NoError(t, os.WriteFile(csvPath, []byte("0x1234567890abcdef1234567890abcdef12345678,0x01\nnot-an-address,0x02\n"), 0o600))
+	_, err = LoadStorageTargets(csvPath)
+	require.ErrorContains(t, err, "line 2")
+
+	target, err := ParseStorageTarget("0x1234567890abcdef1234567890abcdef12345678=0x01")
+	require.NoError(t, err)
+	assert.Equal(t, expected[0], target)
+	_, err = ParseStorageTarget("0x1234567890abcdef1234567890abcdef12345678")
+	require.ErrorContains(t, err, "expected <address>=<slot>")
+}
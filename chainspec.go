@@ -0,0 +1,199 @@
+package fallback_prover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"gopkg.in/yaml.v3"
+
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// L2ChainSpec is the declarative L2 configuration of a chain in the Registry, as read from a
+// YAML or JSON chain spec file. Numbers may be decimal or 0x prefixed hex.
+type L2ChainSpec struct {
+	ChainID uint64 `yaml:"chainId"`
+	// Type is the name of the L2 type, e.g. OPStackCannon
+	Type                 string                  `yaml:"type"`
+	Prover               common.Address          `yaml:"prover"`
+	Addresses            []common.Address        `yaml:"addresses"`
+	StorageSlots         []*math.HexOrDecimal256 `yaml:"storageSlots"`
+	FinalityDelaySeconds *math.HexOrDecimal256   `yaml:"finalityDelaySeconds"`
+	// VersionNumber defaults to the live version number plus one
+	VersionNumber *math.HexOrDecimal256 `yaml:"versionNumber"`
+}
+
+// L2ConfigChange is a field of an L2 configuration that differs between the Registry and a spec
+type L2ConfigChange struct {
+	Field string `json:"field"`
+	Live  string `json:"live"`
+	Spec  string `json:"spec"`
+}
+
+// L2ConfigUpdate is the Registry update that applies a chain spec to the live L2 configuration
+type L2ConfigUpdate struct {
+	Registry common.Address         `json:"registry"`
+	ChainID  uint64                 `json:"chainId"`
+	Live     *types.L2Configuration `json:"live"`
+	Spec     *types.L2Configuration `json:"spec"`
+	Changes  []L2ConfigChange       `json:"changes"`
+	// Calldata is the updateL2ChainConfiguration calldata; empty if the live configuration
+	// already matches the spec
+	Calldata hexutil.Bytes `json:"calldata"`
+}
+
+// LoadL2ChainSpec reads a YAML or JSON chain spec file, or stdin if path is "-"
+func LoadL2ChainSpec(path string) (*L2ChainSpec, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain spec: %w", err)
+	}
+
+	// JSON is valid YAML, so both are decoded by the YAML decoder
+	var spec L2ChainSpec
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to decode chain spec: %w", err)
+	}
+	if spec.ChainID == 0 {
+		return nil, fmt.Errorf("chain spec is missing chainId")
+	}
+	if spec.Prover == (common.Address{}) {
+		return nil, fmt.Errorf("chain spec is missing prover")
+	}
+	if spec.FinalityDelaySeconds == nil {
+		return nil, fmt.Errorf("chain spec is missing finalityDelaySeconds")
+	}
+	if _, err := provers.ParseL2Type(spec.Type); err != nil {
+		return nil, fmt.Errorf("invalid chain spec type: %w", err)
+	}
+	return &spec, nil
+}
+
+// PlanL2ConfigUpdate reads the live L2 configuration of the spec's chain from the registry of
+// conf, diffs it against the spec and encodes the updateL2ChainConfiguration calldata applying it
+func PlanL2ConfigUpdate(ctx context.Context, conf *RegistryConfig, spec *L2ChainSpec) (*L2ConfigUpdate, error) {
+	registryProver, err := newRegistryProver(conf)
+	if err != nil {
+		return nil, err
+	}
+	live, err := registryProver.GetL2ConfigurationForUpdate(ctx, spec.ChainID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L2 configuration of chain %d: %w", spec.ChainID, err)
+	}
+	return planL2ConfigUpdate(registryProver, conf.RegistryAddress, live, spec)
+}
+
+func planL2ConfigUpdate(
+	registryProver *provers.RegistryProver,
+	registry common.Address,
+	live *types.L2Configuration,
+	spec *L2ChainSpec,
+) (*L2ConfigUpdate, error) {
+	l2Type, err := provers.ParseL2Type(spec.Type)
+	if err != nil {
+		return nil, err
+	}
+	config := &types.L2Configuration{
+		Prover:               spec.Prover,
+		Addresses:            spec.Addresses,
+		StorageSlots:         make([]*big.Int, len(spec.StorageSlots)),
+		VersionNumber:        (*big.Int)(spec.VersionNumber),
+		FinalityDelaySeconds: (*big.Int)(spec.FinalityDelaySeconds),
+		L2Type:               l2Type,
+	}
+	if config.Addresses == nil {
+		config.Addresses = []common.Address{}
+	}
+	for i, slot := range spec.StorageSlots {
+		config.StorageSlots[i] = (*big.Int)(slot)
+	}
+
+	update := &L2ConfigUpdate{
+		Registry: registry,
+		ChainID:  spec.ChainID,
+		Live:     live,
+		Spec:     config,
+		Changes:  diffL2Configuration(live, config),
+	}
+	if len(update.Changes) == 0 {
+		return update, nil
+	}
+
+	// Any change is a new version, unless the spec pins one
+	if config.VersionNumber == nil {
+		config.VersionNumber = new(big.Int).Add(bigOrZero(live.VersionNumber), common.Big1)
+		update.Changes = append(update.Changes, L2ConfigChange{
+			Field: "versionNumber",
+			Live:  bigOrZero(live.VersionNumber).String(),
+			Spec:  config.VersionNumber.String(),
+		})
+	}
+	update.Calldata, err = registryProver.EncodeUpdateL2ChainConfigurationCalldata(spec.ChainID, config)
+	if err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// diffL2Configuration lists the fields of spec that differ from live. A nil spec version number
+// is not compared.
+func diffL2Configuration(live, spec *types.L2Configuration) []L2ConfigChange {
+	changes := []L2ConfigChange{}
+	diff := func(field, liveValue, specValue string) {
+		if liveValue != specValue {
+			changes = append(changes, L2ConfigChange{Field: field, Live: liveValue, Spec: specValue})
+		}
+	}
+	diff("type", provers.ConfigTypeName(live.L2Type), provers.ConfigTypeName(spec.L2Type))
+	diff("prover", live.Prover.String(), spec.Prover.String())
+	diff("addresses", joinStrings(live.Addresses), joinStrings(spec.Addresses))
+	diff("storageSlots", joinStrings(live.StorageSlots), joinStrings(spec.StorageSlots))
+	diff("finalityDelaySeconds", bigOrZero(live.FinalityDelaySeconds).String(), spec.FinalityDelaySeconds.String())
+	if spec.VersionNumber != nil {
+		diff("versionNumber", bigOrZero(live.VersionNumber).String(), spec.VersionNumber.String())
+	}
+	return changes
+}
+
+func bigOrZero(value *big.Int) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value
+}
+
+// WriteDiff writes the changes of the update, one field per line
+func (u *L2ConfigUpdate) WriteDiff(w io.Writer) error {
+	t := &treeWriter{w: w}
+	if len(u.Changes) == 0 {
+		t.line("L2 configuration of chain %d in registry %s already matches the spec", u.ChainID, u.Registry)
+		return t.err
+	}
+	t.line("L2 configuration of chain %d in registry %s", u.ChainID, u.Registry)
+	t.indent(func() {
+		for _, change := range u.Changes {
+			t.line("%s", change.Field)
+			t.indent(func() {
+				t.line("- %s", change.Live)
+				t.line("+ %s", change.Spec)
+			})
+		}
+	})
+	return t.err
+}
@@ -0,0 +1,117 @@
+package fallback_prover
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/polymerdao/fallback_prover/provers"
+	types2 "github.com/polymerdao/fallback_prover/types"
+)
+
+func writeChainSpec(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadL2ChainSpec(t *testing.T) {
+	yamlSpec, err := LoadL2ChainSpec(writeChainSpec(t, "spec.yaml", `
+chainId: 10
+type: OPStackCannon
+prover: 0x00000000000000000000000000000000000000aa
+addresses:
+  - 0x00000000000000000000000000000000000000bb
+storageSlots: [0x68, 1]
+finalityDelaySeconds: 302400
+`))
+	require.NoError(t, err)
+	jsonSpec, err := LoadL2ChainSpec(writeChainSpec(t, "spec.json", `{
+  "chainId": 10,
+  "type": "opstackcannon",
+  "prover": "0x00000000000000000000000000000000000000aa",
+  "addresses": ["0x00000000000000000000000000000000000000bb"],
+  "storageSlots": ["0x68", "1"],
+  "finalityDelaySeconds": "0x49d40"
+}`))
+	require.NoError(t, err)
+
+	for _, spec := range []*L2ChainSpec{yamlSpec, jsonSpec} {
+		assert.Equal(t, uint64(10), spec.ChainID)
+		assert.Equal(t, common.HexToAddress("0xaa"), spec.Prover)
+		assert.Equal(t, []common.Address{common.HexToAddress("0xbb")}, spec.Addresses)
+		require.Len(t, spec.StorageSlots, 2)
+		assert.Equal(t, "104", (*big.Int)(spec.StorageSlots[0]).String())
+		assert.Equal(t, "302400", (*big.Int)(spec.FinalityDelaySeconds).String())
+		assert.Nil(t, spec.VersionNumber)
+	}
+
+	_, err = LoadL2ChainSpec(writeChainSpec(t, "unknown.yaml", "chainId: 10\nprovr: 0x00000000000000000000000000000000000000aa\n"))
+	require.ErrorContains(t, err, "field provr not found")
+	_, err = LoadL2ChainSpec(writeChainSpec(t, "prover.yaml", "chainId: 10\ntype: OPStackCannon\n"))
+	require.ErrorContains(t, err, "chain spec is missing prover")
+	_, err = LoadL2ChainSpec(writeChainSpec(t, "type.yaml", `
+chainId: 10
+type: Cannon
+prover: 0x00000000000000000000000000000000000000aa
+finalityDelaySeconds: 1
+`))
+	require.ErrorContains(t, err, `unknown L2 type "Cannon"`)
+}
+
+func TestPlanL2ConfigUpdate(t *testing.T) {
+	registry := common.HexToAddress("0x01")
+	registryProver := provers.NewRegistryProver(nil, nil, registry)
+	spec, err := LoadL2ChainSpec(writeChainSpec(t, "spec.yaml", `
+chainId: 10
+type: OPStackCannon
+prover: 0x00000000000000000000000000000000000000aa
+addresses: [0x00000000000000000000000000000000000000cc]
+storageSlots: [0x68]
+finalityDelaySeconds: 302400
+`))
+	require.NoError(t, err)
+	live := &types2.L2Configuration{
+		Prover:               common.HexToAddress("0xaa"),
+		Addresses:            []common.Address{common.HexToAddress("0xbb")},
+		StorageSlots:         []*big.Int{big.NewInt(0x68)},
+		VersionNumber:        big.NewInt(3),
+		FinalityDelaySeconds: big.NewInt(604800),
+		L2Type:               types2.OPStackBedrock,
+	}
+
+	update, err := planL2ConfigUpdate(registryProver, registry, live, spec)
+	require.NoError(t, err)
+	assert.Equal(t, []L2ConfigChange{
+		{Field: "type", Live: "OPStackBedrock", Spec: "OPStackCannon"},
+		{
+			Field: "addresses",
+			Live:  "0x00000000000000000000000000000000000000bb",
+			Spec:  "0x00000000000000000000000000000000000000cc",
+		},
+		{Field: "finalityDelaySeconds", Live: "604800", Spec: "302400"},
+		{Field: "versionNumber", Live: "3", Spec: "4"},
+	}, update.Changes)
+
+	expected, err := registryProver.EncodeUpdateL2ChainConfigurationCalldata(10, &types2.L2Configuration{
+		Prover:               common.HexToAddress("0xaa"),
+		Addresses:            []common.Address{common.HexToAddress("0xcc")},
+		StorageSlots:         []*big.Int{big.NewInt(0x68)},
+		VersionNumber:        big.NewInt(4),
+		FinalityDelaySeconds: big.NewInt(302400),
+		L2Type:               types2.OPStackCannon,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expected, []byte(update.Calldata))
+
+	// Applying the spec again changes nothing
+	update, err = planL2ConfigUpdate(registryProver, registry, update.Spec, spec)
+	require.NoError(t, err)
+	assert.Empty(t, update.Changes)
+	assert.Empty(t, update.Calldata)
+}
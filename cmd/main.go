@@ -1,16 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
 
 	"github.com/polymerdao/fallback_prover"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
 )
 
 var (
@@ -32,6 +41,11 @@ func main() {
 	app.Commands = []*cli.Command{
 		ProveNativeCmd,
 		ProveL1NativeCmd,
+		ExplainRevertCmd,
+		EncodeCmd,
+		InspectCmd,
+		RegistryCmd,
+		ServeCmd,
 	}
 
 	// Create a context that gets canceled on interrupt signal
@@ -69,10 +83,259 @@ var ProveL1NativeCmd = &cli.Command{
 	Flags:       fallback_prover.L1Flags,
 }
 
+var ExplainRevertCmd = &cli.Command{
+	Name:        "explain-revert",
+	Usage:       "Decode NativeProver and settled state prover revert data",
+	ArgsUsage:   "<hex>",
+	Description: "Match hex encoded revert data against the custom errors of the bundled ABIs and suggest a fix",
+	Action:      explainRevert,
+}
+
+var EncodeCmd = &cli.Command{
+	Name:      "encode",
+	Usage:     "Encode the calldata of a saved proof bundle again, without RPC calls",
+	ArgsUsage: "<bundle.json | ->",
+	Description: "Read a proof bundle printed with --output json and encode its proveNative or proveL1Native " +
+		"calldata again from the headers, settled state proof and storage proofs it holds",
+	Action: encode,
+	Flags:  fallback_prover.EncodeFlags,
+}
+
+var InspectCmd = &cli.Command{
+	Name:      "inspect",
+	Usage:     "Decode proveNative or proveL1Native calldata",
+	ArgsUsage: "<hex | ->",
+	Description: "Decode the arguments, headers and settled state proof of NativeProver calldata and report " +
+		"inputs that do not match each other. Prints a tree, or with --output json a proof bundle that " +
+		"encode accepts together with the decoded settled state proof",
+	Action: inspect,
+	Flags:  fallback_prover.InspectFlags,
+}
+
+var RegistryCmd = &cli.Command{
+	Name:  "registry",
+	Usage: "Explore the chains and access control of the L1 registry",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "chains",
+			Usage: "List every configured chain with its configuration and update history",
+			Description: "Find the chains of the registry in its L2ChainConfigurationUpdated and " +
+				"L1ChainConfigurationUpdated logs and read their configurations at the latest L1 block",
+			Action: registryChains,
+			Flags:  fallback_prover.RegistryFlags,
+		},
+		{
+			Name:  "access",
+			Usage: "Show the owner, paused state, irrevocable grantees and role members of the registry",
+			Description: "Find grantees and roles in the NewIrrevocableGrantee and role logs of the registry and " +
+				"check which accounts still hold each role at the latest L1 block",
+			Action: registryAccess,
+			Flags:  fallback_prover.RegistryFlags,
+		},
+		{
+			Name:      "update-l2-config",
+			Usage:     "Generate Registry.updateL2ChainConfiguration calldata from a chain spec",
+			ArgsUsage: "<spec.yaml | spec.json | ->",
+			Description: "Read a YAML or JSON chain spec with chainId, type, prover, addresses, storageSlots, " +
+				"finalityDelaySeconds and an optional versionNumber, diff it against the live L2 configuration of " +
+				"the chain in the registry and print the updateL2ChainConfiguration calldata that applies it. " +
+				"The diff is written to stderr",
+			Action: registryUpdateL2Config,
+			Flags:  fallback_prover.RegistryUpdateFlags,
+		},
+	},
+}
+
+var ServeCmd = &cli.Command{
+	Name:  "serve",
+	Usage: "Serve proveNative and proveNativeL1 calldata over JSON-RPC",
+	Description: "Run a long-lived JSON-RPC server exposing prover_proveNative, prover_proveNativeL1 and " +
+		"prover_status, keeping provers warm per source and destination chain pair",
+	Action: serve,
+	Flags:  fallback_prover.ServeFlags,
+}
+
+func serve(c *cli.Context) error {
+	if err := fallback_prover.CheckRequiredServe(c); err != nil {
+		return err
+	}
+
+	config, err := fallback_prover.NewServiceConfigFromCLI(c)
+	if err != nil {
+		return err
+	}
+	return fallback_prover.Serve(c.Context, config, VersionWithMeta)
+}
+
+func encode(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one proof bundle file argument")
+	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+
+	bundle, err := fallback_prover.LoadProofBundle(c.Args().First())
+	if err != nil {
+		return err
+	}
+	encoded, err := fallback_prover.EncodeProofBundle(bundle)
+	if err != nil {
+		return fmt.Errorf("failed to encode proof bundle: %w", err)
+	}
+	if bundle.Calldata != nil && !bytes.Equal(bundle.Calldata, encoded.Calldata) {
+		log.Info("Encoded calldata differs from the saved calldata", "method", encoded.Method)
+	}
+	return printOutput(c, encoded.Calldata.String(), encoded)
+}
+
+func inspect(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one hex encoded calldata argument")
+	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+
+	arg := c.Args().First()
+	if arg == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read calldata: %w", err)
+		}
+		arg = strings.TrimSpace(string(data))
+	}
+	if !strings.HasPrefix(arg, "0x") && !strings.HasPrefix(arg, "0X") {
+		arg = "0x" + arg
+	}
+	calldata, err := hexutil.Decode(arg)
+	if err != nil {
+		return fmt.Errorf("invalid calldata: %w", err)
+	}
+
+	inspection, err := fallback_prover.InspectCalldata(calldata)
+	if err != nil {
+		return fmt.Errorf("failed to inspect calldata: %w", err)
+	}
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		return printJSON(inspection)
+	}
+	return inspection.WriteTree(os.Stdout)
+}
+
+func registryChains(c *cli.Context) error {
+	if err := fallback_prover.CheckRequiredRegistry(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+
+	chains, err := fallback_prover.GetRegistryChains(c.Context, fallback_prover.NewRegistryConfigFromCLI(c))
+	if err != nil {
+		return err
+	}
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		return printJSON(chains)
+	}
+	return fallback_prover.WriteRegistryChains(os.Stdout, chains)
+}
+
+func registryAccess(c *cli.Context) error {
+	if err := fallback_prover.CheckRequiredRegistry(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+
+	access, err := fallback_prover.GetRegistryAccess(c.Context, fallback_prover.NewRegistryConfigFromCLI(c))
+	if err != nil {
+		return err
+	}
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		return printJSON(access)
+	}
+	return fallback_prover.WriteRegistryAccess(os.Stdout, access)
+}
+
+func registryUpdateL2Config(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one chain spec file argument")
+	}
+	if err := fallback_prover.CheckRequiredRegistry(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+
+	spec, err := fallback_prover.LoadL2ChainSpec(c.Args().First())
+	if err != nil {
+		return err
+	}
+	update, err := fallback_prover.PlanL2ConfigUpdate(c.Context, fallback_prover.NewRegistryConfigFromCLI(c), spec)
+	if err != nil {
+		return err
+	}
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		return printJSON(update)
+	}
+	if err := update.WriteDiff(os.Stderr); err != nil {
+		return err
+	}
+	if len(update.Calldata) > 0 {
+		fmt.Println(update.Calldata)
+	}
+	return nil
+}
+
+func explainRevert(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("expected exactly one hex encoded revert data argument")
+	}
+
+	arg := c.Args().First()
+	if !strings.HasPrefix(arg, "0x") && !strings.HasPrefix(arg, "0X") {
+		arg = "0x" + arg
+	}
+	data, err := hexutil.Decode(arg)
+	if err != nil {
+		return fmt.Errorf("invalid revert data: %w", err)
+	}
+
+	decoder, err := provers.NewRevertDecoder()
+	if err != nil {
+		return fmt.Errorf("failed to initialize revert decoder: %w", err)
+	}
+
+	decoded := decoder.Decode(data)
+	fmt.Println(decoded.Error())
+	var hinted provers.HintedError
+	if errors.As(decoded, &hinted) && hinted.Hint() != "" {
+		fmt.Printf("hint: %s\n", hinted.Hint())
+	}
+
+	var unknown *provers.UnknownRevertError
+	if errors.As(decoded, &unknown) {
+		return fmt.Errorf("revert data does not match any bundled ABI")
+	}
+	return nil
+}
+
 func proveL1Native(c *cli.Context) error {
 	if err := fallback_prover.CheckRequiredL1(c); err != nil {
 		return err
 	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckSimulate(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckSubmit(c); err != nil {
+		return err
+	}
 
 	config := fallback_prover.NewL1ConfigFromCLI(c)
 	params := fallback_prover.NewParamsFromCLI(c)
@@ -92,23 +355,41 @@ func proveL1Native(c *cli.Context) error {
 	}
 
 	// Generate proof calldata
-	calldata, err := prover.GenerateProveL1Calldata(
+	bundle, err := prover.GenerateProveL1Bundle(
 		c.Context,
 		params,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to generate proof calldata: %w", err)
 	}
+	calldata := bundle.Calldata.String()
 
 	// Output the calldata
-	fmt.Println(calldata)
-	return nil
+	if err := printOutput(c, calldata, bundle); err != nil {
+		return err
+	}
+	if err := simulate(c, calldata); err != nil {
+		return err
+	}
+	return submit(c, calldata)
 }
 
 func proveNative(c *cli.Context) error {
 	if err := fallback_prover.CheckRequiredL2(c); err != nil {
 		return err
 	}
+	if err := fallback_prover.CheckOutput(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckSimulate(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckMulticall(c); err != nil {
+		return err
+	}
+	if err := fallback_prover.CheckSubmit(c); err != nil {
+		return err
+	}
 
 	config := fallback_prover.NewConfigFromCLI(c)
 	params := fallback_prover.NewParamsFromCLI(c)
@@ -117,7 +398,13 @@ func proveNative(c *cli.Context) error {
 		"srcL2ChainID", config.SrcL2ChainID,
 		"dstL2ChainID", config.DstL2ChainID,
 		"srcAddress", params.Address,
-		"srcStorageSlot", params.StorageSlot)
+		"srcStorageSlot", params.StorageSlot,
+		"l2BlockNumber", params.L2BlockNumber)
+
+	targets, err := fallback_prover.NewStorageTargetsFromCLI(c)
+	if err != nil {
+		return err
+	}
 
 	// Initialize the prover
 	prover, err := fallback_prover.NewProver(
@@ -127,17 +414,254 @@ func proveNative(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize prover: %w", err)
 	}
+	if len(targets) == 0 && c.Bool(fallback_prover.Multicall.Name) {
+		targets = []fallback_prover.StorageTarget{{Address: params.Address, StorageSlot: params.StorageSlot}}
+	}
+	if len(targets) > 0 {
+		return proveNativeBatch(c, prover, params, targets)
+	}
 
 	// Generate proveNative calldata
-	calldata, err := prover.GenerateProveNativeCalldata(
+	bundle, err := prover.GenerateProveNativeBundle(
 		c.Context,
 		params,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to generate proveNative calldata: %w", err)
 	}
+	calldata, settled := bundle.Calldata.String(), bundle.Settled
+	log.Info("Proved against settled state",
+		"index", settled.Index,
+		"root", settled.RootAddress,
+		"l1Block", settled.L1BlockNumber,
+		"l2Block", settled.L2BlockNumber,
+		"l2BlockHash", settled.L2BlockHash)
 
 	// Output the calldata
+	if err := printOutput(c, calldata, bundle); err != nil {
+		return err
+	}
+	if err := simulate(c, calldata); err != nil {
+		return err
+	}
+	return submit(c, calldata)
+}
+
+// printOutput prints the calldata, or the proof bundle with --output json
+func printOutput(c *cli.Context, calldata string, bundle *types.ProofBundle) error {
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		return printJSON(bundle)
+	}
 	fmt.Println(calldata)
 	return nil
 }
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// proveNativeBatch proves every storage target against the same L1 origin and settled state and
+// prints one calldata per line, in the order the targets were given, or a single Multicall3
+// bundle of them with --multicall
+func proveNativeBatch(
+	c *cli.Context,
+	prover *fallback_prover.Prover,
+	params *fallback_prover.ProveParams,
+	targets []fallback_prover.StorageTarget,
+) error {
+	log.Info("Generating proveNative() calldata for a batch", "targets", len(targets))
+
+	proofs, settled, err := prover.GenerateProveNativeCalldataBatch(c.Context, params, targets)
+	if err != nil {
+		return fmt.Errorf("failed to generate proveNative calldata: %w", err)
+	}
+	log.Info("Proved against settled state",
+		"index", settled.Index,
+		"root", settled.RootAddress,
+		"l1Block", settled.L1BlockNumber,
+		"l2Block", settled.L2BlockNumber,
+		"l2BlockHash", settled.L2BlockHash)
+
+	calldatas := make([]string, len(proofs))
+	bundles := make([]*types.ProofBundle, len(proofs))
+	for i, proof := range proofs {
+		log.Info("Proved storage slot",
+			"srcAddress", proof.Address,
+			"srcStorageSlot", proof.StorageSlot,
+			"storageValue", proof.StorageValue)
+		calldatas[i] = proof.Calldata
+		bundles[i] = proof.Bundle
+	}
+	if c.Bool(fallback_prover.Multicall.Name) {
+		if err := printMulticall(c, calldatas, bundles); err != nil {
+			return err
+		}
+	} else if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		if err := printJSON(bundles); err != nil {
+			return err
+		}
+	} else {
+		for _, calldata := range calldatas {
+			fmt.Println(calldata)
+		}
+	}
+
+	for _, proof := range proofs {
+		if err := simulate(c, proof.Calldata); err != nil {
+			return err
+		}
+		if err := submit(c, proof.Calldata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// multicallOutput is printed for a Multicall3 bundle with --output json
+type multicallOutput struct {
+	Multicall3   common.Address       `json:"multicall3"`
+	NativeProver common.Address       `json:"nativeProver"`
+	AllowFailure bool                 `json:"allowFailure"`
+	Calldata     string               `json:"calldata"`
+	Proofs       []*types.ProofBundle `json:"proofs"`
+}
+
+// printMulticall prints the calldatas wrapped into a Multicall3 aggregate3 call and writes the
+// Safe Transaction Builder file when --safe-tx-builder-file is set
+func printMulticall(c *cli.Context, calldatas []string, bundles []*types.ProofBundle) error {
+	nativeProverAddress := common.HexToAddress(c.String(fallback_prover.NativeProverAddress.Name))
+	multicallAddress := common.HexToAddress(c.String(fallback_prover.Multicall3Address.Name))
+	aggregate3, err := fallback_prover.EncodeAggregate3Calldata(
+		nativeProverAddress,
+		calldatas,
+		c.Bool(fallback_prover.AllowFailure.Name),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to bundle calldata: %w", err)
+	}
+	log.Info("Bundled proveNative calls with Multicall3",
+		"multicall3", multicallAddress,
+		"nativeProver", nativeProverAddress,
+		"calls", len(calldatas),
+		"allowFailure", c.Bool(fallback_prover.AllowFailure.Name))
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		if err := printJSON(&multicallOutput{
+			Multicall3:   multicallAddress,
+			NativeProver: nativeProverAddress,
+			AllowFailure: c.Bool(fallback_prover.AllowFailure.Name),
+			Calldata:     aggregate3,
+			Proofs:       bundles,
+		}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(aggregate3)
+	}
+
+	path := c.String(fallback_prover.SafeTxBuilderFile.Name)
+	if path == "" {
+		return nil
+	}
+	batch := fallback_prover.NewSafeTxBuilderBatch(
+		c.Uint64(fallback_prover.DstL2ChainID.Name),
+		multicallAddress,
+		aggregate3,
+		len(calldatas),
+	)
+	if err := fallback_prover.WriteSafeTxBuilderFile(path, batch); err != nil {
+		return err
+	}
+	log.Info("Wrote Safe Transaction Builder file", "path", path)
+	return nil
+}
+
+// simulate dry-runs the calldata against the destination NativeProver when --simulate is set
+func simulate(c *cli.Context, calldata string) error {
+	if !c.Bool(fallback_prover.Simulate.Name) {
+		return nil
+	}
+
+	nativeProverAddress := common.HexToAddress(c.String(fallback_prover.NativeProverAddress.Name))
+	result, err := fallback_prover.SimulateCalldata(
+		c.Context,
+		c.String(fallback_prover.DstL2HTTPPath.Name),
+		nativeProverAddress,
+		calldata,
+	)
+	if err != nil {
+		log.Error("Simulation failed", "nativeProver", nativeProverAddress, "err", err)
+		var hinted provers.HintedError
+		if errors.As(err, &hinted) && hinted.Hint() != "" {
+			log.Info("Simulation hint: " + hinted.Hint())
+		}
+		return fmt.Errorf("simulation failed: %w", err)
+	}
+
+	log.Info("Simulation succeeded",
+		"nativeProver", nativeProverAddress,
+		"chainID", result.ChainID,
+		"storingContract", result.StoringContract,
+		"storageSlot", result.StorageSlot,
+		"storageValue", result.StorageValue)
+	return nil
+}
+
+// submit signs and sends the calldata to the destination NativeProver when --submit is set
+func submit(c *cli.Context, calldata string) error {
+	if !c.Bool(fallback_prover.Submit.Name) {
+		return nil
+	}
+
+	key, err := fallback_prover.LoadSigningKey(
+		c.String(fallback_prover.PrivateKeyFile.Name),
+		c.String(fallback_prover.KeystoreFile.Name),
+		c.String(fallback_prover.KeystorePasswordFile.Name),
+	)
+	if err != nil {
+		return err
+	}
+
+	nativeProverAddress := common.HexToAddress(c.String(fallback_prover.NativeProverAddress.Name))
+	result, err := fallback_prover.SubmitCalldata(
+		c.Context,
+		c.String(fallback_prover.DstL2HTTPPath.Name),
+		key,
+		nativeProverAddress,
+		calldata,
+	)
+	if err != nil {
+		var hinted provers.HintedError
+		if errors.As(err, &hinted) && hinted.Hint() != "" {
+			log.Info("Submit hint: " + hinted.Hint())
+		}
+		return fmt.Errorf("failed to submit proof: %w", err)
+	}
+
+	log.Info("Proof transaction mined",
+		"hash", result.TxHash,
+		"block", result.BlockNumber,
+		"gasUsed", result.GasUsed)
+	event := result.Event
+	// Stdout only holds the JSON output, so the event is logged instead
+	if c.String(fallback_prover.Output.Name) == fallback_prover.OutputJSON {
+		log.Info("Proof event emitted",
+			"event", event.Event,
+			"chainID", event.ChainID,
+			"blockNumber", event.BlockNumber,
+			"stateRoot", event.StateRoot)
+		return nil
+	}
+	if event.ChainID != nil {
+		fmt.Printf("%s chainID=%s blockNumber=%s stateRoot=%s\n",
+			event.Event, event.ChainID, event.BlockNumber, event.StateRoot.Hex())
+	} else {
+		fmt.Printf("%s blockNumber=%s stateRoot=%s\n", event.Event, event.BlockNumber, event.StateRoot.Hex())
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package fallback_prover
 
 import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/urfave/cli/v2"
 )
@@ -20,7 +26,22 @@ type ProveConfig struct {
 	SrcL2RPC        string
 	DstL2RPC        string
 	RegistryAddress common.Address
-	WaitForNewEpoch bool
+	// NativeProverAddress is the NativeProver on the destination L2; when set, the registry, its
+	// mapping slot and the L1 block hash oracle are read from its L1_CONFIGURATION()
+	NativeProverAddress common.Address
+	// L2ConfigMappingSlot is the registry l2ChainConfigurationHashMap slot; nil uses the default
+	// or the one of the NativeProver
+	L2ConfigMappingSlot *big.Int
+	// OptimismPortalAddress is the OptimismPortal2 of an OPStackCannon source L2; when set, only
+	// games of its respected game type that it has not blacklisted are proven against
+	OptimismPortalAddress common.Address
+	// AllowUnverifiedNitroProofs enables settled state proofs for an Arbitrum Nitro source L2,
+	// whose encoding no on-chain verifier checks yet
+	AllowUnverifiedNitroProofs bool
+	WaitForNewEpoch            bool
+	// SettledStateTTL is how long the latest settled state is reused between proofs;
+	// zero re-resolves it on every proof
+	SettledStateTTL time.Duration
 }
 
 // ProveL1Config contains the configuration for proving a storage slot on an L1
@@ -29,11 +50,108 @@ type ProveL1Config struct {
 	L1HTTPPath      string
 	DstL2RPC        string
 	RegistryAddress common.Address
+	// NativeProverAddress is the NativeProver on the destination L2; when set, the L1 block hash
+	// oracle is read from its L1_CONFIGURATION()
+	NativeProverAddress common.Address
+}
+
+// RegistryConfig contains the configuration for reading the chains and access control of an L1
+// registry
+type RegistryConfig struct {
+	L1HTTPPath      string
+	RegistryAddress common.Address
+	// FromBlock is the first L1 block registry logs are read from
+	FromBlock uint64
+	// LogBlockRange is the most L1 blocks requested in one eth_getLogs call; zero requests
+	// all of them at once
+	LogBlockRange uint64
+}
+
+// NewRegistryConfigFromCLI creates a registry config from the provided *cli.Context
+func NewRegistryConfigFromCLI(ctx *cli.Context) *RegistryConfig {
+	return &RegistryConfig{
+		L1HTTPPath:      ctx.String(L1HTTPPath.Name),
+		RegistryAddress: common.HexToAddress(ctx.String(L1RegistryAddress.Name)),
+		FromBlock:       ctx.Uint64(FromBlock.Name),
+		LogBlockRange:   ctx.Uint64(LogBlockRange.Name),
+	}
+}
+
+// NewServiceConfigFromCLI creates a proof service config from the provided *cli.Context
+func NewServiceConfigFromCLI(ctx *cli.Context) (*ServiceConfig, error) {
+	l2RPCs, err := parseChainEntries(ctx, L2RPC, "url", func(value string) (string, error) {
+		return value, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	portals, err := parseChainEntries(ctx, OptimismPortal, "address", parseAddress)
+	if err != nil {
+		return nil, err
+	}
+	nativeProvers, err := parseChainEntries(ctx, NativeProver, "address", parseAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServiceConfig{
+		ListenAddr:        ctx.String(ListenAddr.Name),
+		L1HTTPPath:        ctx.String(L1HTTPPath.Name),
+		RegistryAddress:   common.HexToAddress(ctx.String(L1RegistryAddress.Name)),
+		L2RPCs:            l2RPCs,
+		OptimismPortals:   portals,
+		NativeProvers:     nativeProvers,
+		EpochPollingFreq:  ctx.Uint(EpochPollingFreq.Name),
+		EpochPollingTries: ctx.Uint(EpochPollingTries.Name),
+		SettledStateTTL:   ctx.Duration(SettledStateTTL.Name),
+
+		AllowUnverifiedNitroProofs: ctx.Bool(AllowUnverifiedNitroProofs.Name),
+	}, nil
+}
+
+// parseAddress parses a hex encoded address
+func parseAddress(value string) (common.Address, error) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("invalid address %q", value)
+	}
+	return common.HexToAddress(value), nil
+}
+
+// parseChainEntries parses the <chain-id>=<value> entries of a repeated flag into a map keyed by chain ID
+func parseChainEntries[T any](
+	ctx *cli.Context,
+	flag *cli.StringSliceFlag,
+	valueName string,
+	parse func(string) (T, error),
+) (map[uint64]T, error) {
+	entries := make(map[uint64]T)
+	for _, entry := range ctx.StringSlice(flag.Name) {
+		chainID, value, ok := strings.Cut(entry, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid %s %q: expected <chain-id>=<%s>", flag.Name, entry, valueName)
+		}
+		id, err := strconv.ParseUint(chainID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain ID in %s %q: %w", flag.Name, entry, err)
+		}
+		if _, ok := entries[id]; ok {
+			return nil, fmt.Errorf("duplicate %s for chain %d", flag.Name, id)
+		}
+		parsed, err := parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", flag.Name, entry, err)
+		}
+		entries[id] = parsed
+	}
+	return entries, nil
 }
 
 type ProveParams struct {
-	Address           common.Address
-	StorageSlot       common.Hash
+	Address     common.Address
+	StorageSlot common.Hash
+	// L2BlockNumber selects the earliest settled state at or after this source L2 block;
+	// nil proves against the latest settled state
+	L2BlockNumber     *big.Int
 	WaitForNewEpoch   bool
 	EpochPollingFreq  uint
 	EpochPollingTries uint
@@ -49,24 +167,64 @@ func NewConfigFromCLI(ctx *cli.Context) *ProveConfig {
 		DstL2ChainID:    ctx.Uint64(DstL2ChainID.Name),
 		RegistryAddress: common.HexToAddress(ctx.String(L1RegistryAddress.Name)),
 		WaitForNewEpoch: ctx.Bool(WaitForNewEpoch.Name),
+
+		OptimismPortalAddress:      common.HexToAddress(ctx.String(OptimismPortalAddress.Name)),
+		AllowUnverifiedNitroProofs: ctx.Bool(AllowUnverifiedNitroProofs.Name),
+		NativeProverAddress:        common.HexToAddress(ctx.String(NativeProverAddress.Name)),
+		L2ConfigMappingSlot:        l2ConfigMappingSlotFromCLI(ctx),
 	}
 }
 
+// l2ConfigMappingSlotFromCLI returns the l1-registry-l2-config-mapping-slot, or nil if it is not set
+func l2ConfigMappingSlotFromCLI(ctx *cli.Context) *big.Int {
+	if !ctx.IsSet(L1RegistryL2ConfigMappingSlot.Name) {
+		return nil
+	}
+	return new(big.Int).SetUint64(ctx.Uint64(L1RegistryL2ConfigMappingSlot.Name))
+}
+
 func NewL1ConfigFromCLI(ctx *cli.Context) *ProveL1Config {
 	return &ProveL1Config{
 		L1HTTPPath:      ctx.String(L1HTTPPath.Name),
 		DstL2RPC:        ctx.String(DstL2HTTPPath.Name),
 		DstL2ChainID:    ctx.Uint64(DstL2ChainID.Name),
 		RegistryAddress: common.HexToAddress(ctx.String(L1RegistryAddress.Name)),
+
+		NativeProverAddress: common.HexToAddress(ctx.String(NativeProverAddress.Name)),
+	}
+}
+
+// NewStorageTargetsFromCLI returns the storage targets given with src-storage-target and
+// src-storage-targets-file, or nil if neither is set
+func NewStorageTargetsFromCLI(ctx *cli.Context) ([]StorageTarget, error) {
+	var targets []StorageTarget
+	for _, entry := range ctx.StringSlice(SrcStorageTarget.Name) {
+		target, err := ParseStorageTarget(entry)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
 	}
+	if path := ctx.String(SrcStorageTargetsFile.Name); path != "" {
+		fileTargets, err := LoadStorageTargets(path)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, fileTargets...)
+	}
+	return targets, nil
 }
 
 func NewParamsFromCLI(ctx *cli.Context) *ProveParams {
-	return &ProveParams{
+	params := &ProveParams{
 		Address:           common.HexToAddress(ctx.String(SrcContractAddress.Name)),
 		StorageSlot:       common.HexToHash(ctx.String(SrcStorageSlot.Name)),
 		WaitForNewEpoch:   ctx.Bool(WaitForNewEpoch.Name),
 		EpochPollingFreq:  ctx.Uint(EpochPollingFreq.Name),
 		EpochPollingTries: ctx.Uint(EpochPollingTries.Name),
 	}
+	if ctx.IsSet(L2BlockNumber.Name) {
+		params.L2BlockNumber = new(big.Int).SetUint64(ctx.Uint64(L2BlockNumber.Name))
+	}
+	return params
 }
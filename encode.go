@@ -0,0 +1,143 @@
+package fallback_prover
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	types2 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/provers/verify"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// LoadProofBundle reads a JSON proof bundle, as printed with --output json, from a file or from
+// stdin when path is "-"
+func LoadProofBundle(path string) (*types.ProofBundle, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof bundle: %w", err)
+	}
+
+	var bundle types.ProofBundle
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode proof bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+// EncodeProofBundle encodes the calldata of a saved proof bundle again from the inputs it holds,
+// without any RPC calls. The headers are checked against their hashes and the storage proof
+// against the proven state root, and the settled state proof is repacked by the current encoder.
+// It returns a copy of the bundle with the new calldata and settled state proof.
+func EncodeProofBundle(bundle *types.ProofBundle) (*types.ProofBundle, error) {
+	nativeProver, err := provers.NewNativeProver()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize NativeProver: %w", err)
+	}
+
+	l1Header, err := decodeBundleHeader("L1", &bundle.L1Header)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := *bundle
+	switch bundle.Method {
+	case "proveL1Native":
+		if err := verifyBundleStorage(l1Header, &bundle.Storage); err != nil {
+			return nil, fmt.Errorf("failed to verify L1 storage proof: %w", err)
+		}
+		encoded.Calldata, err = nativeProver.EncodeProveL1NativeCalldata(
+			types.ProveL1ScalarArgs{
+				ContractAddr:     bundle.Storage.Address,
+				StorageSlot:      bundle.Storage.StorageSlot,
+				StorageValue:     bundle.Storage.StorageValue,
+				L1WorldStateRoot: l1Header.Root,
+			},
+			bundle.L1Header.RLP,
+			types.RawProof(bundle.Storage.StorageProof),
+			bundle.Storage.RlpEncodedAccount,
+			types.RawProof(bundle.Storage.AccountProof),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack proveL1Native calldata: %w", err)
+		}
+	case "proveNative":
+		if bundle.L2Header == nil || bundle.UpdateArgs == nil || bundle.SrcChainID == nil {
+			return nil, fmt.Errorf("proveNative bundle is missing its L2 header, update args or source chain ID")
+		}
+		l2Header, err := decodeBundleHeader("L2", bundle.L2Header)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyBundleStorage(l2Header, &bundle.Storage); err != nil {
+			return nil, fmt.Errorf("failed to verify L2 storage proof: %w", err)
+		}
+		encoded.SettledStateProof, err = provers.EncodeSettledStateProof(bundle.ConfigType, bundle.SettledStateProof)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Calldata, err = nativeProver.EncodeProveNativeCalldata(
+			bundle.UpdateArgs.Args(),
+			types.ProveScalarArgs{
+				ChainID:          bundle.SrcChainID,
+				ContractAddr:     bundle.Storage.Address,
+				StorageSlot:      bundle.Storage.StorageSlot,
+				StorageValue:     bundle.Storage.StorageValue,
+				L2WorldStateRoot: l2Header.Root,
+			},
+			bundle.L1Header.RLP,
+			bundle.L2Header.RLP,
+			encoded.SettledStateProof,
+			types.RawProof(bundle.Storage.StorageProof),
+			bundle.Storage.RlpEncodedAccount,
+			types.RawProof(bundle.Storage.AccountProof),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack proveNative calldata: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown proof bundle method %q", bundle.Method)
+	}
+	return &encoded, nil
+}
+
+// decodeBundleHeader decodes the RLP of a bundle header and checks it against the hash and
+// number saved with it
+func decodeBundleHeader(name string, header *types.BundleHeader) (*types2.Header, error) {
+	var decoded types2.Header
+	if err := rlp.DecodeBytes(header.RLP, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode %s header: %w", name, err)
+	}
+	if decoded.Hash() != header.Hash {
+		return nil, fmt.Errorf("%s header RLP hashes to %s, bundle has %s", name, decoded.Hash(), header.Hash)
+	}
+	if header.Number != nil && decoded.Number.Cmp(header.Number) != 0 {
+		return nil, fmt.Errorf("%s header RLP is block %s, bundle has %s", name, decoded.Number, header.Number)
+	}
+	return &decoded, nil
+}
+
+// verifyBundleStorage checks the storage proof of a bundle against the state root of header
+func verifyBundleStorage(header *types2.Header, storage *types.BundleStorageProof) error {
+	return verify.AccountAndStorage(
+		header.Root,
+		storage.Address,
+		storage.StorageSlot,
+		storage.StorageValue,
+		types.RawProof(storage.StorageProof),
+		storage.RlpEncodedAccount,
+		types.RawProof(storage.AccountProof),
+	)
+}
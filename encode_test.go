@@ -0,0 +1,138 @@
+package fallback_prover
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCannonBundle returns a proveNative bundle whose mock settled state proof is a real Cannon
+// proof for the settled L2 header, so that it can be decoded and packed again offline
+func newCannonBundle(t *testing.T) *types2.ProofBundle {
+	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	srcStorageSlot := common.HexToHash("0x01")
+	prover, settledStateProver := newMockProver(t, srcAddress, srcStorageSlot)
+
+	generate := settledStateProver.GenerateSettledStateProofFunc
+	settledStateProver.GenerateSettledStateProofFunc = func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *types2.L2ConfigInfo) ([]byte, *types.Header, error) {
+		_, l2Header, err := generate(ctx, l1Header, outputIndex, rootAddress, config)
+		require.NoError(t, err)
+		settledStateProof, err := provers.EncodedOpstackCannonProof.Pack(
+			provers.DisputeGameFactoryProof{
+				LatestBlockHash: l2Header.Hash(),
+				GameIndex:       outputIndex,
+				GameId:          common.HexToHash("0x99"),
+			},
+			provers.FaultDisputeGameProof{
+				FaultDisputeGameStatusSlotData: provers.FaultDisputeGameStatusSlot{
+					CreatedAt:   1650000000,
+					ResolvedAt:  1650001000,
+					GameStatus:  uint8(provers.GameStatusDefenderWins),
+					Initialized: true,
+				},
+				RlpEncodedFaultDisputeGameData: []byte{0x01},
+			},
+		)
+		return settledStateProof, l2Header, err
+	}
+
+	bundle, err := prover.GenerateProveNativeBundle(context.Background(), &ProveParams{
+		Address:     srcAddress,
+		StorageSlot: srcStorageSlot,
+	})
+	require.NoError(t, err)
+	return bundle
+}
+
+func TestEncodeProofBundle(t *testing.T) {
+	bundle := newCannonBundle(t)
+
+	// Save the bundle and encode it again from the file
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	saved, err := LoadProofBundle(path)
+	require.NoError(t, err)
+	saved.Calldata = nil
+
+	encoded, err := EncodeProofBundle(saved)
+	require.NoError(t, err)
+	assert.Equal(t, bundle.Calldata, encoded.Calldata)
+	assert.Equal(t, bundle.SettledStateProof, encoded.SettledStateProof)
+	assert.Nil(t, saved.Calldata, "the saved bundle must not be modified")
+
+	// Inputs that do not match their hashes or state roots are rejected
+	tampered := *saved
+	tampered.L1Header.Hash = common.HexToHash("0xbad")
+	_, err = EncodeProofBundle(&tampered)
+	require.ErrorContains(t, err, "L1 header RLP hashes to")
+
+	tampered = *saved
+	tampered.Storage.StorageValue = common.HexToHash("0x456")
+	_, err = EncodeProofBundle(&tampered)
+	require.ErrorContains(t, err, "failed to verify L2 storage proof")
+
+	tampered = *saved
+	tampered.SettledStateProof = []byte("settled-state-proof")
+	_, err = EncodeProofBundle(&tampered)
+	require.ErrorContains(t, err, "failed to decode Cannon settled state proof")
+
+	tampered = *saved
+	tampered.Method = "prove"
+	_, err = EncodeProofBundle(&tampered)
+	require.ErrorContains(t, err, `unknown proof bundle method "prove"`)
+}
+
+func TestEncodeProofBundle_L1(t *testing.T) {
+	l1Address := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	l1StorageSlot := common.HexToHash("0x01")
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(l1Address, l1StorageSlot, common.HexToHash("0x123"))
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
+	rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
+	require.NoError(t, err)
+
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+	prover := &L1Prover{
+		l1OriginProver: &testutil.MockL1OriginProver{
+			GetL1OriginFunc: func(ctx context.Context, l1Hash common.Hash) ([]byte, *types.Header, error) {
+				return rlpEncodedL1Header, l1Header, nil
+			},
+		},
+		l1StorageProver: &testutil.MockStorageProver{
+			GetStorageAtFunc: func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (string, error) {
+				return common.HexToHash("0x123").Hex(), nil
+			},
+			GenerateStorageProofFunc: func(ctx context.Context, contractAddr common.Address, storageSlot common.Hash, blockNumber *big.Int) ([][]byte, []byte, [][]byte, error) {
+				storageProof, account, accountProof := l1State.ProofBytes(t, contractAddr, storageSlot)
+				return storageProof, account, accountProof, nil
+			},
+		},
+		nativeProver: nativeProver,
+	}
+
+	bundle, err := prover.GenerateProveL1Bundle(context.Background(), &ProveParams{
+		Address:     l1Address,
+		StorageSlot: l1StorageSlot,
+	})
+	require.NoError(t, err)
+
+	encoded, err := EncodeProofBundle(bundle)
+	require.NoError(t, err)
+	assert.Equal(t, bundle.Calldata, encoded.Calldata)
+}
@@ -2,14 +2,24 @@ package fallback_prover
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/polymerdao/fallback_prover/provers"
 )
 
 const EnvVarPrefix = "FALLBACK_PROVER"
 
 const DefaultRegistryAddress = "0x0000000000000000000000000000000000000000"
 
+const (
+	// OutputText prints the calldata as hex
+	OutputText = "text"
+	// OutputJSON prints a JSON proof bundle with the calldata and every input it was generated from
+	OutputJSON = "json"
+)
+
 func prefixEnvVars(names ...string) []string {
 	envs := make([]string, 0, len(names))
 	for _, name := range names {
@@ -60,6 +70,12 @@ var (
 		EnvVars: prefixEnvVars("SRC_L2_STORAGE_SLOT"),
 		Value:   DefaultRegistryAddress,
 	}
+	L1RegistryL2ConfigMappingSlot = &cli.Uint64Flag{
+		Name: "l1-registry-l2-config-mapping-slot",
+		Usage: fmt.Sprintf("Storage slot of the l2ChainConfigurationHashMap mapping in the L1 registry, "+
+			"defaults to %d or the one of native-prover-address", provers.DefaultL2ConfigMappingSlot),
+		EnvVars: prefixEnvVars("L1_REGISTRY_L2_CONFIG_MAPPING_SLOT"),
+	}
 	WaitForNewEpoch = &cli.BoolFlag{
 		Name: "wait-for-new-epoch",
 		Usage: "Wait for a new L2 epoch before constructing proof if true." +
@@ -79,6 +95,144 @@ var (
 		EnvVars: prefixEnvVars("EPOCH_POLLING_TRIES"),
 		Value:   10,
 	}
+	L2BlockNumber = &cli.Uint64Flag{
+		Name: "l2-block-number",
+		Usage: "Prove against the earliest settled source L2 block at or after this number instead of the " +
+			"latest settled state",
+		EnvVars: prefixEnvVars("L2_BLOCK_NUMBER"),
+	}
+	ListenAddr = &cli.StringFlag{
+		Name:    "listen-addr",
+		Usage:   "Address the serve command listens on for JSON-RPC requests",
+		EnvVars: prefixEnvVars("LISTEN_ADDR"),
+		Value:   "127.0.0.1:8547",
+	}
+	SettledStateTTL = &cli.DurationFlag{
+		Name:    "settled-state-ttl",
+		Usage:   "How long the serve command reuses the latest settled state of a chain pair before resolving it again",
+		EnvVars: prefixEnvVars("SETTLED_STATE_TTL"),
+		Value:   time.Minute,
+	}
+	L2RPC = &cli.StringSliceFlag{
+		Name:    "l2-rpc",
+		Usage:   "RPC URL of a source or destination L2 for the serve command, as <chain-id>=<url>. May be repeated",
+		EnvVars: prefixEnvVars("L2_RPC"),
+	}
+	SrcStorageTarget = &cli.StringSliceFlag{
+		Name: "src-storage-target",
+		Usage: "Contract address and storage slot on the source L2 to prove, as <address>=<slot>. May be repeated " +
+			"to prove several slots against the same L1 origin, instead of src-contract-address and src-storage-slot",
+		EnvVars: prefixEnvVars("SRC_STORAGE_TARGET"),
+	}
+	SrcStorageTargetsFile = &cli.StringFlag{
+		Name: "src-storage-targets-file",
+		Usage: "JSON file with an array of {address, storageSlot} objects, or CSV file of address,slot lines, " +
+			"to prove against the same L1 origin instead of src-contract-address and src-storage-slot",
+		EnvVars: prefixEnvVars("SRC_STORAGE_TARGETS_FILE"),
+	}
+	Multicall = &cli.BoolFlag{
+		Name: "multicall",
+		Usage: "Print a single Multicall3 aggregate3 calldata wrapping the proveNative calls of every storage " +
+			"target instead of one calldata per target. Requires native-prover-address",
+		EnvVars: prefixEnvVars("MULTICALL"),
+		Value:   false,
+	}
+	Multicall3Address = &cli.StringFlag{
+		Name:    "multicall3-address",
+		Usage:   "Address of Multicall3 on the destination L2",
+		EnvVars: prefixEnvVars("MULTICALL3_ADDRESS"),
+		Value:   provers.Multicall3Address.Hex(),
+	}
+	AllowFailure = &cli.BoolFlag{
+		Name:    "allow-failure",
+		Usage:   "Let the other calls of a multicall bundle succeed when one of them reverts",
+		EnvVars: prefixEnvVars("ALLOW_FAILURE"),
+		Value:   false,
+	}
+	SafeTxBuilderFile = &cli.StringFlag{
+		Name:    "safe-tx-builder-file",
+		Usage:   "Also write the multicall bundle as a Safe Transaction Builder JSON file to this path",
+		EnvVars: prefixEnvVars("SAFE_TX_BUILDER_FILE"),
+	}
+	OptimismPortalAddress = &cli.StringFlag{
+		Name: "optimism-portal-address",
+		Usage: "Address of the OptimismPortal2 of an OPStackCannon source L2. When set, only dispute games of its " +
+			"respected game type that it has not blacklisted are proven against",
+		EnvVars: prefixEnvVars("OPTIMISM_PORTAL_ADDRESS"),
+	}
+	OptimismPortal = &cli.StringSliceFlag{
+		Name: "optimism-portal",
+		Usage: "OptimismPortal2 address of an OPStackCannon source L2 for the serve command, as " +
+			"<chain-id>=<address>. May be repeated",
+		EnvVars: prefixEnvVars("OPTIMISM_PORTAL"),
+	}
+	AllowUnverifiedNitroProofs = &cli.BoolFlag{
+		Name: "allow-unverified-nitro-proofs",
+		Usage: "Generate settled state proofs for Arbitrum Nitro source L2s, whose encoding is not checked " +
+			"against an on-chain verifier and may be rejected",
+		EnvVars: prefixEnvVars("ALLOW_UNVERIFIED_NITRO_PROOFS"),
+		Value:   false,
+	}
+	NativeProver = &cli.StringSliceFlag{
+		Name: "native-prover",
+		Usage: "NativeProver address of a destination L2 for the serve command, as <chain-id>=<address>. " +
+			"The L1 registry, its mapping slot and the L1 block hash oracle are read from its L1_CONFIGURATION(). " +
+			"May be repeated",
+		EnvVars: prefixEnvVars("NATIVE_PROVER"),
+	}
+	Output = &cli.StringFlag{
+		Name: "output",
+		Usage: "Output format: text prints the calldata as hex, json prints a proof bundle with the calldata and " +
+			"every input it was generated from",
+		EnvVars: prefixEnvVars("OUTPUT"),
+		Value:   OutputText,
+	}
+	FromBlock = &cli.Uint64Flag{
+		Name:    "from-block",
+		Usage:   "First L1 block the registry commands read registry logs from",
+		EnvVars: prefixEnvVars("FROM_BLOCK"),
+	}
+	LogBlockRange = &cli.Uint64Flag{
+		Name: "log-block-range",
+		Usage: "Most L1 blocks the registry commands request in one eth_getLogs call, for nodes that limit " +
+			"log queries. 0 requests all blocks at once",
+		EnvVars: prefixEnvVars("LOG_BLOCK_RANGE"),
+	}
+	Simulate = &cli.BoolFlag{
+		Name: "simulate",
+		Usage: "Dry-run the generated calldata with eth_call against the NativeProver on the destination L2 " +
+			"and report the result. Requires native-prover-address",
+		EnvVars: prefixEnvVars("SIMULATE"),
+		Value:   false,
+	}
+	NativeProverAddress = &cli.StringFlag{
+		Name: "native-prover-address",
+		Usage: "Address of the NativeProver contract on the destination L2. The L1 registry, its mapping slot and " +
+			"the L1 block hash oracle are read from its L1_CONFIGURATION()",
+		EnvVars: prefixEnvVars("NATIVE_PROVER_ADDRESS"),
+	}
+	Submit = &cli.BoolFlag{
+		Name: "submit",
+		Usage: "Sign the generated calldata, send it to the NativeProver on the destination L2 and wait for the " +
+			"proven event. Requires native-prover-address and private-key-file or keystore-file",
+		EnvVars: prefixEnvVars("SUBMIT"),
+		Value:   false,
+	}
+	PrivateKeyFile = &cli.StringFlag{
+		Name:    "private-key-file",
+		Usage:   "File holding the hex encoded private key used with submit",
+		EnvVars: prefixEnvVars("PRIVATE_KEY_FILE"),
+	}
+	KeystoreFile = &cli.StringFlag{
+		Name:    "keystore-file",
+		Usage:   "Encrypted keystore file holding the key used with submit",
+		EnvVars: prefixEnvVars("KEYSTORE_FILE"),
+	}
+	KeystorePasswordFile = &cli.StringFlag{
+		Name:    "keystore-password-file",
+		Usage:   "File holding the password for keystore-file",
+		EnvVars: prefixEnvVars("KEYSTORE_PASSWORD_FILE"),
+	}
 )
 
 var requiredProveFlags = []cli.Flag{
@@ -99,11 +253,48 @@ var requiredProveL1Flags = []cli.Flag{
 	SrcStorageSlot,
 }
 
+var requiredServeFlags = []cli.Flag{
+	L1HTTPPath,
+	L2RPC,
+}
+
+var optionalServeFlags = []cli.Flag{
+	ListenAddr,
+	SettledStateTTL,
+	L1RegistryAddress,
+	OptimismPortal,
+	AllowUnverifiedNitroProofs,
+	NativeProver,
+	EpochPollingFreq,
+	EpochPollingTries,
+}
+
+// optionalL2Flags only apply to the prove commands for a source L2
+var optionalL2Flags = []cli.Flag{
+	L2BlockNumber,
+	SrcStorageTarget,
+	SrcStorageTargetsFile,
+	Multicall,
+	Multicall3Address,
+	AllowFailure,
+	SafeTxBuilderFile,
+	OptimismPortalAddress,
+	AllowUnverifiedNitroProofs,
+	L1RegistryL2ConfigMappingSlot,
+}
+
 var optionalFlags = []cli.Flag{
 	L1RegistryAddress,
+	Output,
 	WaitForNewEpoch,
 	EpochPollingFreq,
 	EpochPollingTries,
+	Simulate,
+	NativeProverAddress,
+	Submit,
+	PrivateKeyFile,
+	KeystoreFile,
+	KeystorePasswordFile,
 }
 
 // L2Flags contains the list of configuration options available for the prove commands
@@ -112,13 +303,62 @@ var L2Flags []cli.Flag
 // L1Flags contains the list of configuration options available for the proveL1 commands
 var L1Flags []cli.Flag
 
+// ServeFlags contains the list of configuration options available for the serve command
+var ServeFlags []cli.Flag
+
+// EncodeFlags contains the list of configuration options available for the encode command
+var EncodeFlags = []cli.Flag{Output}
+
+// InspectFlags contains the list of configuration options available for the inspect command
+var InspectFlags = []cli.Flag{Output}
+
+var requiredRegistryFlags = []cli.Flag{
+	L1HTTPPath,
+	L1RegistryAddress,
+}
+
+// RegistryFlags contains the list of configuration options available for the registry commands
+var RegistryFlags = append(requiredRegistryFlags, FromBlock, LogBlockRange, Output)
+
+// RegistryUpdateFlags contains the list of configuration options available for the registry
+// update commands
+var RegistryUpdateFlags = append(requiredRegistryFlags, Output)
+
 func init() {
-	L2Flags = append(requiredProveFlags, optionalFlags...)
+	L2Flags = append(append(requiredProveFlags, optionalL2Flags...), optionalFlags...)
 	L1Flags = append(requiredProveL1Flags, optionalFlags...)
+	ServeFlags = append(requiredServeFlags, optionalServeFlags...)
 }
 
 func CheckRequiredL2(ctx *cli.Context) error {
+	batch := ctx.IsSet(SrcStorageTarget.Name) || ctx.IsSet(SrcStorageTargetsFile.Name)
 	for _, f := range requiredProveFlags {
+		// A batch of storage targets replaces the single contract address and slot
+		if batch && (f == SrcContractAddress || f == SrcStorageSlot) {
+			if ctx.IsSet(f.Names()[0]) {
+				return fmt.Errorf("flag %s cannot be combined with %s or %s",
+					f.Names()[0], SrcStorageTarget.Name, SrcStorageTargetsFile.Name)
+			}
+			continue
+		}
+		if !ctx.IsSet(f.Names()[0]) {
+			return fmt.Errorf("flag %s is required", f.Names()[0])
+		}
+	}
+	return nil
+}
+
+func CheckRequiredServe(ctx *cli.Context) error {
+	for _, f := range requiredServeFlags {
+		if !ctx.IsSet(f.Names()[0]) {
+			return fmt.Errorf("flag %s is required", f.Names()[0])
+		}
+	}
+	return nil
+}
+
+func CheckRequiredRegistry(ctx *cli.Context) error {
+	for _, f := range requiredRegistryFlags {
 		if !ctx.IsSet(f.Names()[0]) {
 			return fmt.Errorf("flag %s is required", f.Names()[0])
 		}
@@ -134,3 +374,51 @@ func CheckRequiredL1(ctx *cli.Context) error {
 	}
 	return nil
 }
+
+// CheckOutput validates the output format
+func CheckOutput(ctx *cli.Context) error {
+	switch ctx.String(Output.Name) {
+	case OutputText, OutputJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid %s %q: expected %s or %s", Output.Name, ctx.String(Output.Name), OutputText, OutputJSON)
+}
+
+// CheckSimulate validates the flags needed to simulate the generated calldata
+func CheckSimulate(ctx *cli.Context) error {
+	if ctx.Bool(Simulate.Name) && !ctx.IsSet(NativeProverAddress.Name) {
+		return fmt.Errorf("flag %s is required with %s", NativeProverAddress.Name, Simulate.Name)
+	}
+	return nil
+}
+
+// CheckMulticall validates the flags needed to bundle the generated calldata with Multicall3
+func CheckMulticall(ctx *cli.Context) error {
+	if !ctx.Bool(Multicall.Name) {
+		if ctx.IsSet(SafeTxBuilderFile.Name) {
+			return fmt.Errorf("flag %s requires %s", SafeTxBuilderFile.Name, Multicall.Name)
+		}
+		return nil
+	}
+	if !ctx.IsSet(NativeProverAddress.Name) {
+		return fmt.Errorf("flag %s is required with %s", NativeProverAddress.Name, Multicall.Name)
+	}
+	if ctx.Bool(Submit.Name) {
+		return fmt.Errorf("flag %s cannot be combined with %s", Submit.Name, Multicall.Name)
+	}
+	return nil
+}
+
+// CheckSubmit validates the flags needed to submit the generated calldata
+func CheckSubmit(ctx *cli.Context) error {
+	if !ctx.Bool(Submit.Name) {
+		return nil
+	}
+	if !ctx.IsSet(NativeProverAddress.Name) {
+		return fmt.Errorf("flag %s is required with %s", NativeProverAddress.Name, Submit.Name)
+	}
+	if ctx.IsSet(PrivateKeyFile.Name) == ctx.IsSet(KeystoreFile.Name) {
+		return fmt.Errorf("exactly one of %s and %s is required with %s", PrivateKeyFile.Name, KeystoreFile.Name, Submit.Name)
+	}
+	return nil
+}
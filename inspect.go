@@ -0,0 +1,371 @@
+package fallback_prover
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	types2 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// CalldataInspection is decoded proveNative or proveL1Native calldata
+type CalldataInspection struct {
+	// Bundle holds the decoded arguments, and can be encoded again with EncodeProofBundle
+	Bundle *types.ProofBundle `json:"bundle"`
+	// SettledStateProof is the decoded settled state proof of an OP Stack proveNative call
+	SettledStateProof *DecodedSettledStateProof `json:"decodedSettledStateProof,omitempty"`
+	// Problems lists the inputs that are inconsistent with each other and would make the call revert
+	Problems []string `json:"problems,omitempty"`
+}
+
+// DecodedSettledStateProof is a settled state proof decoded by its L2 type
+type DecodedSettledStateProof struct {
+	Bedrock *DecodedBedrockProof `json:"bedrock,omitempty"`
+	Cannon  *DecodedCannonProof  `json:"cannon,omitempty"`
+}
+
+// DecodedBedrockProof is the RLP list of an OP Stack Bedrock settled state proof
+type DecodedBedrockProof struct {
+	MessagePasserStateRoot     common.Hash     `json:"messagePasserStateRoot"`
+	OutputIndex                *big.Int        `json:"outputIndex"`
+	L1StorageProof             []hexutil.Bytes `json:"l1StorageProof"`
+	RlpEncodedOutputOracleData hexutil.Bytes   `json:"rlpEncodedOutputOracleData"`
+	L1AccountProof             []hexutil.Bytes `json:"l1AccountProof"`
+}
+
+// DecodedCannonProof is the EncodedOpstackCannonProof tuple of an OP Stack Cannon settled state proof
+type DecodedCannonProof struct {
+	Factory          DecodedDisputeGameFactoryProof `json:"disputeGameFactory"`
+	FaultDisputeGame DecodedFaultDisputeGameProof   `json:"faultDisputeGame"`
+}
+
+// DecodedDisputeGameFactoryProof is the DisputeGameFactoryProofData of a Cannon settled state
+// proof, with its game ID unpacked
+type DecodedDisputeGameFactoryProof struct {
+	MessagePasserStateRoot           common.Hash     `json:"messagePasserStateRoot"`
+	LatestBlockHash                  common.Hash     `json:"latestBlockHash"`
+	GameIndex                        *big.Int        `json:"gameIndex"`
+	GameId                           common.Hash     `json:"gameId"`
+	GameType                         uint32          `json:"gameType"`
+	GameTimestamp                    uint64          `json:"gameTimestamp"`
+	GameAddress                      common.Address  `json:"gameAddress"`
+	DisputeFaultGameStorageProof     []hexutil.Bytes `json:"disputeFaultGameStorageProof"`
+	RlpEncodedDisputeGameFactoryData hexutil.Bytes   `json:"rlpEncodedDisputeGameFactoryData"`
+	DisputeGameFactoryAccountProof   []hexutil.Bytes `json:"disputeGameFactoryAccountProof"`
+}
+
+// DecodedFaultDisputeGameProof is the FaultDisputeGameProofData of a Cannon settled state proof
+type DecodedFaultDisputeGameProof struct {
+	StateRoot               common.Hash     `json:"stateRoot"`
+	RootClaimStorageProof   []hexutil.Bytes `json:"rootClaimStorageProof"`
+	CreatedAt               uint64          `json:"createdAt"`
+	ResolvedAt              uint64          `json:"resolvedAt"`
+	GameStatus              string          `json:"gameStatus"`
+	Initialized             bool            `json:"initialized"`
+	L2BlockNumberChallenged bool            `json:"l2BlockNumberChallenged"`
+	StatusStorageProof      []hexutil.Bytes `json:"statusStorageProof"`
+	RlpEncodedGameData      hexutil.Bytes   `json:"rlpEncodedGameData"`
+	AccountProof            []hexutil.Bytes `json:"accountProof"`
+}
+
+// InspectCalldata decodes proveNative or proveL1Native calldata with the NativeProver ABI, RLP
+// decodes its headers and decodes the settled state proof of OP Stack chains. Inputs that do not
+// match each other are reported as problems rather than errors, so broken calldata can be inspected.
+func InspectCalldata(calldata []byte) (*CalldataInspection, error) {
+	nativeProver, err := provers.NewNativeProver()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize NativeProver: %w", err)
+	}
+	method, call, err := nativeProver.DecodeProveCalldata(calldata)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := &CalldataInspection{}
+	switch call := call.(type) {
+	case *types.ProveL1NativeCall:
+		inspection.Bundle = &types.ProofBundle{
+			Method:   method,
+			Calldata: calldata,
+			Storage: types.BundleStorageProof{
+				Address:           call.Args.ContractAddr,
+				StorageSlot:       call.Args.StorageSlot,
+				StorageValue:      call.Args.StorageValue,
+				StorageProof:      types.HexProof(call.L1StorageProof),
+				RlpEncodedAccount: call.RlpEncodedContractAccount,
+				AccountProof:      types.HexProof(call.L1AccountProof),
+			},
+		}
+		l1Header := inspection.header("L1", call.RlpEncodedL1Header, &inspection.Bundle.L1Header)
+		inspection.checkStorage("L1", l1Header, call.Args.L1WorldStateRoot)
+	case *types.ProveNativeCall:
+		configType := provers.ConfigTypeName(call.UpdateArgs.Config.L2Type)
+		inspection.Bundle = &types.ProofBundle{
+			Method:            method,
+			Calldata:          calldata,
+			SrcChainID:        call.ProveArgs.ChainID,
+			ConfigType:        configType,
+			L2Header:          &types.BundleHeader{},
+			SettledStateProof: call.SettledStateProof,
+			UpdateArgs:        types.NewBundleUpdateArgs(&call.UpdateArgs),
+			Storage: types.BundleStorageProof{
+				Address:           call.ProveArgs.ContractAddr,
+				StorageSlot:       call.ProveArgs.StorageSlot,
+				StorageValue:      call.ProveArgs.StorageValue,
+				StorageProof:      types.HexProof(call.L2StorageProof),
+				RlpEncodedAccount: call.RlpEncodedContractAccount,
+				AccountProof:      types.HexProof(call.L2AccountProof),
+			},
+		}
+		inspection.header("L1", call.RlpEncodedL1Header, &inspection.Bundle.L1Header)
+		l2Header := inspection.header("L2", call.RlpEncodedL2Header, inspection.Bundle.L2Header)
+		inspection.checkStorage("L2", l2Header, call.ProveArgs.L2WorldStateRoot)
+		inspection.settledStateProof(configType, call.SettledStateProof, l2Header)
+	}
+	return inspection, nil
+}
+
+// header RLP decodes a header of the calldata into bundle. It returns nil and records a problem
+// if the RLP does not decode.
+func (i *CalldataInspection) header(name string, rlpEncoded []byte, bundle *types.BundleHeader) *types2.Header {
+	var header types2.Header
+	if err := rlp.DecodeBytes(rlpEncoded, &header); err != nil {
+		i.problem("failed to decode %s header: %v", name, err)
+		bundle.RLP = rlpEncoded
+		return nil
+	}
+	*bundle = types.NewBundleHeader(&header, rlpEncoded)
+	return &header
+}
+
+// checkStorage records a problem if the proven state root is not the one of header or the
+// storage proof does not verify against it
+func (i *CalldataInspection) checkStorage(name string, header *types2.Header, stateRoot common.Hash) {
+	if header == nil {
+		return
+	}
+	if header.Root != stateRoot {
+		i.problem("%s world state root %s is not the state root %s of the %s header", name, stateRoot, header.Root, name)
+		return
+	}
+	if err := verifyBundleStorage(header, &i.Bundle.Storage); err != nil {
+		i.problem("%s storage proof does not verify: %v", name, err)
+	}
+}
+
+// settledStateProof decodes an OP Stack settled state proof and rebuilds its output root
+func (i *CalldataInspection) settledStateProof(configType string, proof []byte, l2Header *types2.Header) {
+	switch configType {
+	case "OPStackBedrock":
+		decoded, err := provers.DecodeBedrockSettledStateProof(proof)
+		if err != nil {
+			i.problem("%v", err)
+			return
+		}
+		i.SettledStateProof = &DecodedSettledStateProof{Bedrock: &DecodedBedrockProof{
+			MessagePasserStateRoot:     decoded.MessagePasserStateRoot,
+			OutputIndex:                new(big.Int).SetBytes(decoded.OutputIndex),
+			L1StorageProof:             types.HexProof(decoded.L1StorageProof),
+			RlpEncodedOutputOracleData: decoded.RlpEncodedOutputOracleData,
+			L1AccountProof:             types.HexProof(decoded.L1AccountProof),
+		}}
+	case "OPStackCannon":
+		factoryData, faultData, err := provers.DecodeCannonSettledStateProof(proof)
+		if err != nil {
+			i.problem("%v", err)
+			return
+		}
+		gameType, timestamp, gameAddress := provers.UnpackGameId(factoryData.GameId)
+		status := faultData.FaultDisputeGameStatusSlotData
+		i.SettledStateProof = &DecodedSettledStateProof{Cannon: &DecodedCannonProof{
+			Factory: DecodedDisputeGameFactoryProof{
+				MessagePasserStateRoot:           factoryData.MessagePasserStateRoot,
+				LatestBlockHash:                  factoryData.LatestBlockHash,
+				GameIndex:                        factoryData.GameIndex,
+				GameId:                           factoryData.GameId,
+				GameType:                         gameType,
+				GameTimestamp:                    timestamp,
+				GameAddress:                      gameAddress,
+				DisputeFaultGameStorageProof:     types.HexProof(factoryData.DisputeFaultGameStorageProof),
+				RlpEncodedDisputeGameFactoryData: factoryData.RlpEncodedDisputeGameFactoryData,
+				DisputeGameFactoryAccountProof:   types.HexProof(factoryData.DisputeGameFactoryAccountProof),
+			},
+			FaultDisputeGame: DecodedFaultDisputeGameProof{
+				StateRoot:               faultData.FaultDisputeGameStateRoot,
+				RootClaimStorageProof:   types.HexProof(faultData.FaultDisputeGameRootClaimStorageProof),
+				CreatedAt:               status.CreatedAt,
+				ResolvedAt:              status.ResolvedAt,
+				GameStatus:              provers.GameStatus(status.GameStatus).String(),
+				Initialized:             status.Initialized,
+				L2BlockNumberChallenged: status.L2BlockNumberChallenged,
+				StatusStorageProof:      types.HexProof(faultData.FaultDisputeGameStatusStorageProof),
+				RlpEncodedGameData:      faultData.RlpEncodedFaultDisputeGameData,
+				AccountProof:            types.HexProof(faultData.FaultDisputeGameAccountProof),
+			},
+		}}
+		if l2Header != nil && common.Hash(factoryData.LatestBlockHash) != l2Header.Hash() {
+			i.problem("settled state proof is for L2 block %s, not the L2 header %s",
+				common.Hash(factoryData.LatestBlockHash), l2Header.Hash())
+		}
+	default:
+		return
+	}
+
+	if l2Header != nil {
+		outputRoot, _, err := provers.SettledOutputRoot(configType, proof, l2Header)
+		if err == nil {
+			i.Bundle.OutputRoot = &outputRoot
+		}
+	}
+}
+
+func (i *CalldataInspection) problem(format string, args ...interface{}) {
+	i.Problems = append(i.Problems, fmt.Sprintf(format, args...))
+}
+
+// WriteTree writes the inspection as an indented tree. Proofs and RLP are summarized by their
+// size; the JSON form holds them in full.
+func (i *CalldataInspection) WriteTree(w io.Writer) error {
+	t := &treeWriter{w: w}
+	b := i.Bundle
+	t.line("%s (%d bytes)", b.Method, len(b.Calldata))
+	t.indent(func() {
+		t.header("L1 header", &b.L1Header)
+		if b.Method == "proveNative" {
+			t.line("source chain: %s (%s)", b.SrcChainID, b.ConfigType)
+			t.header("L2 header", b.L2Header)
+			if b.OutputRoot != nil {
+				t.line("output root: %s", b.OutputRoot)
+			}
+			t.line("update args")
+			t.indent(func() {
+				config := b.UpdateArgs.Config
+				t.line("prover: %s", config.Prover)
+				t.line("addresses: %s", joinStrings(config.Addresses))
+				t.line("storage slots: %s", joinStrings(config.StorageSlots))
+				t.line("version: %s", config.VersionNumber)
+				t.line("finality delay: %ss", config.FinalityDelaySeconds)
+				t.line("l2 type: %s", b.ConfigType)
+				t.proof("L1 storage proof", b.UpdateArgs.L1StorageProof)
+				t.line("registry account: %d bytes", len(b.UpdateArgs.RlpEncodedRegistryAccountData))
+				t.proof("registry account proof", b.UpdateArgs.L1RegistryProof)
+			})
+			t.settledStateProof(i.SettledStateProof, len(b.SettledStateProof))
+		}
+		t.line("storage")
+		t.indent(func() {
+			t.line("address: %s", b.Storage.Address)
+			t.line("slot: %s", b.Storage.StorageSlot)
+			t.line("value: %s", b.Storage.StorageValue)
+			t.proof("storage proof", b.Storage.StorageProof)
+			t.line("account: %d bytes", len(b.Storage.RlpEncodedAccount))
+			t.proof("account proof", b.Storage.AccountProof)
+		})
+		if len(i.Problems) > 0 {
+			t.line("problems")
+			t.indent(func() {
+				for _, problem := range i.Problems {
+					t.line("%s", problem)
+				}
+			})
+		}
+	})
+	return t.err
+}
+
+// treeWriter writes indented lines and keeps the first write error
+type treeWriter struct {
+	w     io.Writer
+	depth int
+	err   error
+}
+
+func (t *treeWriter) line(format string, args ...interface{}) {
+	if t.err != nil {
+		return
+	}
+	_, t.err = fmt.Fprintf(t.w, "%s%s\n", strings.Repeat("  ", t.depth), fmt.Sprintf(format, args...))
+}
+
+func (t *treeWriter) indent(fn func()) {
+	t.depth++
+	fn()
+	t.depth--
+}
+
+func (t *treeWriter) header(name string, header *types.BundleHeader) {
+	if header.Number == nil {
+		t.line("%s: %d bytes, not decodable", name, len(header.RLP))
+		return
+	}
+	t.line("%s: #%s %s", name, header.Number, header.Hash)
+	t.indent(func() {
+		t.line("state root: %s", header.StateRoot)
+		t.line("timestamp: %d", header.Timestamp)
+	})
+}
+
+func (t *treeWriter) proof(name string, proof []hexutil.Bytes) {
+	size := 0
+	for _, node := range proof {
+		size += len(node)
+	}
+	t.line("%s: %d nodes, %d bytes", name, len(proof), size)
+}
+
+func (t *treeWriter) settledStateProof(decoded *DecodedSettledStateProof, size int) {
+	switch {
+	case decoded != nil && decoded.Bedrock != nil:
+		proof := decoded.Bedrock
+		t.line("settled state proof (Bedrock)")
+		t.indent(func() {
+			t.line("output index: %s", proof.OutputIndex)
+			t.line("message passer storage root: %s", proof.MessagePasserStateRoot)
+			t.proof("output storage proof", proof.L1StorageProof)
+			t.line("output oracle account: %d bytes", len(proof.RlpEncodedOutputOracleData))
+			t.proof("output oracle account proof", proof.L1AccountProof)
+		})
+	case decoded != nil && decoded.Cannon != nil:
+		factory, game := decoded.Cannon.Factory, decoded.Cannon.FaultDisputeGame
+		t.line("settled state proof (Cannon)")
+		t.indent(func() {
+			t.line("dispute game factory")
+			t.indent(func() {
+				t.line("game index: %s", factory.GameIndex)
+				t.line("game: %s (type %d, created %d)", factory.GameAddress, factory.GameType, factory.GameTimestamp)
+				t.line("message passer storage root: %s", factory.MessagePasserStateRoot)
+				t.line("latest block hash: %s", factory.LatestBlockHash)
+				t.proof("game storage proof", factory.DisputeFaultGameStorageProof)
+				t.line("factory account: %d bytes", len(factory.RlpEncodedDisputeGameFactoryData))
+				t.proof("factory account proof", factory.DisputeGameFactoryAccountProof)
+			})
+			t.line("fault dispute game")
+			t.indent(func() {
+				t.line("status: %s (created %d, resolved %d)", game.GameStatus, game.CreatedAt, game.ResolvedAt)
+				t.line("initialized: %t, l2 block number challenged: %t", game.Initialized, game.L2BlockNumberChallenged)
+				t.line("state root: %s", game.StateRoot)
+				t.proof("root claim storage proof", game.RootClaimStorageProof)
+				t.proof("status storage proof", game.StatusStorageProof)
+				t.line("game account: %d bytes", len(game.RlpEncodedGameData))
+				t.proof("game account proof", game.AccountProof)
+			})
+		})
+	default:
+		t.line("settled state proof: %d bytes", size)
+	}
+}
+
+// joinStrings formats a list of values separated by commas
+func joinStrings[T fmt.Stringer](values []T) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = value.String()
+	}
+	return strings.Join(parts, ", ")
+}
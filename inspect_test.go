@@ -0,0 +1,90 @@
+package fallback_prover
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectCalldata(t *testing.T) {
+	bundle := newCannonBundle(t)
+
+	inspection, err := InspectCalldata(bundle.Calldata)
+	require.NoError(t, err)
+	assert.Empty(t, inspection.Problems)
+
+	decoded := inspection.Bundle
+	assert.Equal(t, "proveNative", decoded.Method)
+	assert.Equal(t, bundle.L1Header, decoded.L1Header)
+	assert.Equal(t, bundle.L2Header, decoded.L2Header)
+	assert.Equal(t, bundle.SrcChainID, decoded.SrcChainID)
+	assert.Equal(t, bundle.Storage, decoded.Storage)
+	assert.Equal(t, bundle.SettledStateProof, decoded.SettledStateProof)
+	assert.Equal(t, bundle.UpdateArgs.Config.VersionNumber, decoded.UpdateArgs.Config.VersionNumber)
+	assert.Equal(t, bundle.OutputRoot, decoded.OutputRoot)
+
+	require.NotNil(t, inspection.SettledStateProof)
+	require.NotNil(t, inspection.SettledStateProof.Cannon)
+	factory := inspection.SettledStateProof.Cannon.Factory
+	assert.Equal(t, big.NewInt(9), factory.GameIndex)
+	assert.Equal(t, bundle.L2Header.Hash, factory.LatestBlockHash)
+	assert.Equal(t, common.HexToAddress("0x99"), factory.GameAddress)
+	assert.Equal(t, provers.GameStatusDefenderWins.String(), inspection.SettledStateProof.Cannon.FaultDisputeGame.GameStatus)
+
+	// The decoded bundle encodes back to the same calldata
+	encoded, err := EncodeProofBundle(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, bundle.Calldata, encoded.Calldata)
+
+	var tree bytes.Buffer
+	require.NoError(t, inspection.WriteTree(&tree))
+	assert.Contains(t, tree.String(), "proveNative (")
+	assert.Contains(t, tree.String(), "L2 header: #"+bundle.L2Header.Number.String()+" "+bundle.L2Header.Hash.Hex())
+	assert.Contains(t, tree.String(), "settled state proof (Cannon)")
+	assert.Contains(t, tree.String(), "status: DEFENDER_WINS")
+	assert.NotContains(t, tree.String(), "problems")
+}
+
+func TestInspectCalldata_Problems(t *testing.T) {
+	bundle := newCannonBundle(t)
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+
+	// A storage value that is not the proven one
+	calldata, err := nativeProver.EncodeProveNativeCalldata(
+		bundle.UpdateArgs.Args(),
+		types.ProveScalarArgs{
+			ChainID:          bundle.SrcChainID,
+			ContractAddr:     bundle.Storage.Address,
+			StorageSlot:      bundle.Storage.StorageSlot,
+			StorageValue:     common.HexToHash("0x456"),
+			L2WorldStateRoot: bundle.L2Header.StateRoot,
+		},
+		bundle.L1Header.RLP,
+		[]byte("not-a-header"),
+		bundle.SettledStateProof,
+		types.RawProof(bundle.Storage.StorageProof),
+		bundle.Storage.RlpEncodedAccount,
+		types.RawProof(bundle.Storage.AccountProof),
+	)
+	require.NoError(t, err)
+
+	inspection, err := InspectCalldata(calldata)
+	require.NoError(t, err)
+	require.Len(t, inspection.Problems, 1)
+	assert.Contains(t, inspection.Problems[0], "failed to decode L2 header")
+
+	var tree bytes.Buffer
+	require.NoError(t, inspection.WriteTree(&tree))
+	assert.Contains(t, tree.String(), "L2 header: 12 bytes, not decodable")
+	assert.Contains(t, tree.String(), "problems")
+
+	_, err = InspectCalldata([]byte{0x01, 0x02, 0x03, 0x04})
+	assert.ErrorContains(t, err, "failed to find NativeProver method")
+}
@@ -0,0 +1,78 @@
+package fallback_prover
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/polymerdao/fallback_prover/provers"
+)
+
+// registrySettings are the registry, block hash oracle and registry mapping slots a prover
+// generates proofs for
+type registrySettings struct {
+	registryAddress common.Address
+	// blockHashOracle is zero when it is to be read from the registry
+	blockHashOracle     common.Address
+	l2ConfigMappingSlot *big.Int
+	// l1ConfigMappingSlot is only known from a NativeProver, nil otherwise
+	l1ConfigMappingSlot *big.Int
+}
+
+// resolveRegistrySettings returns the registry settings of a prover. Without a NativeProver
+// address they are the explicit ones, with the default L2 config mapping slot and no L1 config
+// mapping slot. With one, they are read from its L1_CONFIGURATION() on the destination L2, and
+// explicit settings that contradict it are rejected, since proofs for another registry or slot
+// would revert on the destination.
+func resolveRegistrySettings(
+	ctx context.Context,
+	nativeProver *provers.NativeProver,
+	dstL2Client provers.IEthClient,
+	nativeProverAddress common.Address,
+	registryAddress common.Address,
+	l2ConfigMappingSlot *big.Int,
+) (*registrySettings, error) {
+	if nativeProverAddress == (common.Address{}) {
+		if l2ConfigMappingSlot == nil {
+			l2ConfigMappingSlot = big.NewInt(provers.DefaultL2ConfigMappingSlot)
+		}
+		return &registrySettings{
+			registryAddress:     registryAddress,
+			l2ConfigMappingSlot: l2ConfigMappingSlot,
+		}, nil
+	}
+
+	config, err := nativeProver.GetL1Configuration(ctx, dstL2Client, nativeProverAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 configuration of NativeProver %s: %w", nativeProverAddress, err)
+	}
+	if registryAddress != (common.Address{}) && registryAddress != config.SettlementRegistry {
+		return nil, fmt.Errorf(
+			"%s %s contradicts settlementRegistry %s of NativeProver %s",
+			L1RegistryAddress.Name, registryAddress, config.SettlementRegistry, nativeProverAddress,
+		)
+	}
+	if l2ConfigMappingSlot != nil && l2ConfigMappingSlot.Cmp(config.SettlementRegistryL2ConfigMappingSlot) != 0 {
+		return nil, fmt.Errorf(
+			"%s %s contradicts settlementRegistryL2ConfigMappingSlot %s of NativeProver %s",
+			L1RegistryL2ConfigMappingSlot.Name, l2ConfigMappingSlot,
+			config.SettlementRegistryL2ConfigMappingSlot, nativeProverAddress,
+		)
+	}
+	log.Info("Read L1 configuration from NativeProver",
+		"nativeProver", nativeProverAddress,
+		"registry", config.SettlementRegistry,
+		"blockHashOracle", config.BlockHashOracle,
+		"l2ConfigMappingSlot", config.SettlementRegistryL2ConfigMappingSlot,
+		"l1ConfigMappingSlot", config.SettlementRegistryL1ConfigMappingSlot,
+		"settlementBlocksDelay", config.SettlementBlocksDelay)
+
+	return &registrySettings{
+		registryAddress:     config.SettlementRegistry,
+		blockHashOracle:     config.BlockHashOracle,
+		l2ConfigMappingSlot: config.SettlementRegistryL2ConfigMappingSlot,
+		l1ConfigMappingSlot: config.SettlementRegistryL1ConfigMappingSlot,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package fallback_prover
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRegistrySettings(t *testing.T) {
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+	nativeProverAddr := common.HexToAddress("0x5678")
+	registry := common.HexToAddress("0x1234")
+	oracle := common.HexToAddress("0x4200000000000000000000000000000000000015")
+
+	method := nativeProver.GetABI().Methods["L1_CONFIGURATION"]
+	dstL2Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, nativeProverAddr, *msg.To)
+			return method.Outputs.Pack(oracle, big.NewInt(25), registry, big.NewInt(5), big.NewInt(6))
+		},
+	}
+	resolve := func(nativeProverAddr, registry common.Address, slot *big.Int) (*registrySettings, error) {
+		return resolveRegistrySettings(context.Background(), nativeProver, dstL2Client, nativeProverAddr, registry, slot)
+	}
+
+	// Without a NativeProver the explicit settings are used, with the default slot
+	settings, err := resolve(common.Address{}, registry, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &registrySettings{
+		registryAddress:     registry,
+		l2ConfigMappingSlot: big.NewInt(provers.DefaultL2ConfigMappingSlot),
+	}, settings)
+
+	// The NativeProver's configuration fills in everything not given explicitly
+	expected := &registrySettings{
+		registryAddress:     registry,
+		blockHashOracle:     oracle,
+		l2ConfigMappingSlot: big.NewInt(5),
+		l1ConfigMappingSlot: big.NewInt(6),
+	}
+	settings, err = resolve(nativeProverAddr, common.Address{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, settings)
+	settings, err = resolve(nativeProverAddr, registry, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, expected, settings)
+
+	// Explicit settings contradicting the NativeProver are rejected
+	_, err = resolve(nativeProverAddr, common.HexToAddress("0x9999"), nil)
+	require.ErrorContains(t, err, "l1-registry-address 0x0000000000000000000000000000000000009999 contradicts settlementRegistry")
+	_, err = resolve(nativeProverAddr, common.Address{}, big.NewInt(2))
+	require.ErrorContains(t, err, "l1-registry-l2-config-mapping-slot 2 contradicts settlementRegistryL2ConfigMappingSlot 5")
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/provers/verify"
 	"github.com/polymerdao/fallback_prover/types"
 )
 
@@ -38,15 +39,29 @@ func NewL1Prover(ctx context.Context, conf *ProveL1Config) (*L1Prover, error) {
 	}
 	dstL2Client := ethclient.NewClient(dstL2RPC)
 
-	registryProver := provers.NewRegistryProver(l1Client, l1RPC, conf.RegistryAddress)
 	nativeProver, err := provers.NewNativeProver()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create native prover: %w", err)
 	}
 
-	l1BlockHashOracle, err := registryProver.GetL1BlockHashOracle(ctx, conf.DstL2ChainID)
+	settings, err := resolveRegistrySettings(
+		ctx,
+		nativeProver,
+		dstL2Client,
+		conf.NativeProverAddress,
+		conf.RegistryAddress,
+		nil,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get L1 block hash oracle: %w", err)
+		return nil, err
+	}
+	l1BlockHashOracle := settings.blockHashOracle
+	if l1BlockHashOracle == (common.Address{}) {
+		registryProver := provers.NewRegistryProver(l1Client, l1RPC, settings.registryAddress)
+		l1BlockHashOracle, err = registryProver.GetL1BlockHashOracle(ctx, conf.DstL2ChainID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get L1 block hash oracle: %w", err)
+		}
 	}
 
 	return &L1Prover{
@@ -62,14 +77,24 @@ func (p *L1Prover) GenerateProveL1Calldata(
 	ctx context.Context,
 	params *ProveParams,
 ) (string, error) {
+	bundle, err := p.GenerateProveL1Bundle(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	return bundle.Calldata.String(), nil
+}
+
+// GenerateProveL1Bundle generates the calldata for the NativeProver.proveL1Native() function
+// together with every input it was generated from
+func (p *L1Prover) GenerateProveL1Bundle(ctx context.Context, params *ProveParams) (*types.ProofBundle, error) {
 	rlpEncodedL1Header, l1Header, err := p.GetL1Origin(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to get L1 origin: %w", err)
+		return nil, fmt.Errorf("failed to get L1 origin: %w", err)
 	}
 
-	result, err := p.l1StorageProver.GetStorageAt(ctx, params.Address, params.StorageSlot, nil)
+	result, err := p.l1StorageProver.GetStorageAt(ctx, params.Address, params.StorageSlot, l1Header.Number)
 	if err != nil {
-		return "", fmt.Errorf("failed to get storage value: %w", err)
+		return nil, fmt.Errorf("failed to get storage value: %w", err)
 	}
 
 	storageValue := common.HexToHash(result)
@@ -80,7 +105,20 @@ func (p *L1Prover) GenerateProveL1Calldata(
 		l1Header.Number,
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate storage proof: %w", err)
+		return nil, fmt.Errorf("failed to generate storage proof: %w", err)
+	}
+
+	// Check the proofs locally so a bad RPC response fails here rather than on-chain
+	if err := verify.AccountAndStorage(
+		l1Header.Root,
+		params.Address,
+		params.StorageSlot,
+		storageValue,
+		l1StorageProof,
+		rlpEncodedContractAccount,
+		l1AccountProof,
+	); err != nil {
+		return nil, fmt.Errorf("failed to verify L1 storage proof: %w", err)
 	}
 
 	proveArgs := types.ProveL1ScalarArgs{
@@ -98,11 +136,22 @@ func (p *L1Prover) GenerateProveL1Calldata(
 		l1AccountProof,
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack calldata: %w", err)
+		return nil, fmt.Errorf("failed to pack calldata: %w", err)
 	}
 
-	// Return the calldata as a hex string
-	return "0x" + common.Bytes2Hex(calldata), nil
+	return &types.ProofBundle{
+		Method:   "proveL1Native",
+		Calldata: calldata,
+		L1Header: types.NewBundleHeader(l1Header, rlpEncodedL1Header),
+		Storage: types.BundleStorageProof{
+			Address:           params.Address,
+			StorageSlot:       params.StorageSlot,
+			StorageValue:      storageValue,
+			StorageProof:      types.HexProof(l1StorageProof),
+			RlpEncodedAccount: rlpEncodedContractAccount,
+			AccountProof:      types.HexProof(l1AccountProof),
+		},
+	}, nil
 }
 
 func (p *L1Prover) GetL1Origin(ctx context.Context, params *ProveParams) ([]byte, *types2.Header, error) {
@@ -10,7 +10,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/provers/verify"
 	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -20,19 +22,20 @@ func TestL1Prover_GenerateProveL1Calldata(t *testing.T) {
 	l1Address := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
 	l1StorageSlot := common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890")
 
+	// Build a real L1 state so the storage proofs verify
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(l1Address, l1StorageSlot, common.HexToHash("0x123"))
+	mockStorageProof, mockEncodedContractAccount, mockAccountProof := l1State.ProofBytes(t, l1Address, l1StorageSlot)
+
 	// Create a test header and block
 	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
 	l1Block := testutil.CreateTestBlock(t, l1Header)
 
 	// RLP encode header
 	rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
 	require.NoError(t, err)
 
-	// Mock storage proof data
-	mockStorageProof := [][]byte{[]byte("storage-proof-1"), []byte("storage-proof-2")}
-	mockEncodedContractAccount := []byte("mock-encoded-contract-account")
-	mockAccountProof := [][]byte{[]byte("account-proof-1"), []byte("account-proof-2")}
-
 	// Create mock provers
 	mockL1OriginProver := &testutil.MockL1OriginProver{
 		GetL1OriginFunc: func(ctx context.Context, l1Hash common.Hash) ([]byte, *types.Header, error) {
@@ -139,4 +142,64 @@ func TestL1Prover_GenerateProveL1Calldata(t *testing.T) {
 		assert.NotNil(t, unpackedMap["_rlpEncodedContractAccount"], "RLP encoded contract account should be present")
 		assert.NotNil(t, unpackedMap["_l1AccountProof"], "L1 account proof should be present")
 	}
+	// The bundle carries the same calldata and the L1 inputs it was generated from
+	bundle, err := prover.GenerateProveL1Bundle(context.Background(), &ProveParams{
+		Address:     l1Address,
+		StorageSlot: l1StorageSlot,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "proveL1Native", bundle.Method)
+	assert.Equal(t, calldata, bundle.Calldata.String())
+	assert.Equal(t, l1Header.Hash(), bundle.L1Header.Hash)
+	assert.Equal(t, l1Header.Number, bundle.L1Header.Number)
+	assert.Equal(t, hexutil.Bytes(rlpEncodedL1Header), bundle.L1Header.RLP)
+	assert.Equal(t, common.HexToHash("0x123"), bundle.Storage.StorageValue)
+	assert.Equal(t, mockAccountProof, types2.RawProof(bundle.Storage.AccountProof))
+	assert.Nil(t, bundle.L2Header)
+	assert.Nil(t, bundle.Settled)
+}
+
+func TestL1Prover_GenerateProveL1Calldata_InvalidProof(t *testing.T) {
+	l1Address := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	l1StorageSlot := common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890")
+
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(l1Address, l1StorageSlot, common.HexToHash("0x123"))
+	storageProof, encodedContractAccount, accountProof := l1State.ProofBytes(t, l1Address, l1StorageSlot)
+
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
+	rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
+	require.NoError(t, err)
+
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+
+	prover := &L1Prover{
+		l1OriginProver: &testutil.MockL1OriginProver{
+			GetL1OriginFunc: func(ctx context.Context, l1Hash common.Hash) ([]byte, *types.Header, error) {
+				return rlpEncodedL1Header, l1Header, nil
+			},
+		},
+		l1StorageProver: &testutil.MockStorageProver{
+			// A lagging node reports a value the proof does not commit to
+			GetStorageAtFunc: func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (string, error) {
+				return "0x0000000000000000000000000000000000000000000000000000000000000456", nil
+			},
+			GenerateStorageProofFunc: func(ctx context.Context, contractAddr common.Address, storageSlot common.Hash, blockNumber *big.Int) ([][]byte, []byte, [][]byte, error) {
+				return storageProof, encodedContractAccount, accountProof, nil
+			},
+		},
+		nativeProver: nativeProver,
+	}
+
+	_, err = prover.GenerateProveL1Calldata(context.Background(), &ProveParams{
+		Address:     l1Address,
+		StorageSlot: l1StorageSlot,
+	})
+	require.ErrorIs(t, err, verify.ErrValueMismatch)
+
+	var proofErr *verify.ProofError
+	require.ErrorAs(t, err, &proofErr)
+	assert.Contains(t, proofErr.Proof, l1StorageSlot.Hex())
 }
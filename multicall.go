@@ -0,0 +1,103 @@
+package fallback_prover
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/polymerdao/fallback_prover/provers"
+)
+
+// safeTxBuilderVersion is the Safe Transaction Builder file format version written by WriteSafeTxBuilderFile
+const safeTxBuilderVersion = "1.0"
+
+// EncodeAggregate3Calldata wraps hex encoded calldatas for the NativeProver at nativeProverAddress
+// into a single Multicall3 aggregate3 call, in order. With allowFailure set a reverting call does
+// not revert the others.
+func EncodeAggregate3Calldata(
+	nativeProverAddress common.Address,
+	calldatas []string,
+	allowFailure bool,
+) (string, error) {
+	multicall, err := provers.NewMulticall3()
+	if err != nil {
+		return "", err
+	}
+
+	calls := make([]provers.Call3, len(calldatas))
+	for i, calldata := range calldatas {
+		calls[i] = provers.Call3{
+			Target:       nativeProverAddress,
+			AllowFailure: allowFailure,
+			CallData:     common.FromHex(calldata),
+		}
+	}
+	aggregate3, err := multicall.EncodeAggregate3(calls)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + common.Bytes2Hex(aggregate3), nil
+}
+
+// SafeTxBuilderBatch is a batch file that can be imported into the Safe Transaction Builder app
+type SafeTxBuilderBatch struct {
+	Version      string                     `json:"version"`
+	ChainID      string                     `json:"chainId"`
+	CreatedAt    int64                      `json:"createdAt"`
+	Meta         SafeTxBuilderMeta          `json:"meta"`
+	Transactions []SafeTxBuilderTransaction `json:"transactions"`
+}
+
+// SafeTxBuilderMeta describes a Safe Transaction Builder batch
+type SafeTxBuilderMeta struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SafeTxBuilderTransaction is a raw transaction of a Safe Transaction Builder batch
+type SafeTxBuilderTransaction struct {
+	To                   string            `json:"to"`
+	Value                string            `json:"value"`
+	Data                 string            `json:"data"`
+	ContractMethod       *json.RawMessage  `json:"contractMethod"`
+	ContractInputsValues map[string]string `json:"contractInputsValues"`
+}
+
+// NewSafeTxBuilderBatch returns a Safe Transaction Builder batch with a single transaction
+// sending the aggregate3 calldata to Multicall3 on the given chain
+func NewSafeTxBuilderBatch(
+	chainID uint64,
+	multicallAddress common.Address,
+	aggregate3Calldata string,
+	calls int,
+) *SafeTxBuilderBatch {
+	return &SafeTxBuilderBatch{
+		Version:   safeTxBuilderVersion,
+		ChainID:   strconv.FormatUint(chainID, 10),
+		CreatedAt: time.Now().UnixMilli(),
+		Meta: SafeTxBuilderMeta{
+			Name:        "NativeProver proofs",
+			Description: fmt.Sprintf("Multicall3 aggregate3 of %d NativeProver calls", calls),
+		},
+		Transactions: []SafeTxBuilderTransaction{{
+			To:    multicallAddress.Hex(),
+			Value: "0",
+			Data:  aggregate3Calldata,
+		}},
+	}
+}
+
+// WriteSafeTxBuilderFile writes the batch as indented JSON to path
+func WriteSafeTxBuilderFile(path string, batch *SafeTxBuilderBatch) error {
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode Safe Transaction Builder batch: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write Safe Transaction Builder file: %w", err)
+	}
+	return nil
+}
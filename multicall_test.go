@@ -0,0 +1,68 @@
+package fallback_prover
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeAggregate3Calldata(t *testing.T) {
+	nativeProverAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	calldatas := []string{"0x01020304", "0x05060708"}
+
+	multicall, err := provers.NewMulticall3()
+	require.NoError(t, err)
+	method := multicall.GetABI().Methods["aggregate3"]
+
+	for _, allowFailure := range []bool{false, true} {
+		aggregate3, err := EncodeAggregate3Calldata(nativeProverAddress, calldatas, allowFailure)
+		require.NoError(t, err)
+
+		data := common.FromHex(aggregate3)
+		require.Equal(t, method.ID, data[:4])
+		args, err := method.Inputs.Unpack(data[4:])
+		require.NoError(t, err)
+		calls := args[0].([]struct {
+			Target       common.Address `json:"target"`
+			AllowFailure bool           `json:"allowFailure"`
+			CallData     []byte         `json:"callData"`
+		})
+		require.Len(t, calls, len(calldatas))
+		for i, call := range calls {
+			assert.Equal(t, nativeProverAddress, call.Target)
+			assert.Equal(t, allowFailure, call.AllowFailure)
+			assert.Equal(t, common.FromHex(calldatas[i]), call.CallData)
+		}
+	}
+
+	_, err = EncodeAggregate3Calldata(nativeProverAddress, nil, false)
+	require.ErrorContains(t, err, "no calls")
+}
+
+func TestWriteSafeTxBuilderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	batch := NewSafeTxBuilderBatch(8453, provers.Multicall3Address, "0x82ad56cb", 2)
+	require.NoError(t, WriteSafeTxBuilderFile(path, batch))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, "1.0", decoded["version"])
+	assert.Equal(t, "8453", decoded["chainId"])
+	assert.NotZero(t, decoded["createdAt"])
+
+	transactions := decoded["transactions"].([]interface{})
+	require.Len(t, transactions, 1)
+	tx := transactions[0].(map[string]interface{})
+	assert.Equal(t, provers.Multicall3Address.Hex(), tx["to"])
+	assert.Equal(t, "0", tx["value"])
+	assert.Equal(t, "0x82ad56cb", tx["data"])
+	assert.Nil(t, tx["contractMethod"])
+}
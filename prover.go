@@ -5,14 +5,17 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	types2 "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/provers/verify"
 	"github.com/polymerdao/fallback_prover/types"
 )
 
@@ -25,9 +28,36 @@ type Prover struct {
 	l2Config           *types.L2ConfigInfo
 	l1BlockHashOracle  common.Address
 	srcChainID         *big.Int
-	configProof        func(blockNum *big.Int) (*types.UpdateL2ConfigArgs, error)
-	gameIndex          *big.Int
-	rootAddress        common.Address
+	configProof        func(l1Header *types2.Header) (*types.UpdateL2ConfigArgs, error)
+	// settledRules applies the registry finality delay at an L1 block to settledStateProver;
+	// nil for chain types without one
+	settledRules func(ctx context.Context, l1BlockNumber *big.Int) error
+	// settledStateTTL is how long the latest settled state is reused; zero re-resolves it on every call
+	settledStateTTL time.Duration
+
+	mu      sync.Mutex
+	settled *settledSelection
+	lookup  *settledLookup
+	// rulesMu holds the settled rules of one L1 block while a lookup uses them
+	rulesMu sync.Mutex
+}
+
+// settledSelection is a settled state selected by FindLatestResolved at an L1 origin block
+type settledSelection struct {
+	index         *big.Int
+	rootAddress   common.Address
+	l1BlockNumber *big.Int
+	l1BlockHash   common.Hash
+	resolvedAt    time.Time
+}
+
+// settledLookup is an in-flight FindLatestResolved call at an L1 origin block, shared by
+// concurrent callers. done is closed once selection or err is set.
+type settledLookup struct {
+	l1BlockHash common.Hash
+	done        chan struct{}
+	selection   *settledSelection
+	err         error
 }
 
 // NewProver initializes a new prover with the given RPC endpoints
@@ -52,49 +82,89 @@ func NewProver(ctx context.Context, conf *ProveConfig) (*Prover, error) {
 	}
 	dstL2Client := ethclient.NewClient(dstL2RPC)
 
-	registryProver := provers.NewRegistryProver(l1Client, l1RPC, conf.RegistryAddress)
-	l1BlockHashOracle, err := registryProver.GetL1BlockHashOracle(ctx, conf.DstL2ChainID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get L1 block hash oracle: %w", err)
-	}
-
 	nativeProver, err := provers.NewNativeProver()
 	if err != nil {
 		return nil, err
 	}
 
-	l2Config, err := registryProver.GetL2Configuration(context.Background(), conf.SrcL2ChainID)
+	settings, err := resolveRegistrySettings(
+		ctx,
+		nativeProver,
+		dstL2Client,
+		conf.NativeProverAddress,
+		conf.RegistryAddress,
+		conf.L2ConfigMappingSlot,
+	)
 	if err != nil {
 		return nil, err
 	}
-	getL2ConfigProof := func(blockNum *big.Int) (*types.UpdateL2ConfigArgs, error) {
-		return registryProver.GenerateUpdateL2ConfigArgs(ctx, conf.SrcL2ChainID, blockNum)
+	registryProver := provers.NewRegistryProver(l1Client, l1RPC, settings.registryAddress)
+	registryProver.SetL2ConfigMappingSlot(settings.l2ConfigMappingSlot)
+	registryProver.SetL1ConfigMappingSlot(settings.l1ConfigMappingSlot)
+	l1BlockHashOracle := settings.blockHashOracle
+	if l1BlockHashOracle == (common.Address{}) {
+		l1BlockHashOracle, err = registryProver.GetL1BlockHashOracle(ctx, conf.DstL2ChainID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get L1 block hash oracle: %w", err)
+		}
 	}
 
+	l2Config, err := registryProver.GetL2Configuration(context.Background(), conf.SrcL2ChainID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate L2 config proof: %w", err)
+		return nil, err
+	}
+	getL2ConfigProof := func(l1Header *types2.Header) (*types.UpdateL2ConfigArgs, error) {
+		return registryProver.GenerateUpdateL2ConfigArgs(ctx, conf.SrcL2ChainID, l1Header)
 	}
 
+	// The finality delay is read at the L1 origin of each call, the L1 block the settled state
+	// is proven against
 	var settledStateProver provers.ISettledStateProver
+	var settledRules func(ctx context.Context, l1BlockNumber *big.Int) error
 	if l2Config.ConfigType == "OPStackBedrock" {
-		settledStateProver, err = provers.NewOPStackBedrockProver(l1Client, l1RPC, srcL2RPC)
+		bedrockProver, err := provers.NewOPStackBedrockProver(l1Client, l1RPC, srcL2RPC)
 		if err != nil {
 			return nil, err
 		}
+		settledRules = func(ctx context.Context, l1BlockNumber *big.Int) error {
+			finalityDelay, err := getFinalityDelay(ctx, registryProver, conf.SrcL2ChainID, l1BlockNumber)
+			if err != nil {
+				return err
+			}
+			bedrockProver.SetFinalityDelay(finalityDelay)
+			return nil
+		}
+		settledStateProver = bedrockProver
 	} else if l2Config.ConfigType == "OPStackCannon" {
-		settledStateProver, err = provers.NewOPStackCannonProver(l1Client, l1RPC, srcL2RPC)
+		cannonProver, err := provers.NewOPStackCannonProver(l1Client, l1RPC, srcL2RPC)
+		if err != nil {
+			return nil, err
+		}
+		settledRules = func(ctx context.Context, l1BlockNumber *big.Int) error {
+			finalityDelay, err := getFinalityDelay(ctx, registryProver, conf.SrcL2ChainID, l1BlockNumber)
+			if err != nil {
+				return err
+			}
+			cannonProver.SetGameRules(provers.CannonGameRules{
+				OptimismPortal:       conf.OptimismPortalAddress,
+				FinalityDelaySeconds: finalityDelay,
+			})
+			return nil
+		}
+		settledStateProver = cannonProver
+	} else if l2Config.ConfigType == "Arbitrum" {
+		nitroProver, err := provers.NewArbitrumNitroProver(l1Client, l1RPC, srcL2RPC)
 		if err != nil {
 			return nil, err
 		}
+		if conf.AllowUnverifiedNitroProofs {
+			nitroProver.AllowUnverifiedEncoding()
+		}
+		settledStateProver = nitroProver
 	} else {
 		return nil, fmt.Errorf("unsupported L2 config type: %s", l2Config.ConfigType)
 	}
 
-	index, address, err := settledStateProver.FindLatestResolved(ctx, l2Config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find latest resolved info: %w", err)
-	}
-
 	return &Prover{
 		l1OriginProver:     provers.NewL1OriginProver(l1Client, dstL2Client),
 		l2StorageProver:    provers.NewStorageProver(ethclient.NewClient(srcL2RPC), srcL2RPC),
@@ -104,34 +174,173 @@ func NewProver(ctx context.Context, conf *ProveConfig) (*Prover, error) {
 		l1BlockHashOracle:  l1BlockHashOracle,
 		srcChainID:         big.NewInt(int64(conf.SrcL2ChainID)),
 		configProof:        getL2ConfigProof,
-		gameIndex:          index,
-		rootAddress:        address,
+		settledRules:       settledRules,
+		settledStateTTL:    conf.SettledStateTTL,
 	}, nil
 }
 
-// GenerateProveNativeCalldata generates the calldata for the NativeProver.proveNative() function
+// getFinalityDelay returns the FinalityDelaySeconds of the chain's registry configuration at
+// L1 block l1BlockNumber
+func getFinalityDelay(
+	ctx context.Context,
+	registryProver provers.IRegistryProver,
+	chainID uint64,
+	l1BlockNumber *big.Int,
+) (uint64, error) {
+	l2Config, err := registryProver.GetL2ConfigurationForUpdate(ctx, chainID, l1BlockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get L2 config finality delay: %w", err)
+	}
+	if l2Config.FinalityDelaySeconds == nil {
+		return 0, nil
+	}
+	return l2Config.FinalityDelaySeconds.Uint64(), nil
+}
+
+// Refresh re-resolves the latest settled state at the current L1 origin, so following calls
+// against the same L1 origin prove against it until the settled state TTL expires. It returns
+// the selected game, output or node index.
+func (p *Prover) Refresh(ctx context.Context) (*big.Int, common.Address, error) {
+	_, l1Header, err := p.GetL1Origin(ctx, &ProveParams{})
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to get L1 origin: %w", err)
+	}
+	selection, err := p.latestSettled(ctx, l1Header, true)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return selection.index, selection.rootAddress, nil
+}
+
+// latestSettled returns the latest settled state as seen at the L1 origin block, so that the
+// selection and the settled state proof are read from the same block. A selection made at the
+// same L1 origin is reused while it is younger than the TTL. Concurrent callers at the same L1
+// origin share a single lookup, which keeps running if the caller that started it is cancelled.
+func (p *Prover) latestSettled(ctx context.Context, l1Header *types2.Header, force bool) (*settledSelection, error) {
+	l1BlockHash := l1Header.Hash()
+
+	p.mu.Lock()
+	if !force &&
+		p.settled != nil &&
+		p.settled.l1BlockHash == l1BlockHash &&
+		time.Since(p.settled.resolvedAt) < p.settledStateTTL {
+		selection := p.settled
+		p.mu.Unlock()
+		return selection, nil
+	}
+	lookup := p.lookup
+	if lookup == nil || lookup.l1BlockHash != l1BlockHash {
+		lookup = &settledLookup{l1BlockHash: l1BlockHash, done: make(chan struct{})}
+		p.lookup = lookup
+		go p.resolveLatestSettled(context.WithoutCancel(ctx), l1Header, lookup)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-lookup.done:
+		return lookup.selection, lookup.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolveLatestSettled runs the lookup and publishes its result to the cache
+func (p *Prover) resolveLatestSettled(ctx context.Context, l1Header *types2.Header, lookup *settledLookup) {
+	index, rootAddress, err := p.withSettledRules(ctx, l1Header.Number, func() (*big.Int, common.Address, error) {
+		return p.settledStateProver.FindLatestResolved(ctx, p.l2Config, l1Header.Number)
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		lookup.err = fmt.Errorf("failed to find latest resolved info at L1 block %s: %w", l1Header.Number, err)
+	} else {
+		lookup.selection = &settledSelection{
+			index:         index,
+			rootAddress:   rootAddress,
+			l1BlockNumber: l1Header.Number,
+			l1BlockHash:   lookup.l1BlockHash,
+			resolvedAt:    time.Now(),
+		}
+		// A slow lookup at an older L1 origin must not replace a newer selection
+		if p.settled == nil || p.settled.l1BlockNumber.Cmp(l1Header.Number) <= 0 {
+			p.settled = lookup.selection
+		}
+		log.Info("Resolved latest settled state",
+			"type", p.l2Config.ConfigType,
+			"index", index,
+			"root", rootAddress,
+			"l1Block", l1Header.Number)
+	}
+	if p.lookup == lookup {
+		p.lookup = nil
+	}
+	close(lookup.done)
+}
+
+// withSettledRules applies the settled rules at l1BlockNumber and runs find with them. Lookups
+// at different L1 blocks take turns, so none runs with the rules of another block.
+func (p *Prover) withSettledRules(
+	ctx context.Context,
+	l1BlockNumber *big.Int,
+	find func() (*big.Int, common.Address, error),
+) (*big.Int, common.Address, error) {
+	p.rulesMu.Lock()
+	defer p.rulesMu.Unlock()
+	if p.settledRules != nil {
+		if err := p.settledRules(ctx, l1BlockNumber); err != nil {
+			return nil, common.Address{}, err
+		}
+	}
+	return find()
+}
+
+// cachedSettled returns the last resolved latest settled state, or nil if there is none
+func (p *Prover) cachedSettled() *settledSelection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settled
+}
+
+// settledInputs are the parts of the proveNative calldata shared by every storage slot proven
+// against the same L1 origin and settled state
+type settledInputs struct {
+	rlpEncodedL1Header []byte
+	l1Header           *types2.Header
+	rlpEncodedL2Header []byte
+	l2Header           *types2.Header
+	settledStateProof  []byte
+	updateArgs         *types.UpdateL2ConfigArgs
+	settled            *types.SettledState
+	// outputRoot is nil for chain types without output roots
+	outputRoot *common.Hash
+}
+
+// GenerateProveNativeCalldata generates the calldata for the NativeProver.proveNative() function,
+// along with the settled state it was generated against
 func (p *Prover) GenerateProveNativeCalldata(
 	ctx context.Context,
 	params *ProveParams,
-) (string, error) {
-	rlpEncodedL1Header, l1Header, err := p.GetL1Origin(ctx, params)
+) (string, *types.SettledState, error) {
+	bundle, err := p.GenerateProveNativeBundle(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to get L1 origin: %w", err)
+		return "", nil, err
 	}
+	return bundle.Calldata.String(), bundle.Settled, nil
+}
 
-	settledStateProof, l2Header, err := p.settledStateProver.GenerateSettledStateProof(
-		ctx,
-		l1Header.Number,
-		p.gameIndex,
-		p.rootAddress,
-		p.l2Config)
+// GenerateProveNativeBundle generates the calldata for the NativeProver.proveNative() function
+// together with every input it was generated from
+func (p *Prover) GenerateProveNativeBundle(ctx context.Context, params *ProveParams) (*types.ProofBundle, error) {
+	inputs, err := p.settledInputs(ctx, params)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate %s settled state proof: %w", p.l2Config.ConfigType, err)
+		return nil, err
 	}
+	l2Header := inputs.l2Header
 
 	result, err := p.l2StorageProver.GetStorageAt(ctx, params.Address, params.StorageSlot, l2Header.Number)
 	if err != nil {
-		return "", fmt.Errorf("failed to get storage value: %w", err)
+		return nil, fmt.Errorf("failed to get storage value: %w", err)
 	}
 	storageValue := common.HexToHash(result)
 
@@ -142,44 +351,166 @@ func (p *Prover) GenerateProveNativeCalldata(
 		l2Header.Number,
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate storage proof: %w", err)
+		return nil, fmt.Errorf("failed to generate storage proof: %w", err)
 	}
 
-	// Create ProveScalarArgs for the proveNative call
-	proveArgs := types.ProveScalarArgs{
-		ChainID:          p.srcChainID,
-		ContractAddr:     params.Address,
-		StorageSlot:      params.StorageSlot,
-		StorageValue:     storageValue,
-		L2WorldStateRoot: l2Header.Root,
+	return p.encodeProveNative(
+		inputs,
+		StorageTarget{Address: params.Address, StorageSlot: params.StorageSlot},
+		storageValue,
+		l2StorageProof,
+		rlpEncodedContractAccount,
+		l2AccountProof,
+	)
+}
+
+// settledInputs resolves the L1 origin and the settled state to prove against and generates
+// the L1 header, settled state proof and L2 config update args for them
+func (p *Prover) settledInputs(ctx context.Context, params *ProveParams) (*settledInputs, error) {
+	rlpEncodedL1Header, l1Header, err := p.GetL1Origin(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 origin: %w", err)
+	}
+
+	var index *big.Int
+	var rootAddress common.Address
+	if params.L2BlockNumber == nil {
+		selection, err := p.latestSettled(ctx, l1Header, false)
+		if err != nil {
+			return nil, err
+		}
+		index, rootAddress = selection.index, selection.rootAddress
+	} else {
+		// Only settled state the L1 origin already knows about can be proven on the destination
+		index, rootAddress, err = p.withSettledRules(ctx, l1Header.Number, func() (*big.Int, common.Address, error) {
+			return p.settledStateProver.FindResolvedAtOrAfter(ctx, p.l2Config, params.L2BlockNumber, l1Header.Number)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find settled state for L2 block %s: %w", params.L2BlockNumber, err)
+		}
+	}
+
+	settledStateProof, l2Header, err := p.settledStateProver.GenerateSettledStateProof(
+		ctx,
+		l1Header,
+		index,
+		rootAddress,
+		p.l2Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s settled state proof: %w", p.l2Config.ConfigType, err)
+	}
+	if params.L2BlockNumber != nil && l2Header.Number.Cmp(params.L2BlockNumber) < 0 {
+		return nil, fmt.Errorf(
+			"settled L2 block %s is before target L2 block %s",
+			l2Header.Number,
+			params.L2BlockNumber,
+		)
 	}
 
 	rlpEncodedL2Header, err := rlp.EncodeToBytes(l2Header)
 	if err != nil {
-		return "", fmt.Errorf("failed to encode L2 header: %w", err)
+		return nil, fmt.Errorf("failed to encode L2 header: %w", err)
 	}
 
-	updateArgs, err := p.configProof(l1Header.Number)
+	updateArgs, err := p.configProof(l1Header)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate update args: %w", err)
+		return nil, fmt.Errorf("failed to generate update args: %w", err)
+	}
+
+	// The output root is only reported, so a proof it cannot be read from is still used
+	var outputRoot *common.Hash
+	root, ok, err := provers.SettledOutputRoot(p.l2Config.ConfigType, settledStateProof, l2Header)
+	if err != nil {
+		log.Warn("Failed to read output root from settled state proof", "type", p.l2Config.ConfigType, "err", err)
+	} else if ok {
+		outputRoot = &root
+	}
+
+	return &settledInputs{
+		rlpEncodedL1Header: rlpEncodedL1Header,
+		l1Header:           l1Header,
+		outputRoot:         outputRoot,
+		rlpEncodedL2Header: rlpEncodedL2Header,
+		l2Header:           l2Header,
+		settledStateProof:  settledStateProof,
+		updateArgs:         updateArgs,
+		settled: &types.SettledState{
+			Index:         index,
+			RootAddress:   rootAddress,
+			L1BlockNumber: l1Header.Number,
+			L2BlockNumber: l2Header.Number,
+			L2BlockHash:   l2Header.Hash(),
+		},
+	}, nil
+}
+
+// encodeProveNative checks the storage proof of a slot against the settled L2 state root and
+// packs it with the shared inputs into proveNative calldata
+func (p *Prover) encodeProveNative(
+	inputs *settledInputs,
+	target StorageTarget,
+	storageValue common.Hash,
+	l2StorageProof [][]byte,
+	rlpEncodedContractAccount []byte,
+	l2AccountProof [][]byte,
+) (*types.ProofBundle, error) {
+	// Check the proofs locally so a bad RPC response fails here rather than on-chain
+	if err := verify.AccountAndStorage(
+		inputs.l2Header.Root,
+		target.Address,
+		target.StorageSlot,
+		storageValue,
+		l2StorageProof,
+		rlpEncodedContractAccount,
+		l2AccountProof,
+	); err != nil {
+		return nil, fmt.Errorf("failed to verify L2 storage proof: %w", err)
+	}
+
+	// Create ProveScalarArgs for the proveNative call
+	proveArgs := types.ProveScalarArgs{
+		ChainID:          p.srcChainID,
+		ContractAddr:     target.Address,
+		StorageSlot:      target.StorageSlot,
+		StorageValue:     storageValue,
+		L2WorldStateRoot: inputs.l2Header.Root,
 	}
 
 	calldata, err := p.nativeProver.EncodeProveNativeCalldata(
-		*updateArgs,
+		*inputs.updateArgs,
 		proveArgs,
-		rlpEncodedL1Header,
-		rlpEncodedL2Header,
-		settledStateProof,
+		inputs.rlpEncodedL1Header,
+		inputs.rlpEncodedL2Header,
+		inputs.settledStateProof,
 		l2StorageProof,
 		rlpEncodedContractAccount,
 		l2AccountProof,
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to pack proveNative calldata: %w", err)
+		return nil, fmt.Errorf("failed to pack proveNative calldata: %w", err)
 	}
 
-	// Return the calldata as a hex string
-	return "0x" + common.Bytes2Hex(calldata), nil
+	l2Header := types.NewBundleHeader(inputs.l2Header, inputs.rlpEncodedL2Header)
+	return &types.ProofBundle{
+		Method:            "proveNative",
+		Calldata:          calldata,
+		L1Header:          types.NewBundleHeader(inputs.l1Header, inputs.rlpEncodedL1Header),
+		SrcChainID:        p.srcChainID,
+		ConfigType:        p.l2Config.ConfigType,
+		L2Header:          &l2Header,
+		Settled:           inputs.settled,
+		OutputRoot:        inputs.outputRoot,
+		SettledStateProof: inputs.settledStateProof,
+		UpdateArgs:        types.NewBundleUpdateArgs(inputs.updateArgs),
+		Storage: types.BundleStorageProof{
+			Address:           target.Address,
+			StorageSlot:       target.StorageSlot,
+			StorageValue:      storageValue,
+			StorageProof:      types.HexProof(l2StorageProof),
+			RlpEncodedAccount: rlpEncodedContractAccount,
+			AccountProof:      types.HexProof(l2AccountProof),
+		},
+	}, nil
 }
 
 func (p *Prover) GetL1Origin(ctx context.Context, params *ProveParams) ([]byte, *types2.Header, error) {
@@ -2,8 +2,13 @@ package fallback_prover
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -22,10 +27,16 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
 	srcStorageSlot := common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890")
 
+	// Build a real L2 state so the storage proofs verify
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(srcAddress, srcStorageSlot, common.HexToHash("0x123"))
+	mockStorageProof, mockEncodedContractAccount, mockAccountProof := l2State.ProofBytes(t, srcAddress, srcStorageSlot)
+
 	// Create a test header and block
 	l1Header := testutil.CreateTestHeader(t)
 	l1Block := testutil.CreateTestBlock(t, l1Header)
 	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Root = l2State.Root(t)
 
 	// RLP encode headers
 	rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
@@ -33,9 +44,6 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 
 	// Mock settled state proof data
 	mockSettledStateProof := []byte("mock-settled-state-proof")
-	mockStorageProof := [][]byte{[]byte("storage-proof-1"), []byte("storage-proof-2")}
-	mockEncodedContractAccount := []byte("mock-encoded-contract-account")
-	mockAccountProof := [][]byte{[]byte("account-proof-1"), []byte("account-proof-2")}
 
 	// Create mock L1 storage proof data for UpdateL2ConfigArgs
 	mockL1StorageProof := [][]byte{[]byte("l1-storage-proof-1"), []byte("l1-storage-proof-2")}
@@ -81,10 +89,10 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 	}
 
 	mockCannonProver := &testutil.MockOPStackCannonProver{
-		GenerateSettledStateProofFunc: func(ctx context.Context, l1BlockNumber, outputIndex *big.Int, rootAddress common.Address, config *types2.L2ConfigInfo) ([]byte, *types.Header, error) {
+		GenerateSettledStateProofFunc: func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *types2.L2ConfigInfo) ([]byte, *types.Header, error) {
 			return mockSettledStateProof, l2Header, nil
 		},
-		FindLatestResolvedFunc: func(ctx context.Context, config *types2.L2ConfigInfo) (*big.Int, common.Address, error) {
+		FindLatestResolvedFunc: func(ctx context.Context, config *types2.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
 			return big.NewInt(0), common.HexToAddress("0x1234"), nil
 		},
 	}
@@ -101,7 +109,7 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 		l2Config:           testConfig,
 		l1BlockHashOracle:  common.HexToAddress("0x5678"),
 		srcChainID:         big.NewInt(int64(srcL2ChainID)), // Initialize the srcChainID field
-		configProof: func(blockNum *big.Int) (*types2.UpdateL2ConfigArgs, error) {
+		configProof: func(l1Header *types.Header) (*types2.UpdateL2ConfigArgs, error) {
 			return &types2.UpdateL2ConfigArgs{
 				Config:                        l2Config,
 				L1StorageProof:                mockL1StorageProof,
@@ -112,7 +120,7 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 	}
 
 	// Call the method being tested
-	calldata, err := prover.GenerateProveNativeCalldata(
+	calldata, settled, err := prover.GenerateProveNativeCalldata(
 		context.Background(),
 		&ProveParams{
 			Address:           srcAddress,
@@ -123,6 +131,9 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 		},
 	)
 	require.NoError(t, err)
+	assert.Equal(t, l2Header.Number, settled.L2BlockNumber)
+	assert.Equal(t, l2Header.Hash(), settled.L2BlockHash)
+	assert.Equal(t, l1Header.Number, settled.L1BlockNumber)
 
 	// Get ABI
 	nativeProverABI := prover.nativeProver.(*provers.NativeProver).GetABI()
@@ -244,3 +255,299 @@ func TestProver_GenerateProveNativeCalldata(t *testing.T) {
 	require.True(t, ok, "Expected _l2AccountProof to be a [][]byte")
 	assert.NotEmpty(t, accountProofFromMap, "L2 account proof should be present")
 }
+
+func TestProver_GenerateProveNativeBundle(t *testing.T) {
+	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	srcStorageSlot := common.HexToHash("0x01")
+	prover, _ := newMockProver(t, srcAddress, srcStorageSlot)
+	params := &ProveParams{Address: srcAddress, StorageSlot: srcStorageSlot}
+
+	bundle, err := prover.GenerateProveNativeBundle(context.Background(), params)
+	require.NoError(t, err)
+	calldata, _, err := prover.GenerateProveNativeCalldata(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, calldata, bundle.Calldata.String())
+
+	l1Header := testutil.CreateTestHeader(t)
+	assert.Equal(t, "proveNative", bundle.Method)
+	assert.Equal(t, l1Header.Hash(), bundle.L1Header.Hash)
+	assert.Equal(t, l1Header.Number, bundle.L1Header.Number)
+	assert.Equal(t, big.NewInt(10), bundle.SrcChainID)
+	assert.Equal(t, "OPStackCannon", bundle.ConfigType)
+	require.NotNil(t, bundle.L2Header)
+	assert.Equal(t, bundle.Settled.L2BlockHash, bundle.L2Header.Hash)
+	assert.Equal(t, bundle.Settled.L2BlockNumber, bundle.L2Header.Number)
+	assert.Equal(t, big.NewInt(9), bundle.Settled.Index)
+	assert.Equal(t, hexutil.Bytes("settled-state-proof"), bundle.SettledStateProof)
+	assert.Equal(t, srcAddress, bundle.Storage.Address)
+	assert.Equal(t, common.HexToHash("0x123"), bundle.Storage.StorageValue)
+
+	// The bundle survives a JSON round trip
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var decoded types2.ProofBundle
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	redata, err := json.Marshal(&decoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(redata))
+	assert.Equal(t, bundle.Calldata, decoded.Calldata)
+	assert.Equal(t, bundle.Storage, decoded.Storage)
+	assert.Equal(t, big.NewInt(1), decoded.UpdateArgs.Args().Config.VersionNumber)
+}
+
+// newMockProver returns a Prover whose source L2 storage holds 0x123 at the given slot. The
+// latest settled state is game 9, and the mock settled state prover returns the L2 header
+// of testutil.CreateTestHeader for any game.
+func newMockProver(
+	t *testing.T,
+	srcAddress common.Address,
+	srcStorageSlot common.Hash,
+) (*Prover, *testutil.MockOPStackCannonProver) {
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(srcAddress, srcStorageSlot, common.HexToHash("0x123"))
+	storageProof, encodedContractAccount, accountProof := l2State.ProofBytes(t, srcAddress, srcStorageSlot)
+
+	l1Header := testutil.CreateTestHeader(t)
+	rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
+	require.NoError(t, err)
+	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Root = l2State.Root(t)
+
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+
+	settledStateProver := &testutil.MockOPStackCannonProver{
+		FindLatestResolvedFunc: func(ctx context.Context, config *types2.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+			assert.Equal(t, l1Header.Number, l1BlockNumber)
+			return big.NewInt(9), common.HexToAddress("0x9999"), nil
+		},
+		GenerateSettledStateProofFunc: func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *types2.L2ConfigInfo) ([]byte, *types.Header, error) {
+			return []byte("settled-state-proof"), l2Header, nil
+		},
+	}
+	return &Prover{
+		l1OriginProver: &testutil.MockL1OriginProver{
+			GetL1OriginFunc: func(ctx context.Context, l1OriginHash common.Hash) ([]byte, *types.Header, error) {
+				return rlpEncodedL1Header, l1Header, nil
+			},
+		},
+		nativeProver: nativeProver,
+		l2StorageProver: &testutil.MockStorageProver{
+			GetStorageAtFunc: func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (string, error) {
+				return common.HexToHash("0x123").Hex(), nil
+			},
+			GenerateStorageProofFunc: func(ctx context.Context, contractAddr common.Address, storageSlot common.Hash, blockNumber *big.Int) ([][]byte, []byte, [][]byte, error) {
+				return storageProof, encodedContractAccount, accountProof, nil
+			},
+		},
+		settledStateProver: settledStateProver,
+		l2Config:           &types2.L2ConfigInfo{ConfigType: "OPStackCannon"},
+		srcChainID:         big.NewInt(10),
+		configProof: func(l1Header *types.Header) (*types2.UpdateL2ConfigArgs, error) {
+			return &types2.UpdateL2ConfigArgs{
+				Config: types2.L2Configuration{
+					VersionNumber:        big.NewInt(1),
+					FinalityDelaySeconds: big.NewInt(0),
+					L2Type:               types2.OPStackCannon,
+				},
+			}, nil
+		},
+	}, settledStateProver
+}
+
+func TestProver_GenerateProveNativeCalldata_L2BlockNumber(t *testing.T) {
+	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	srcStorageSlot := common.HexToHash("0x01")
+	gameAddress := common.HexToAddress("0x1234")
+	l1Header := testutil.CreateTestHeader(t)
+
+	// The latest settled game is index 9; the target resolves to index 7 at the L1 origin
+	newProver := func(t *testing.T) *Prover {
+		prover, settledStateProver := newMockProver(t, srcAddress, srcStorageSlot)
+		settledStateProver.FindResolvedAtOrAfterFunc = func(ctx context.Context, config *types2.L2ConfigInfo, l2BlockNumber *big.Int, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+			assert.Equal(t, l1Header.Number, l1BlockNumber)
+			return big.NewInt(7), gameAddress, nil
+		}
+		generate := settledStateProver.GenerateSettledStateProofFunc
+		settledStateProver.GenerateSettledStateProofFunc = func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *types2.L2ConfigInfo) ([]byte, *types.Header, error) {
+			assert.Equal(t, big.NewInt(7), outputIndex)
+			return generate(ctx, l1Header, outputIndex, rootAddress, config)
+		}
+		return prover
+	}
+
+	_, settled, err := newProver(t).GenerateProveNativeCalldata(context.Background(), &ProveParams{
+		Address:       srcAddress,
+		StorageSlot:   srcStorageSlot,
+		L2BlockNumber: big.NewInt(12000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(7), settled.Index)
+	assert.Equal(t, gameAddress, settled.RootAddress)
+	assert.Equal(t, big.NewInt(12345), settled.L2BlockNumber)
+
+	// A settled state before the target must not be used
+	_, _, err = newProver(t).GenerateProveNativeCalldata(context.Background(), &ProveParams{
+		Address:       srcAddress,
+		StorageSlot:   srcStorageSlot,
+		L2BlockNumber: big.NewInt(12346),
+	})
+	require.ErrorContains(t, err, "settled L2 block 12345 is before target L2 block 12346")
+}
+
+func TestProver_SettledStateTTL(t *testing.T) {
+	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	srcStorageSlot := common.HexToHash("0x01")
+	params := &ProveParams{Address: srcAddress, StorageSlot: srcStorageSlot}
+
+	// Every lookup finds a newer game
+	newProver := func(t *testing.T, ttl time.Duration) (*Prover, *atomic.Int64) {
+		prover, settledStateProver := newMockProver(t, srcAddress, srcStorageSlot)
+		prover.settledStateTTL = ttl
+		var lookups atomic.Int64
+		settledStateProver.FindLatestResolvedFunc = func(ctx context.Context, config *types2.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+			return big.NewInt(lookups.Add(1)), common.HexToAddress("0x9999"), nil
+		}
+		return prover, &lookups
+	}
+
+	t.Run("ResolvesEveryCall", func(t *testing.T) {
+		prover, lookups := newProver(t, 0)
+		for i := int64(1); i <= 2; i++ {
+			_, settled, err := prover.GenerateProveNativeCalldata(context.Background(), params)
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(i), settled.Index)
+		}
+		assert.Equal(t, int64(2), lookups.Load())
+	})
+
+	t.Run("ReusesWithinTTL", func(t *testing.T) {
+		prover, lookups := newProver(t, time.Hour)
+		for i := 0; i < 2; i++ {
+			_, settled, err := prover.GenerateProveNativeCalldata(context.Background(), params)
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(1), settled.Index)
+		}
+		assert.Equal(t, int64(1), lookups.Load())
+
+		index, _, err := prover.Refresh(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(2), index)
+
+		_, settled, err := prover.GenerateProveNativeCalldata(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(2), settled.Index)
+		assert.Equal(t, int64(2), lookups.Load())
+	})
+
+	t.Run("ReresolvesOnNewL1Origin", func(t *testing.T) {
+		prover, lookups := newProver(t, time.Hour)
+		_, settled, err := prover.GenerateProveNativeCalldata(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(1), settled.Index)
+		assert.Equal(t, big.NewInt(12345), settled.L1BlockNumber)
+
+		// A selection made at an older L1 origin is not reused, even within the TTL
+		l1Header := testutil.CreateTestHeader(t)
+		l1Header.Number = big.NewInt(12346)
+		rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
+		require.NoError(t, err)
+		prover.l1OriginProver = &testutil.MockL1OriginProver{
+			GetL1OriginFunc: func(ctx context.Context, l1OriginHash common.Hash) ([]byte, *types.Header, error) {
+				return rlpEncodedL1Header, l1Header, nil
+			},
+		}
+		_, settled, err = prover.GenerateProveNativeCalldata(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(2), settled.Index)
+		assert.Equal(t, big.NewInt(12346), settled.L1BlockNumber)
+		assert.Equal(t, int64(2), lookups.Load())
+	})
+
+	t.Run("SharesConcurrentLookup", func(t *testing.T) {
+		prover, settledStateProver := newMockProver(t, srcAddress, srcStorageSlot)
+		prover.settledStateTTL = time.Hour
+		var lookups atomic.Int64
+		release := make(chan struct{})
+		settledStateProver.FindLatestResolvedFunc = func(ctx context.Context, config *types2.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+			lookups.Add(1)
+			<-release
+			return big.NewInt(9), common.HexToAddress("0x9999"), nil
+		}
+
+		// A cancelled caller gives up without failing the lookup for the others
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancelledErr := make(chan error, 1)
+		go func() {
+			_, _, err := prover.Refresh(cancelled)
+			cancelledErr <- err
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, settled, err := prover.GenerateProveNativeCalldata(context.Background(), params)
+				assert.NoError(t, err)
+				assert.Equal(t, big.NewInt(9), settled.Index)
+			}()
+		}
+
+		cancel()
+		require.ErrorIs(t, <-cancelledErr, context.Canceled)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int64(1), lookups.Load())
+	})
+}
+
+func TestProver_SettledRules(t *testing.T) {
+	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	srcStorageSlot := common.HexToHash("0x01")
+
+	// Every lookup must run with the rules read at its own L1 origin
+	prover, settledStateProver := newMockProver(t, srcAddress, srcStorageSlot)
+	var rulesBlock *big.Int
+	prover.settledRules = func(ctx context.Context, l1BlockNumber *big.Int) error {
+		rulesBlock = l1BlockNumber
+		return nil
+	}
+	settledStateProver.FindLatestResolvedFunc = func(ctx context.Context, config *types2.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+		assert.Equal(t, l1BlockNumber, rulesBlock)
+		return big.NewInt(9), common.HexToAddress("0x9999"), nil
+	}
+	settledStateProver.FindResolvedAtOrAfterFunc = func(ctx context.Context, config *types2.L2ConfigInfo, l2BlockNumber *big.Int, l1BlockNumber *big.Int) (*big.Int, common.Address, error) {
+		assert.Equal(t, l1BlockNumber, rulesBlock)
+		return big.NewInt(7), common.HexToAddress("0x7777"), nil
+	}
+
+	_, _, err := prover.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(12345), rulesBlock)
+
+	// A new L1 origin reads the rules again
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Number = big.NewInt(12346)
+	rlpEncodedL1Header, err := rlp.EncodeToBytes(l1Header)
+	require.NoError(t, err)
+	prover.l1OriginProver = &testutil.MockL1OriginProver{
+		GetL1OriginFunc: func(ctx context.Context, l1OriginHash common.Hash) ([]byte, *types.Header, error) {
+			return rlpEncodedL1Header, l1Header, nil
+		},
+	}
+	_, settled, err := prover.GenerateProveNativeCalldata(context.Background(), &ProveParams{
+		Address:       srcAddress,
+		StorageSlot:   srcStorageSlot,
+		L2BlockNumber: big.NewInt(12000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(7), settled.Index)
+	assert.Equal(t, big.NewInt(12346), rulesBlock)
+
+	prover.settledRules = func(ctx context.Context, l1BlockNumber *big.Int) error {
+		return fmt.Errorf("registry unavailable")
+	}
+	_, _, err = prover.Refresh(context.Background())
+	require.ErrorContains(t, err, "registry unavailable")
+}
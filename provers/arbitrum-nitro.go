@@ -0,0 +1,550 @@
+package provers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	types2 "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/polymerdao/fallback_prover/provers/verify"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+var _ ISettledStateProver = &ArbitrumNitroProver{}
+
+// ArbitrumNitroProver handles proof generation for Arbitrum Nitro chains, settled through
+// the confirmed nodes of the rollup contract on L1
+//
+// The registry configuration for a Nitro chain is:
+//
+//	Addresses[0]    the rollup contract
+//	StorageSlots[0] the slot packing _latestConfirmed in its lowest 8 bytes
+//	StorageSlots[1] the base slot of the _nodes mapping
+//
+// Only legacy rollups, which confirm nodes, are supported. BOLD rollups confirm assertions
+// instead and are rejected with a NitroBoldRollupError. Settled state proofs are disabled
+// until AllowUnverifiedEncoding is called, see NitroUnverifiedEncodingError.
+type ArbitrumNitroProver struct {
+	l1Client  IEthClient
+	l1RPC     IRPCClient
+	l2RPC     IRPCClient
+	rollupABI abi.ABI
+	// allowUnverifiedEncoding enables settled state proofs, see NitroUnverifiedEncodingError
+	allowUnverifiedEncoding bool
+}
+
+const (
+	// nitroNodeConfirmDataOffset is the slot of confirmData relative to the node's slot. The
+	// three bytes32 fields before the packed uint64s each take a full slot, so it is also the
+	// word of confirmData in the ABI encoded getNode result.
+	nitroNodeConfirmDataOffset = 2
+	// nitroNodeCreatedAtBlockWord is the word of createdAtBlock in the ABI encoded getNode
+	// result, where every field takes a word. It is not a storage offset: in storage the
+	// uint64 fields are packed four to a slot.
+	nitroNodeCreatedAtBlockWord = 10
+)
+
+// Word offsets in the NodeCreated event data of the block hash and send root of the
+// assertion's after state: executionHash, then beforeState (5 words), then afterState
+const (
+	nitroNodeCreatedBlockHashWord = 6
+	nitroNodeCreatedSendRootWord  = 7
+)
+
+// NewArbitrumNitroProver creates a new prover instance for Arbitrum Nitro
+func NewArbitrumNitroProver(l1Client IEthClient, l1RPC, l2RPC IRPCClient) (*ArbitrumNitroProver, error) {
+	rollupABI, err := getNitroRollupABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Nitro rollup ABI: %w", err)
+	}
+
+	return &ArbitrumNitroProver{
+		l1Client:  l1Client,
+		l1RPC:     l1RPC,
+		l2RPC:     l2RPC,
+		rollupABI: rollupABI,
+	}, nil
+}
+
+// AllowUnverifiedEncoding enables settled state proofs, whose encoding is not checked against an
+// on-chain verifier
+func (p *ArbitrumNitroProver) AllowUnverifiedEncoding() {
+	p.allowUnverifiedEncoding = true
+}
+
+// getNitroRollupABI returns the ABI for the parts of the Nitro rollup contract used here
+func getNitroRollupABI() (abi.ABI, error) {
+	return abi.JSON(strings.NewReader(`[
+		{
+			"inputs": [],
+			"name": "genesisAssertionHash",
+			"outputs": [{"internalType": "bytes32", "name": "", "type": "bytes32"}],
+			"stateMutability": "pure",
+			"type": "function"
+		},
+		{
+			"inputs": [],
+			"name": "latestConfirmed",
+			"outputs": [
+				{
+					"internalType": "uint64",
+					"name": "",
+					"type": "uint64"
+				}
+			],
+			"stateMutability": "view",
+			"type": "function"
+		},
+		{
+			"inputs": [
+				{
+					"internalType": "uint64",
+					"name": "nodeNum",
+					"type": "uint64"
+				}
+			],
+			"name": "getNode",
+			"outputs": [
+				{
+					"components": [
+						{"internalType": "bytes32", "name": "stateHash", "type": "bytes32"},
+						{"internalType": "bytes32", "name": "challengeHash", "type": "bytes32"},
+						{"internalType": "bytes32", "name": "confirmData", "type": "bytes32"},
+						{"internalType": "uint64", "name": "prevNum", "type": "uint64"},
+						{"internalType": "uint64", "name": "deadlineBlock", "type": "uint64"},
+						{"internalType": "uint64", "name": "noChildConfirmedBeforeBlock", "type": "uint64"},
+						{"internalType": "uint64", "name": "stakerCount", "type": "uint64"},
+						{"internalType": "uint64", "name": "childStakerCount", "type": "uint64"},
+						{"internalType": "uint64", "name": "firstChildBlock", "type": "uint64"},
+						{"internalType": "uint64", "name": "latestChildNumber", "type": "uint64"},
+						{"internalType": "uint64", "name": "createdAtBlock", "type": "uint64"},
+						{"internalType": "bytes32", "name": "nodeHash", "type": "bytes32"}
+					],
+					"internalType": "struct Node",
+					"name": "",
+					"type": "tuple"
+				}
+			],
+			"stateMutability": "view",
+			"type": "function"
+		},
+		{
+			"anonymous": false,
+			"inputs": [
+				{"indexed": true, "internalType": "uint64", "name": "nodeNum", "type": "uint64"},
+				{"indexed": true, "internalType": "bytes32", "name": "parentNodeHash", "type": "bytes32"},
+				{"indexed": true, "internalType": "bytes32", "name": "nodeHash", "type": "bytes32"},
+				{"indexed": false, "internalType": "bytes32", "name": "executionHash", "type": "bytes32"},
+				{
+					"components": [
+						{
+							"components": [
+								{
+									"components": [
+										{"internalType": "bytes32[2]", "name": "bytes32Vals", "type": "bytes32[2]"},
+										{"internalType": "uint64[2]", "name": "u64Vals", "type": "uint64[2]"}
+									],
+									"internalType": "struct GlobalState",
+									"name": "globalState",
+									"type": "tuple"
+								},
+								{"internalType": "enum MachineStatus", "name": "machineStatus", "type": "uint8"}
+							],
+							"internalType": "struct ExecutionState",
+							"name": "beforeState",
+							"type": "tuple"
+						},
+						{
+							"components": [
+								{
+									"components": [
+										{"internalType": "bytes32[2]", "name": "bytes32Vals", "type": "bytes32[2]"},
+										{"internalType": "uint64[2]", "name": "u64Vals", "type": "uint64[2]"}
+									],
+									"internalType": "struct GlobalState",
+									"name": "globalState",
+									"type": "tuple"
+								},
+								{"internalType": "enum MachineStatus", "name": "machineStatus", "type": "uint8"}
+							],
+							"internalType": "struct ExecutionState",
+							"name": "afterState",
+							"type": "tuple"
+						},
+						{"internalType": "uint64", "name": "numBlocks", "type": "uint64"}
+					],
+					"indexed": false,
+					"internalType": "struct Assertion",
+					"name": "assertion",
+					"type": "tuple"
+				},
+				{"indexed": false, "internalType": "bytes32", "name": "afterInboxBatchAcc", "type": "bytes32"},
+				{"indexed": false, "internalType": "bytes32", "name": "wasmModuleRoot", "type": "bytes32"},
+				{"indexed": false, "internalType": "uint256", "name": "inboxMaxCount", "type": "uint256"}
+			],
+			"name": "NodeCreated",
+			"type": "event"
+		}
+	]`))
+}
+
+// NitroNodeSlot returns the storage slot of _nodes[nodeNum] given the mapping's base slot
+func NitroNodeSlot(nodesBaseSlot *big.Int, nodeNum uint64) common.Hash {
+	return crypto.Keccak256Hash(
+		common.LeftPadBytes(new(big.Int).SetUint64(nodeNum).Bytes(), 32),
+		common.LeftPadBytes(nodesBaseSlot.Bytes(), 32),
+	)
+}
+
+// NitroConfirmData computes the confirmData a rollup node commits to for its L2 block
+func NitroConfirmData(blockHash, sendRoot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(blockHash.Bytes(), sendRoot.Bytes())
+}
+
+// NitroConfirmDataMismatchError is returned when the L2 block announced for a rollup node
+// does not match the confirmData stored for it on L1
+type NitroConfirmDataMismatchError struct {
+	NodeNum     uint64
+	ConfirmData common.Hash
+	BlockHash   common.Hash
+	SendRoot    common.Hash
+}
+
+func (e *NitroConfirmDataMismatchError) Error() string {
+	return fmt.Sprintf(
+		"rollup node %d confirm data %s does not commit to block %s with send root %s",
+		e.NodeNum,
+		e.ConfirmData.Hex(),
+		e.BlockHash.Hex(),
+		e.SendRoot.Hex(),
+	)
+}
+
+// NitroBoldRollupError is returned for a rollup running BOLD, which settles assertions rather
+// than the nodes this prover proves
+type NitroBoldRollupError struct {
+	Rollup common.Address
+}
+
+func (e *NitroBoldRollupError) Error() string {
+	return fmt.Sprintf("rollup %s runs BOLD, whose assertions are not supported; only legacy rollup nodes can be proven",
+		e.Rollup.Hex())
+}
+
+// NitroUnverifiedEncodingError is returned for settled state proofs unless they were enabled with
+// AllowUnverifiedEncoding. No deployed verifier checks the NitroSettledStateProof encoding yet,
+// so a proof may be rejected on-chain.
+type NitroUnverifiedEncodingError struct {
+	Rollup common.Address
+}
+
+func (e *NitroUnverifiedEncodingError) Error() string {
+	return fmt.Sprintf("settled state proofs for rollup %s use an encoding no on-chain verifier checks; "+
+		"enable unverified Nitro proofs to generate them anyway", e.Rollup.Hex())
+}
+
+func (p *ArbitrumNitroProver) FindLatestResolved(
+	ctx context.Context,
+	config *types.L2ConfigInfo,
+	l1BlockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	return p.latestConfirmed(ctx, config, l1BlockNumber)
+}
+
+// FindResolvedAtOrAfter returns the latest confirmed node at l1BlockNumber. The settled state
+// proof is checked against _latestConfirmed, so older nodes cannot be proven, and an error is
+// returned when the node settles an L2 block before l2BlockNumber.
+func (p *ArbitrumNitroProver) FindResolvedAtOrAfter(
+	ctx context.Context,
+	config *types.L2ConfigInfo,
+	l2BlockNumber *big.Int,
+	l1BlockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	nodeNum, rollupAddr, err := p.latestConfirmed(ctx, config, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	_, blockHash, _, err := p.getNodeAssertion(ctx, rollupAddr, nodeNum.Uint64(), l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	l2Header, err := p.getL2Header(ctx, blockHash)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if l2Header.Number.Cmp(l2BlockNumber) < 0 {
+		return nil, common.Address{}, fmt.Errorf(
+			"latest confirmed node %s settles L2 block %s, before L2 block %s",
+			nodeNum,
+			l2Header.Number,
+			l2BlockNumber,
+		)
+	}
+
+	return nodeNum, rollupAddr, nil
+}
+
+// latestConfirmed reads the latest confirmed node number from the rollup at blockNumber
+func (p *ArbitrumNitroProver) latestConfirmed(
+	ctx context.Context,
+	config *types.L2ConfigInfo,
+	blockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	if len(config.Addresses) < 1 || len(config.StorageSlots) < 2 {
+		return nil, common.Address{}, fmt.Errorf("invalid config: addresses or slots are insufficient")
+	}
+	rollupAddr := config.Addresses[0]
+
+	// Only BOLD rollups have a genesis assertion. The call reverts on legacy rollups.
+	genesisAssertionHashData, err := p.rollupABI.Pack("genesisAssertionHash")
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to pack genesisAssertionHash call: %w", err)
+	}
+	genesisAssertionHash, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &rollupAddr,
+		Data: genesisAssertionHashData,
+	}, blockNumber)
+	if err == nil && len(genesisAssertionHash) == 32 {
+		return nil, common.Address{}, &NitroBoldRollupError{Rollup: rollupAddr}
+	}
+
+	latestConfirmedData, err := p.rollupABI.Pack("latestConfirmed")
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to pack latestConfirmed call: %w", err)
+	}
+	latestConfirmedResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &rollupAddr,
+		Data: latestConfirmedData,
+	}, blockNumber)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to call latestConfirmed: %w", err)
+	}
+	if len(latestConfirmedResult) != 32 {
+		return nil, common.Address{}, fmt.Errorf("invalid latestConfirmed result of %d bytes", len(latestConfirmedResult))
+	}
+
+	nodeNum := new(big.Int).SetBytes(latestConfirmedResult)
+	if !nodeNum.IsUint64() {
+		return nil, common.Address{}, fmt.Errorf("invalid latest confirmed node %s", nodeNum)
+	}
+	log.Debug("Latest confirmed rollup node", "rollup", rollupAddr.Hex(), "node", nodeNum)
+
+	return nodeNum, rollupAddr, nil
+}
+
+// GenerateSettledStateProof creates a proof for an Arbitrum Nitro L2 against L1
+func (p *ArbitrumNitroProver) GenerateSettledStateProof(
+	ctx context.Context,
+	l1Header *types2.Header,
+	nodeIndex *big.Int,
+	rollupAddr common.Address,
+	config *types.L2ConfigInfo,
+) ([]byte, *types2.Header, error) {
+	if !p.allowUnverifiedEncoding {
+		return nil, nil, &NitroUnverifiedEncodingError{Rollup: rollupAddr}
+	}
+	if len(config.Addresses) < 1 || len(config.StorageSlots) < 2 {
+		return nil, nil, fmt.Errorf("invalid config: addresses or slots are insufficient")
+	}
+	if !nodeIndex.IsUint64() {
+		return nil, nil, fmt.Errorf("invalid rollup node %s", nodeIndex)
+	}
+	nodeNum := nodeIndex.Uint64()
+	l1BlockNumber := l1Header.Number
+
+	latestConfirmedSlot := common.BigToHash(config.StorageSlots[0])
+	nodeSlot := NitroNodeSlot(config.StorageSlots[1], nodeNum)
+	confirmDataSlot := common.BigToHash(new(big.Int).Add(nodeSlot.Big(), big.NewInt(nitroNodeConfirmDataOffset)))
+
+	confirmData, blockHash, sendRoot, err := p.getNodeAssertion(ctx, rollupAddr, nodeNum, l1BlockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Prove the node is the latest confirmed one and what it committed to
+	var rollupProof types.StorageProofResult
+	err = p.l1RPC.CallContext(
+		ctx,
+		&rollupProof,
+		"eth_getProof",
+		rollupAddr.Hex(),
+		[]string{latestConfirmedSlot.Hex(), confirmDataSlot.Hex()},
+		toBlockNumArg(l1BlockNumber),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get rollup proof: %w", err)
+	}
+	if len(rollupProof.StorageProof) != 2 {
+		return nil, nil, fmt.Errorf("expected 2 rollup storage proofs, got %d", len(rollupProof.StorageProof))
+	}
+	if rollupProof.Nonce == nil || rollupProof.Balance == nil {
+		return nil, nil, fmt.Errorf("incomplete account data in rollup proof")
+	}
+	if err := verify.ProofResult(l1Header.Root, &rollupProof); err != nil {
+		return nil, nil, fmt.Errorf("failed to verify rollup proof: %w", err)
+	}
+
+	var latestConfirmedSlotValue, provenConfirmData common.Hash
+	if value := rollupProof.StorageProof[0].Value; value != nil {
+		latestConfirmedSlotValue = common.BigToHash(value.ToInt())
+	}
+	if value := rollupProof.StorageProof[1].Value; value != nil {
+		provenConfirmData = common.BigToHash(value.ToInt())
+	}
+	// _latestConfirmed is the first uint64 packed in its slot, so it sits in the lowest 8 bytes
+	if provenLatest := new(big.Int).SetBytes(latestConfirmedSlotValue[24:]).Uint64(); provenLatest != nodeNum {
+		return nil, nil, fmt.Errorf("node %d is not the latest confirmed node %d at L1 block %s",
+			nodeNum, provenLatest, l1BlockNumber)
+	}
+	if provenConfirmData != confirmData {
+		return nil, nil, fmt.Errorf("proven confirm data %s for node %d does not match %s",
+			provenConfirmData.Hex(), nodeNum, confirmData.Hex())
+	}
+
+	rlpEncodedRollupData, err := rlp.EncodeToBytes(Account{
+		Nonce:    uint64(*rollupProof.Nonce),
+		Balance:  rollupProof.Balance.ToInt(),
+		Root:     rollupProof.StorageHash,
+		CodeHash: rollupProof.CodeHash.Bytes(),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to RLP encode rollup account: %w", err)
+	}
+
+	// Fetch the L2 header the node settled
+	l2Header, err := p.getL2Header(ctx, blockHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settledStateProof, err := rlp.EncodeToBytes(&NitroSettledStateProof{
+		SendRoot:                    sendRoot,
+		NodeNum:                     common.LeftPadBytes(new(big.Int).SetUint64(nodeNum).Bytes(), 32),
+		LatestConfirmedSlotValue:    latestConfirmedSlotValue,
+		LatestConfirmedStorageProof: verify.DecodeProof(rollupProof.StorageProof[0].Proof),
+		ConfirmDataStorageProof:     verify.DecodeProof(rollupProof.StorageProof[1].Proof),
+		RlpEncodedRollupData:        rlpEncodedRollupData,
+		RollupAccountProof:          verify.DecodeProof(rollupProof.AccountProof),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to RLP encode settled state proof: %w", err)
+	}
+
+	return settledStateProof, l2Header, nil
+}
+
+// nitroLog holds the fields of an eth_getLogs entry read by the prover
+type nitroLog struct {
+	Topics []common.Hash `json:"topics"`
+	Data   hexutil.Bytes `json:"data"`
+}
+
+// getNodeAssertion reads the confirm data of a rollup node at l1BlockNumber together with the
+// L2 block hash and send root it asserts, and checks that they match
+func (p *ArbitrumNitroProver) getNodeAssertion(
+	ctx context.Context,
+	rollupAddr common.Address,
+	nodeNum uint64,
+	l1BlockNumber *big.Int,
+) (common.Hash, common.Hash, common.Hash, error) {
+	// Read the node to learn when it was created and what it committed to
+	getNodeData, err := p.rollupABI.Pack("getNode", nodeNum)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, common.Hash{}, fmt.Errorf("failed to pack getNode call: %w", err)
+	}
+	getNodeResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &rollupAddr,
+		Data: getNodeData,
+	}, l1BlockNumber)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, common.Hash{}, fmt.Errorf("failed to call getNode for node %d: %w", nodeNum, err)
+	}
+	if len(getNodeResult) < 32*(nitroNodeCreatedAtBlockWord+1) {
+		return common.Hash{}, common.Hash{}, common.Hash{}, fmt.Errorf("invalid getNode result of %d bytes", len(getNodeResult))
+	}
+	confirmData := common.BytesToHash(getNodeResult[32*nitroNodeConfirmDataOffset : 32*(nitroNodeConfirmDataOffset+1)])
+	createdAtBlock := new(big.Int).SetBytes(
+		getNodeResult[32*nitroNodeCreatedAtBlockWord : 32*(nitroNodeCreatedAtBlockWord+1)],
+	)
+
+	// The NodeCreated event carries the L2 block hash and send root the node asserts
+	blockHash, sendRoot, err := p.getNodeCreated(ctx, rollupAddr, nodeNum, createdAtBlock)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, common.Hash{}, err
+	}
+	if NitroConfirmData(blockHash, sendRoot) != confirmData {
+		return common.Hash{}, common.Hash{}, common.Hash{}, &NitroConfirmDataMismatchError{
+			NodeNum:     nodeNum,
+			ConfirmData: confirmData,
+			BlockHash:   blockHash,
+			SendRoot:    sendRoot,
+		}
+	}
+
+	return confirmData, blockHash, sendRoot, nil
+}
+
+// getL2Header fetches the L2 header with the given hash and checks that it hashes to it
+func (p *ArbitrumNitroProver) getL2Header(ctx context.Context, blockHash common.Hash) (*types2.Header, error) {
+	var l2Header types2.Header
+	if err := p.l2RPC.CallContext(ctx, &l2Header, "eth_getBlockByHash", blockHash, false); err != nil {
+		return nil, fmt.Errorf("failed to get L2 block %s: %w", blockHash.Hex(), err)
+	}
+	if l2Header.Hash() != blockHash {
+		return nil, fmt.Errorf("L2 header hashes to %s, expected %s", l2Header.Hash().Hex(), blockHash.Hex())
+	}
+	return &l2Header, nil
+}
+
+// getNodeCreated reads the L2 block hash and send root asserted by a rollup node from
+// its NodeCreated event, emitted in the node's createdAtBlock
+func (p *ArbitrumNitroProver) getNodeCreated(
+	ctx context.Context,
+	rollupAddr common.Address,
+	nodeNum uint64,
+	createdAtBlock *big.Int,
+) (common.Hash, common.Hash, error) {
+	nodeCreated := p.rollupABI.Events["NodeCreated"]
+	filter := map[string]interface{}{
+		"address":   rollupAddr,
+		"fromBlock": toBlockNumArg(createdAtBlock),
+		"toBlock":   toBlockNumArg(createdAtBlock),
+		"topics": [][]common.Hash{
+			{nodeCreated.ID},
+			{common.BigToHash(new(big.Int).SetUint64(nodeNum))},
+		},
+	}
+
+	var logs []nitroLog
+	if err := p.l1RPC.CallContext(ctx, &logs, "eth_getLogs", filter); err != nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("failed to get NodeCreated logs for node %d: %w", nodeNum, err)
+	}
+	if len(logs) != 1 {
+		return common.Hash{}, common.Hash{}, fmt.Errorf(
+			"expected 1 NodeCreated log for node %d in block %s, got %d",
+			nodeNum,
+			createdAtBlock,
+			len(logs),
+		)
+	}
+
+	data := logs[0].Data
+	if len(data) < 32*(nitroNodeCreatedSendRootWord+1) {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("invalid NodeCreated data of %d bytes", len(data))
+	}
+	blockHash := common.BytesToHash(data[32*nitroNodeCreatedBlockHashWord : 32*(nitroNodeCreatedBlockHashWord+1)])
+	sendRoot := common.BytesToHash(data[32*nitroNodeCreatedSendRootWord : 32*(nitroNodeCreatedSendRootWord+1)])
+	log.Debug("Rollup node assertion", "node", nodeNum, "blockHash", blockHash, "sendRoot", sendRoot)
+
+	return blockHash, sendRoot, nil
+}
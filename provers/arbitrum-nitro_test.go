@@ -0,0 +1,287 @@
+package provers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polymerdao/fallback_prover/provers/verify"
+	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArbitrumNitroProver_FindLatestResolved(t *testing.T) {
+	rollupAddr := common.HexToAddress("0x5e1497dd1f08c87b2d8fe23e9aab6c1de833d927")
+	config := &types2.L2ConfigInfo{
+		ConfigType:   "Arbitrum",
+		Addresses:    []common.Address{rollupAddr},
+		StorageSlots: []*big.Int{big.NewInt(0x75), big.NewInt(0x76)},
+	}
+
+	rollupABI, err := getNitroRollupABI()
+	require.NoError(t, err)
+
+	// A BOLD rollup answers genesisAssertionHash, a legacy rollup reverts
+	bold := false
+	mockL1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, rollupAddr, *msg.To)
+			require.Equal(t, big.NewInt(5000), blockNumber)
+			method, err := rollupABI.MethodById(msg.Data[:4])
+			require.NoError(t, err)
+			switch method.Name {
+			case "genesisAssertionHash":
+				if bold {
+					return common.HexToHash("0x9e2e").Bytes(), nil
+				}
+				return nil, fmt.Errorf("execution reverted")
+			case "latestConfirmed":
+				return common.BigToHash(big.NewInt(42)).Bytes(), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", method.Name)
+		},
+	}
+
+	prover, err := NewArbitrumNitroProver(mockL1Client, nil, nil)
+	require.NoError(t, err)
+
+	nodeNum, addr, err := prover.FindLatestResolved(context.Background(), config, big.NewInt(5000))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), nodeNum)
+	assert.Equal(t, rollupAddr, addr)
+
+	_, _, err = prover.FindLatestResolved(context.Background(), &types2.L2ConfigInfo{
+		Addresses:    config.Addresses,
+		StorageSlots: config.StorageSlots[:1],
+	}, big.NewInt(5000))
+	require.Error(t, err)
+
+	bold = true
+	var boldErr *NitroBoldRollupError
+	_, _, err = prover.FindLatestResolved(context.Background(), config, big.NewInt(5000))
+	require.ErrorAs(t, err, &boldErr)
+	assert.Equal(t, rollupAddr, boldErr.Rollup)
+}
+
+func TestArbitrumNitroProver_GenerateSettledStateProof(t *testing.T) {
+	rollupABI, err := getNitroRollupABI()
+	require.NoError(t, err)
+
+	// Create test data
+	rollupAddr := common.HexToAddress("0x5e1497dd1f08c87b2d8fe23e9aab6c1de833d927")
+	nodeNum := uint64(42)
+	createdAtBlock := uint64(19000000)
+	sendRoot := common.HexToHash("0x5e4d")
+	config := &types2.L2ConfigInfo{
+		ConfigType:   "Arbitrum",
+		Addresses:    []common.Address{rollupAddr},
+		StorageSlots: []*big.Int{big.NewInt(0x75), big.NewInt(0x76)},
+	}
+	latestConfirmedSlot := common.BigToHash(config.StorageSlots[0])
+	confirmDataSlot := common.BigToHash(
+		new(big.Int).Add(NitroNodeSlot(config.StorageSlots[1], nodeNum).Big(), big.NewInt(nitroNodeConfirmDataOffset)),
+	)
+
+	// Build the L2 header the node settles
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(common.HexToAddress("0x1234"), common.HexToHash("0x1"), common.HexToHash("0x1"))
+	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Root = l2State.Root(t)
+	confirmData := NitroConfirmData(l2Header.Hash(), sendRoot)
+
+	// Build real L1 state holding the confirmed node. _latestConfirmed is packed with the
+	// _firstUnresolvedNode stored next to it.
+	latestConfirmedSlotValue := common.BigToHash(
+		new(big.Int).Or(new(big.Int).SetUint64(nodeNum), new(big.Int).Lsh(new(big.Int).SetUint64(nodeNum+1), 64)),
+	)
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(rollupAddr, latestConfirmedSlot, latestConfirmedSlotValue)
+	l1State.SetStorage(rollupAddr, confirmDataSlot, confirmData)
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
+
+	mockL1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, rollupAddr, *msg.To)
+			require.Equal(t, l1Header.Number, blockNumber)
+			method, err := rollupABI.MethodById(msg.Data[:4])
+			require.NoError(t, err)
+			switch method.Name {
+			case "genesisAssertionHash":
+				return nil, fmt.Errorf("execution reverted")
+			case "latestConfirmed":
+				return common.BigToHash(new(big.Int).SetUint64(nodeNum)).Bytes(), nil
+			case "getNode":
+				node := make([]byte, 12*32)
+				copy(node[32*nitroNodeConfirmDataOffset:], confirmData.Bytes())
+				copy(node[32*nitroNodeCreatedAtBlockWord:], common.BigToHash(new(big.Int).SetUint64(createdAtBlock)).Bytes())
+				return node, nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", method.Name)
+		},
+	}
+
+	// The NodeCreated event asserts the L2 block hash and send root of the node
+	eventSendRoot := sendRoot
+	mockL1RPC := &testutil.MockRPCClient{
+		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			var data interface{}
+			switch method {
+			case "eth_getLogs":
+				filter := args[0].(map[string]interface{})
+				require.Equal(t, hexutil.EncodeUint64(createdAtBlock), filter["fromBlock"])
+				topics := filter["topics"].([][]common.Hash)
+				require.Equal(t, rollupABI.Events["NodeCreated"].ID, topics[0][0])
+				require.Equal(t, common.BigToHash(new(big.Int).SetUint64(nodeNum)), topics[1][0])
+				data = []map[string]interface{}{{
+					"address": rollupAddr,
+					"topics":  []common.Hash{topics[0][0], topics[1][0], {}, {}},
+					"data":    hexutil.Bytes(nitroNodeCreatedData(l2Header.Hash(), eventSendRoot)),
+				}}
+			case "eth_getProof":
+				require.Equal(t, []string{latestConfirmedSlot.Hex(), confirmDataSlot.Hex()}, args[1])
+				data = l1State.GetProof(t, rollupAddr, latestConfirmedSlot, confirmDataSlot)
+			default:
+				return fmt.Errorf("unexpected L1 method %s", method)
+			}
+			raw, err := json.Marshal(data)
+			require.NoError(t, err)
+			return json.Unmarshal(raw, result)
+		},
+	}
+
+	servedL2Header := l2Header
+	mockL2RPC := &testutil.MockRPCClient{
+		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			require.Equal(t, "eth_getBlockByHash", method)
+			require.Equal(t, l2Header.Hash(), args[0])
+			raw, err := json.Marshal(servedL2Header)
+			require.NoError(t, err)
+			return json.Unmarshal(raw, result)
+		},
+	}
+
+	prover, err := NewArbitrumNitroProver(mockL1Client, mockL1RPC, mockL2RPC)
+	require.NoError(t, err)
+	generate := func() ([]byte, *types.Header, error) {
+		return prover.GenerateSettledStateProof(
+			context.Background(),
+			l1Header,
+			new(big.Int).SetUint64(nodeNum),
+			rollupAddr,
+			config,
+		)
+	}
+
+	// No verifier checks the encoding, so proofs are refused until explicitly allowed
+	var unverifiedErr *NitroUnverifiedEncodingError
+	_, _, err = generate()
+	require.ErrorAs(t, err, &unverifiedErr)
+	assert.Equal(t, rollupAddr, unverifiedErr.Rollup)
+
+	prover.AllowUnverifiedEncoding()
+	settledStateProof, settledL2Header, err := generate()
+	require.NoError(t, err)
+	assert.Equal(t, l2Header.Hash(), settledL2Header.Hash())
+
+	// The proof decodes back to the node and holds against the L1 state root
+	decoded, err := DecodeNitroSettledStateProof(settledStateProof)
+	require.NoError(t, err)
+	assert.Equal(t, sendRoot, decoded.SendRoot)
+	assert.Equal(t, common.BigToHash(new(big.Int).SetUint64(nodeNum)).Bytes(), decoded.NodeNum)
+	assert.Equal(t, latestConfirmedSlotValue, decoded.LatestConfirmedSlotValue)
+
+	require.NoError(t, verify.AccountProof(l1Header.Root, rollupAddr, decoded.RlpEncodedRollupData, decoded.RollupAccountProof))
+	var rollupAccount Account
+	require.NoError(t, rlp.DecodeBytes(decoded.RlpEncodedRollupData, &rollupAccount))
+	require.NoError(t, verify.StorageProof(
+		rollupAccount.Root,
+		latestConfirmedSlot,
+		decoded.LatestConfirmedSlotValue,
+		decoded.LatestConfirmedStorageProof,
+	))
+	rlpEncodedL2Header, err := rlp.EncodeToBytes(settledL2Header)
+	require.NoError(t, err)
+	require.NoError(t, verify.StorageProof(
+		rollupAccount.Root,
+		confirmDataSlot,
+		NitroConfirmData(crypto.Keccak256Hash(rlpEncodedL2Header), decoded.SendRoot),
+		decoded.ConfirmDataStorageProof,
+	))
+
+	// Only the latest confirmed node can be proven, so a later target is an error
+	index, addr, err := prover.FindResolvedAtOrAfter(context.Background(), config, l2Header.Number, l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, new(big.Int).SetUint64(nodeNum), index)
+	assert.Equal(t, rollupAddr, addr)
+
+	_, _, err = prover.FindResolvedAtOrAfter(
+		context.Background(),
+		config,
+		new(big.Int).Add(l2Header.Number, big.NewInt(1)),
+		l1Header.Number,
+	)
+	require.ErrorContains(t, err, fmt.Sprintf("latest confirmed node 42 settles L2 block %s", l2Header.Number))
+
+	// The event must assert what the node's confirmData commits to
+	eventSendRoot = common.HexToHash("0xbad")
+	var mismatchErr *NitroConfirmDataMismatchError
+	_, _, err = generate()
+	require.ErrorAs(t, err, &mismatchErr)
+	assert.Equal(t, nodeNum, mismatchErr.NodeNum)
+	assert.Equal(t, eventSendRoot, mismatchErr.SendRoot)
+	eventSendRoot = sendRoot
+
+	// The L2 RPC must serve the header the node settled
+	forkedHeader := types.CopyHeader(l2Header)
+	forkedHeader.Number = big.NewInt(1)
+	servedL2Header = forkedHeader
+	_, _, err = generate()
+	require.ErrorContains(t, err, "L2 header hashes to")
+	servedL2Header = l2Header
+
+	// A node confirmed after the requested one is proven to be the latest
+	l1State.SetStorage(rollupAddr, latestConfirmedSlot, common.BigToHash(new(big.Int).SetUint64(nodeNum+1)))
+	l1Header.Root = l1State.Root(t)
+	_, _, err = generate()
+	require.ErrorContains(t, err, "is not the latest confirmed node 43")
+}
+
+func TestArbitrumNitroProver_NodeCreatedLayout(t *testing.T) {
+	rollupABI, err := getNitroRollupABI()
+	require.NoError(t, err)
+
+	// The word offsets used by the prover must agree with the ABI layout of the event
+	blockHash := common.HexToHash("0xb10c")
+	sendRoot := common.HexToHash("0x5e4d")
+	values, err := rollupABI.Events["NodeCreated"].Inputs.NonIndexed().Unpack(nitroNodeCreatedData(blockHash, sendRoot))
+	require.NoError(t, err)
+	globalState := reflect.ValueOf(values[1]).FieldByName("AfterState").FieldByName("GlobalState")
+	bytes32Vals := globalState.FieldByName("Bytes32Vals")
+	assert.Equal(t, [32]byte(blockHash), bytes32Vals.Index(0).Interface())
+	assert.Equal(t, [32]byte(sendRoot), bytes32Vals.Index(1).Interface())
+}
+
+// nitroNodeCreatedData lays out the non-indexed NodeCreated fields with the given after state
+func nitroNodeCreatedData(blockHash, sendRoot common.Hash) []byte {
+	words := make([]common.Hash, 15)
+	words[0] = common.HexToHash("0xe7ec") // executionHash
+	words[nitroNodeCreatedBlockHashWord] = blockHash
+	words[nitroNodeCreatedSendRootWord] = sendRoot
+	var data []byte
+	for _, word := range words {
+		data = append(data, word.Bytes()...)
+	}
+	return data
+}
@@ -0,0 +1,40 @@
+package provers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// getBundledABI loads and parses one of the ABI files shipped in the abis directory
+func getBundledABI(name string) (abi.ABI, error) {
+	// Get the absolute path of the current file
+	_, thisFile, _, _ := runtime.Caller(0)
+	// Construct the path to the ABI file
+	abiPath := filepath.Join(filepath.Dir(thisFile), "abis", name+".abi.json")
+
+	// Read the ABI file
+	abiFile, err := os.Open(abiPath)
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to open %s ABI file: %w", name, err)
+	}
+	defer abiFile.Close()
+
+	abiBytes, err := io.ReadAll(abiFile)
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to read %s ABI file: %w", name, err)
+	}
+
+	// Parse the ABI
+	parsedABI, err := abi.JSON(strings.NewReader(string(abiBytes)))
+	if err != nil {
+		return abi.ABI{}, fmt.Errorf("failed to parse %s ABI: %w", name, err)
+	}
+
+	return parsedABI, nil
+}
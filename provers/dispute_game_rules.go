@@ -0,0 +1,163 @@
+package provers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CannonGameRules are the checks a DEFENDER_WINS dispute game must also pass to be selected as
+// the settled state of an OP Stack Cannon chain
+type CannonGameRules struct {
+	// OptimismPortal is the chain's OptimismPortal2. Games it blacklists, and games that were not
+	// created under its respected game type, are skipped. The zero address skips these checks.
+	OptimismPortal common.Address
+	// FinalityDelaySeconds is the air-gap a game must have been resolved for at the L1 block,
+	// from the chain's L2Configuration
+	FinalityDelaySeconds uint64
+}
+
+// SetGameRules sets the rules dispute games are selected by
+func (p *OPStackCannonProver) SetGameRules(rules CannonGameRules) {
+	p.rules = rules
+}
+
+// getOptimismPortal2ABI returns the ABI for the dispute game checks of the OptimismPortal2 contract
+func getOptimismPortal2ABI() (abi.ABI, error) {
+	return abi.JSON(strings.NewReader(`[
+		{
+			"inputs": [{"internalType": "contract IDisputeGame", "name": "", "type": "address"}],
+			"name": "disputeGameBlacklist",
+			"outputs": [{"internalType": "bool", "name": "", "type": "bool"}],
+			"stateMutability": "view",
+			"type": "function"
+		},
+		{
+			"inputs": [],
+			"name": "respectedGameType",
+			"outputs": [{"internalType": "GameType", "name": "", "type": "uint32"}],
+			"stateMutability": "view",
+			"type": "function"
+		},
+		{
+			"inputs": [],
+			"name": "respectedGameTypeUpdatedAt",
+			"outputs": [{"internalType": "uint64", "name": "", "type": "uint64"}],
+			"stateMutability": "view",
+			"type": "function"
+		}
+	]`))
+}
+
+// checkGameId applies the rules that only need the game's GameId and returns why the game is
+// rejected, or "" if it passes them
+func (p *OPStackCannonProver) checkGameId(s *gameSearch, game *disputeGame) string {
+	if p.rules.OptimismPortal == (common.Address{}) {
+		return ""
+	}
+	gameType, createdAt, _ := UnpackGameId(game.id)
+	if gameType != s.respectedGameType {
+		return fmt.Sprintf("game type %d is not the respected game type %d", gameType, s.respectedGameType)
+	}
+	if createdAt < s.respectedGameTypeUpdatedAt {
+		return fmt.Sprintf(
+			"created at %d before the respected game type was updated at %d",
+			createdAt,
+			s.respectedGameTypeUpdatedAt,
+		)
+	}
+	return ""
+}
+
+// checkGameState applies the rules that need the portal's blacklist and the games' resolution
+// time, reading them in a single batch, and returns why each game is rejected, or "" if it passes
+func (p *OPStackCannonProver) checkGameState(ctx context.Context, s *gameSearch, games []*disputeGame) ([]string, error) {
+	reasons := make([]string, len(games))
+	resolvedAt := make([]uint64, len(games))
+	resolvedAtResults := make([]hexutil.Bytes, len(games))
+	blacklistResults := make([]hexutil.Bytes, len(games))
+	var elems []rpc.BatchElem
+	// elemGames and elemBlacklist tell which game and check each batch element belongs to
+	var elemGames []int
+	var elemBlacklist []bool
+
+	resolvedAtData, err := p.gameABI.Pack("resolvedAt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack resolvedAt call: %w", err)
+	}
+	p.games.mu.Lock()
+	for i, game := range games {
+		if p.rules.FinalityDelaySeconds > 0 {
+			if at, ok := p.games.resolvedAt[game.address()]; ok {
+				resolvedAt[i] = at
+			} else {
+				elems = append(elems, callElem(game.address(), resolvedAtData, s.l1BlockNumber, &resolvedAtResults[i]))
+				elemGames, elemBlacklist = append(elemGames, i), append(elemBlacklist, false)
+			}
+		}
+		if p.rules.OptimismPortal != (common.Address{}) {
+			blacklistData, err := p.portalABI.Pack("disputeGameBlacklist", game.address())
+			if err != nil {
+				p.games.mu.Unlock()
+				return nil, fmt.Errorf("failed to pack disputeGameBlacklist call: %w", err)
+			}
+			elems = append(elems, callElem(p.rules.OptimismPortal, blacklistData, s.l1BlockNumber, &blacklistResults[i]))
+			elemGames, elemBlacklist = append(elemGames, i), append(elemBlacklist, true)
+		}
+	}
+	p.games.mu.Unlock()
+
+	if len(elems) > 0 {
+		if err := p.l1RPC.BatchCallContext(ctx, elems); err != nil {
+			return nil, fmt.Errorf("failed to batch call: %w", err)
+		}
+	}
+	p.games.mu.Lock()
+	for j, elem := range elems {
+		index := elemGames[j]
+		switch {
+		case elem.Error != nil && elemBlacklist[j]:
+			reasons[index] = fmt.Sprintf("failed to read the portal blacklist: %v", elem.Error)
+		case elem.Error != nil:
+			reasons[index] = fmt.Sprintf("failed to call resolvedAt: %v", elem.Error)
+		case elemBlacklist[j]:
+			if len(blacklistResults[index]) != 32 {
+				reasons[index] = fmt.Sprintf("unexpected disputeGameBlacklist result length %d", len(blacklistResults[index]))
+			} else if new(big.Int).SetBytes(blacklistResults[index]).Sign() != 0 {
+				reasons[index] = fmt.Sprintf("blacklisted by portal %s", p.rules.OptimismPortal.Hex())
+			}
+		default:
+			if len(resolvedAtResults[index]) != 32 {
+				reasons[index] = fmt.Sprintf("unexpected resolvedAt result length %d", len(resolvedAtResults[index]))
+			} else {
+				resolvedAt[index] = new(big.Int).SetBytes(resolvedAtResults[index]).Uint64()
+				p.games.resolvedAt[games[index].address()] = resolvedAt[index]
+			}
+		}
+	}
+	p.games.mu.Unlock()
+
+	if p.rules.FinalityDelaySeconds > 0 {
+		for i := range games {
+			if reasons[i] != "" {
+				continue
+			}
+			if final := resolvedAt[i] + p.rules.FinalityDelaySeconds; final > s.l1Timestamp {
+				reasons[i] = fmt.Sprintf(
+					"resolved at %d and not final until %d after the %ds finality delay, L1 block time is %d",
+					resolvedAt[i],
+					final,
+					p.rules.FinalityDelaySeconds,
+					s.l1Timestamp,
+				)
+			}
+		}
+	}
+	return reasons, nil
+}
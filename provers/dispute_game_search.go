@@ -0,0 +1,416 @@
+package provers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	types2 "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// disputeGameBatchSize is the number of games looked up per L1 RPC batch
+const disputeGameBatchSize = 100
+
+// disputeGame is a DisputeGameFactory entry and its status at the L1 block being searched
+type disputeGame struct {
+	index uint64
+	id    common.Hash
+	// status is only meaningful if err is nil
+	status GameStatus
+	err    error
+}
+
+func (g *disputeGame) address() common.Address {
+	_, _, address := UnpackGameId(g.id)
+	return address
+}
+
+// disputeGameKey identifies a factory entry
+type disputeGameKey struct {
+	factory common.Address
+	index   uint64
+}
+
+// resolvedGame is a final game status and the oldest L1 block it was observed at
+type resolvedGame struct {
+	status        GameStatus
+	l1BlockNumber uint64
+}
+
+// disputeGameCache memoizes what a search learns about games that cannot change anymore:
+// factory entries, resolved statuses and times, and the L2 block numbers games were created for
+type disputeGameCache struct {
+	mu             sync.Mutex
+	ids            map[disputeGameKey]common.Hash
+	resolved       map[common.Address]resolvedGame
+	resolvedAt     map[common.Address]uint64
+	l2BlockNumbers map[common.Address]*big.Int
+}
+
+func newDisputeGameCache() *disputeGameCache {
+	return &disputeGameCache{
+		ids:            make(map[disputeGameKey]common.Hash),
+		resolved:       make(map[common.Address]resolvedGame),
+		resolvedAt:     make(map[common.Address]uint64),
+		l2BlockNumbers: make(map[common.Address]*big.Int),
+	}
+}
+
+// status returns the memoized status of a game if it was already resolved at l1BlockNumber
+func (c *disputeGameCache) status(game common.Address, l1BlockNumber uint64) (GameStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resolved, ok := c.resolved[game]
+	if !ok || resolved.l1BlockNumber > l1BlockNumber {
+		return 0, false
+	}
+	return resolved.status, true
+}
+
+// setStatus memoizes a status read at l1BlockNumber once the game has resolved
+func (c *disputeGameCache) setStatus(game common.Address, status GameStatus, l1BlockNumber uint64) {
+	if status == GameStatusInProgress {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if resolved, ok := c.resolved[game]; ok && resolved.l1BlockNumber <= l1BlockNumber {
+		return
+	}
+	c.resolved[game] = resolvedGame{status: status, l1BlockNumber: l1BlockNumber}
+}
+
+// gameSearch is what a search reads once from the factory, the L1 block and the portal
+type gameSearch struct {
+	factory       common.Address
+	listSlot      common.Hash
+	l1BlockNumber *big.Int
+	l1Timestamp   uint64
+	gameCount     uint64
+	// respectedGameType and respectedGameTypeUpdatedAt are only read if an OptimismPortal is set
+	respectedGameType          uint32
+	respectedGameTypeUpdatedAt uint64
+}
+
+// newGameSearch reads the number of games in the factory, the timestamp of the L1 block and
+// the portal's respected game type in a single batch
+func (p *OPStackCannonProver) newGameSearch(
+	ctx context.Context,
+	config *types.L2ConfigInfo,
+	l1BlockNumber *big.Int,
+) (*gameSearch, error) {
+	if len(config.Addresses) < 1 || len(config.StorageSlots) < 3 {
+		return nil, fmt.Errorf("invalid config: addresses or slots are insufficient")
+	}
+	s := &gameSearch{
+		factory:       config.Addresses[0],
+		listSlot:      common.BigToHash(config.StorageSlots[0]),
+		l1BlockNumber: l1BlockNumber,
+	}
+
+	gameCountData, err := p.factoryABI.Pack("gameCount")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack gameCount call: %w", err)
+	}
+	var gameCount, respectedGameType, respectedGameTypeUpdatedAt hexutil.Bytes
+	var l1Header types2.Header
+	elems := []rpc.BatchElem{
+		callElem(s.factory, gameCountData, l1BlockNumber, &gameCount),
+		{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{toBlockNumArg(l1BlockNumber), false},
+			Result: &l1Header,
+		},
+	}
+	portal := p.rules.OptimismPortal
+	if portal != (common.Address{}) {
+		respectedGameTypeData, err := p.portalABI.Pack("respectedGameType")
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack respectedGameType call: %w", err)
+		}
+		respectedGameTypeUpdatedAtData, err := p.portalABI.Pack("respectedGameTypeUpdatedAt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack respectedGameTypeUpdatedAt call: %w", err)
+		}
+		elems = append(elems,
+			callElem(portal, respectedGameTypeData, l1BlockNumber, &respectedGameType),
+			callElem(portal, respectedGameTypeUpdatedAtData, l1BlockNumber, &respectedGameTypeUpdatedAt),
+		)
+	} else {
+		p.portalWarning.Do(func() {
+			log.Warn("No OptimismPortal2 set, dispute games are not checked against its respected game type "+
+				"and blacklist", "factory", s.factory)
+		})
+	}
+	if err := p.l1RPC.BatchCallContext(ctx, elems); err != nil {
+		return nil, fmt.Errorf("failed to batch call: %w", err)
+	}
+	for _, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("l1 RPC batch request error for method %s: %w", elem.Method, elem.Error)
+		}
+	}
+	s.l1Timestamp = l1Header.Time
+
+	if len(gameCount) != 32 {
+		return nil, fmt.Errorf("unexpected gameCount result length %d from %s", len(gameCount), s.factory.Hex())
+	}
+	count := new(big.Int).SetBytes(gameCount)
+	if !count.IsUint64() {
+		return nil, fmt.Errorf("gameCount %s of %s is out of range", count, s.factory.Hex())
+	}
+	s.gameCount = count.Uint64()
+
+	if portal != (common.Address{}) {
+		if len(respectedGameType) != 32 || len(respectedGameTypeUpdatedAt) != 32 {
+			return nil, fmt.Errorf("unexpected respected game type result from portal %s", portal.Hex())
+		}
+		s.respectedGameType = uint32(new(big.Int).SetBytes(respectedGameType).Uint64())
+		s.respectedGameTypeUpdatedAt = new(big.Int).SetBytes(respectedGameTypeUpdatedAt).Uint64()
+	}
+	return s, nil
+}
+
+// loadGameIds returns the GameIds stored at the given factory indices, reading the entries
+// that are not memoized yet in a single batch
+func (p *OPStackCannonProver) loadGameIds(ctx context.Context, s *gameSearch, indices []uint64) ([]common.Hash, error) {
+	ids := make([]common.Hash, len(indices))
+	results := make([]string, len(indices))
+	var missing []int
+	var elems []rpc.BatchElem
+
+	p.games.mu.Lock()
+	for i, index := range indices {
+		if id, ok := p.games.ids[disputeGameKey{s.factory, index}]; ok {
+			ids[i] = id
+			continue
+		}
+		missing = append(missing, i)
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getStorageAt",
+			Args: []interface{}{
+				s.factory.Hex(),
+				disputeGameListSlot(s.listSlot, index).Hex(),
+				toBlockNumArg(s.l1BlockNumber),
+			},
+			Result: &results[i],
+		})
+	}
+	p.games.mu.Unlock()
+	if len(elems) == 0 {
+		return ids, nil
+	}
+
+	if err := p.l1RPC.BatchCallContext(ctx, elems); err != nil {
+		return nil, fmt.Errorf("failed to batch call: %w", err)
+	}
+	p.games.mu.Lock()
+	defer p.games.mu.Unlock()
+	for j, i := range missing {
+		if elems[j].Error != nil {
+			return nil, fmt.Errorf("failed to read game id at index %d: %w", indices[i], elems[j].Error)
+		}
+		id := common.HexToHash(results[i])
+		if id == (common.Hash{}) {
+			return nil, fmt.Errorf("no game id at index %d of %s", indices[i], s.factory.Hex())
+		}
+		ids[i] = id
+		p.games.ids[disputeGameKey{s.factory, indices[i]}] = id
+	}
+	return ids, nil
+}
+
+// loadGames returns the games with indices in [from, to) and their status at l1BlockNumber.
+// A game whose status cannot be read is returned with err set rather than failing the page.
+func (p *OPStackCannonProver) loadGames(ctx context.Context, s *gameSearch, from, to uint64) ([]*disputeGame, error) {
+	indices := make([]uint64, 0, to-from)
+	for index := from; index < to; index++ {
+		indices = append(indices, index)
+	}
+	ids, err := p.loadGameIds(ctx, s, indices)
+	if err != nil {
+		return nil, err
+	}
+
+	statusData, err := p.gameABI.Pack("status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack status call: %w", err)
+	}
+	games := make([]*disputeGame, len(indices))
+	results := make([]hexutil.Bytes, len(indices))
+	var missing []int
+	var elems []rpc.BatchElem
+	for i, index := range indices {
+		games[i] = &disputeGame{index: index, id: ids[i]}
+		if status, ok := p.games.status(games[i].address(), s.l1BlockNumber.Uint64()); ok {
+			games[i].status = status
+			continue
+		}
+		missing = append(missing, i)
+		elems = append(elems, callElem(games[i].address(), statusData, s.l1BlockNumber, &results[i]))
+	}
+	if len(elems) == 0 {
+		return games, nil
+	}
+
+	if err := p.l1RPC.BatchCallContext(ctx, elems); err != nil {
+		return nil, fmt.Errorf("failed to batch call: %w", err)
+	}
+	for j, i := range missing {
+		game := games[i]
+		switch {
+		case elems[j].Error != nil:
+			game.err = fmt.Errorf("failed to call status on %s: %w", game.address().Hex(), elems[j].Error)
+		case len(results[i]) != 32:
+			game.err = fmt.Errorf("unexpected status result length %d from %s", len(results[i]), game.address().Hex())
+		default:
+			game.status = GameStatus(new(big.Int).SetBytes(results[i]).Uint64())
+			p.games.setStatus(game.address(), game.status, s.l1BlockNumber.Uint64())
+		}
+	}
+	return games, nil
+}
+
+// loadL2BlockNumbers returns the L2 block numbers the given games were created for
+func (p *OPStackCannonProver) loadL2BlockNumbers(
+	ctx context.Context,
+	s *gameSearch,
+	games []*disputeGame,
+) ([]*big.Int, error) {
+	l2BlockNumberData, err := p.gameABI.Pack("l2BlockNumber")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack l2BlockNumber call: %w", err)
+	}
+	l2BlockNumbers := make([]*big.Int, len(games))
+	results := make([]hexutil.Bytes, len(games))
+	var missing []int
+	var elems []rpc.BatchElem
+
+	p.games.mu.Lock()
+	for i, game := range games {
+		if l2BlockNumber, ok := p.games.l2BlockNumbers[game.address()]; ok {
+			l2BlockNumbers[i] = l2BlockNumber
+			continue
+		}
+		missing = append(missing, i)
+		elems = append(elems, callElem(game.address(), l2BlockNumberData, s.l1BlockNumber, &results[i]))
+	}
+	p.games.mu.Unlock()
+	if len(elems) == 0 {
+		return l2BlockNumbers, nil
+	}
+
+	if err := p.l1RPC.BatchCallContext(ctx, elems); err != nil {
+		return nil, fmt.Errorf("failed to batch call: %w", err)
+	}
+	p.games.mu.Lock()
+	defer p.games.mu.Unlock()
+	for j, i := range missing {
+		if elems[j].Error != nil {
+			log.Debug("Failed to call l2BlockNumber for game", "address", games[i].address().Hex(), "error", elems[j].Error)
+			continue
+		}
+		if len(results[i]) != 32 {
+			log.Debug("Unexpected l2BlockNumber result for game", "address", games[i].address().Hex(), "len", len(results[i]))
+			continue
+		}
+		l2BlockNumbers[i] = new(big.Int).SetBytes(results[i])
+		p.games.l2BlockNumbers[games[i].address()] = l2BlockNumbers[i]
+	}
+	return l2BlockNumbers, nil
+}
+
+// eligibleGames returns the games of a page that can be proven against, newest first: games
+// that are DEFENDER_WINS and pass the CannonGameRules. The reasons other resolved games are
+// skipped for are logged.
+func (p *OPStackCannonProver) eligibleGames(ctx context.Context, s *gameSearch, games []*disputeGame) ([]*disputeGame, error) {
+	var candidates []*disputeGame
+	for i := len(games) - 1; i >= 0; i-- {
+		game := games[i]
+		if game.err != nil {
+			log.Debug("Failed to read game status", "index", game.index, "error", game.err)
+			continue
+		}
+		if game.status != GameStatusDefenderWins {
+			continue
+		}
+		if reason := p.checkGameId(s, game); reason != "" {
+			log.Info("Skipping dispute game", "index", game.index, "address", game.address().Hex(), "reason", reason)
+			continue
+		}
+		candidates = append(candidates, game)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	reasons, err := p.checkGameState(ctx, s, candidates)
+	if err != nil {
+		return nil, err
+	}
+	eligible := candidates[:0]
+	for i, game := range candidates {
+		if reasons[i] != "" {
+			log.Info("Skipping dispute game", "index", game.index, "address", game.address().Hex(), "reason", reasons[i])
+			continue
+		}
+		eligible = append(eligible, game)
+	}
+	return eligible, nil
+}
+
+// newestGame walks the games with indices in [from, to) from the newest, one batch at a time,
+// and returns the first eligible one, or nil if there is none
+func (p *OPStackCannonProver) newestGame(ctx context.Context, s *gameSearch, from, to uint64) (*disputeGame, error) {
+	for end := to; end > from; {
+		start := from
+		if end-from > disputeGameBatchSize {
+			start = end - disputeGameBatchSize
+		}
+		games, err := p.loadGames(ctx, s, start, end)
+		if err != nil {
+			return nil, err
+		}
+		eligible, err := p.eligibleGames(ctx, s, games)
+		if err != nil {
+			return nil, err
+		}
+		if len(eligible) > 0 {
+			return eligible[0], nil
+		}
+		end = start
+	}
+	return nil, nil
+}
+
+// callElem builds an eth_call batch element
+func callElem(to common.Address, data []byte, blockNumber *big.Int, result *hexutil.Bytes) rpc.BatchElem {
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []interface{}{
+			map[string]interface{}{
+				"to":   to,
+				"data": hexutil.Bytes(data),
+			},
+			toBlockNumArg(blockNumber),
+		},
+		Result: result,
+	}
+}
+
+// disputeGameListSlot returns the storage slot of the factory's _disputeGameList[index]
+func disputeGameListSlot(listSlot common.Hash, index uint64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(
+		new(big.Int).SetBytes(crypto.Keccak256(listSlot.Bytes())),
+		new(big.Int).SetUint64(index),
+	))
+}
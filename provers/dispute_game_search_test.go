@@ -0,0 +1,409 @@
+package provers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
+)
+
+type mockGame struct {
+	gameType      uint32
+	createdAt     uint64
+	resolvedAt    uint64
+	status        GameStatus
+	l2BlockNumber int64
+	// failStatus makes status calls on the game revert
+	failStatus  bool
+	blacklisted bool
+}
+
+// mockDisputeGameFactory serves a DisputeGameFactory and its games over L1 RPC batches at a
+// single L1 block, and counts the round trips and requests it receives
+type mockDisputeGameFactory struct {
+	tb       testing.TB
+	address  common.Address
+	listSlot *big.Int
+	games    []mockGame
+	l1Header *types.Header
+	slots    map[common.Hash]uint64
+	gameABI  abi.ABI
+
+	// portal serves the OptimismPortal2 game rules
+	portal                     common.Address
+	portalABI                  abi.ABI
+	respectedGameType          uint32
+	respectedGameTypeUpdatedAt uint64
+
+	batches  atomic.Int64
+	requests atomic.Int64
+}
+
+func newMockDisputeGameFactory(tb testing.TB, l1Time uint64, games []mockGame) *mockDisputeGameFactory {
+	f := &mockDisputeGameFactory{
+		tb:       tb,
+		address:  common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"),
+		listSlot: big.NewInt(0x123),
+		games:    games,
+		l1Header: &types.Header{
+			Number:     big.NewInt(5000),
+			Time:       l1Time,
+			Difficulty: big.NewInt(0),
+		},
+		slots:  make(map[common.Hash]uint64, len(games)),
+		portal: common.HexToAddress("0xbEb5Fc579115071764c7423A4f12eDde41f106Ed"),
+	}
+	var err error
+	if f.gameABI, err = getFaultDisputeGameABI(); err != nil {
+		tb.Fatal(err)
+	}
+	if f.portalABI, err = getOptimismPortal2ABI(); err != nil {
+		tb.Fatal(err)
+	}
+	for i := range games {
+		f.slots[disputeGameListSlot(common.BigToHash(f.listSlot), uint64(i))] = uint64(i)
+	}
+	return f
+}
+
+func (f *mockDisputeGameFactory) gameAddress(index uint64) common.Address {
+	return common.BigToAddress(new(big.Int).SetUint64(0x10000000 + index))
+}
+
+func (f *mockDisputeGameFactory) config() *types2.L2ConfigInfo {
+	return &types2.L2ConfigInfo{
+		ConfigType:   "OPStackCannon",
+		Addresses:    []common.Address{f.address},
+		StorageSlots: []*big.Int{f.listSlot, big.NewInt(0x456), big.NewInt(0x789)},
+	}
+}
+
+func (f *mockDisputeGameFactory) prover() *OPStackCannonProver {
+	prover, err := NewOPStackCannonProver(nil, &testutil.MockRPCClient{BatchCallContextFunc: f.batch}, nil)
+	if err != nil {
+		f.tb.Fatal(err)
+	}
+	return prover
+}
+
+func (f *mockDisputeGameFactory) batch(ctx context.Context, b []rpc.BatchElem) error {
+	f.batches.Add(1)
+	f.requests.Add(int64(len(b)))
+	for i := range b {
+		result, err := f.serve(b[i].Method, b[i].Args)
+		if err != nil {
+			b[i].Error = err
+			continue
+		}
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, b[i].Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *mockDisputeGameFactory) serve(method string, args []interface{}) (interface{}, error) {
+	blockArg := toBlockNumArg(f.l1Header.Number)
+	word := func(value uint64) hexutil.Bytes {
+		return common.BigToHash(new(big.Int).SetUint64(value)).Bytes()
+	}
+	switch method {
+	case "eth_getBlockByNumber":
+		if args[0] != blockArg {
+			return nil, fmt.Errorf("unexpected block %v", args[0])
+		}
+		return f.l1Header, nil
+	case "eth_getStorageAt":
+		if args[0] != f.address.Hex() || args[2] != blockArg {
+			return nil, fmt.Errorf("unexpected storage read %v", args)
+		}
+		index, ok := f.slots[common.HexToHash(args[1].(string))]
+		if !ok {
+			return common.Hash{}.Hex(), nil
+		}
+		game := f.games[index]
+		return packGameId(game.gameType, game.createdAt, f.gameAddress(index)).Hex(), nil
+	case "eth_call":
+		if args[1] != blockArg {
+			return nil, fmt.Errorf("unexpected block %v", args[1])
+		}
+		msg := args[0].(map[string]interface{})
+		to, data := msg["to"].(common.Address), msg["data"].(hexutil.Bytes)
+		if to == f.address {
+			return word(uint64(len(f.games))), nil
+		}
+		if to == f.portal {
+			return f.servePortal(data)
+		}
+		index := new(big.Int).Sub(to.Big(), big.NewInt(0x10000000)).Uint64()
+		if index >= uint64(len(f.games)) {
+			return nil, fmt.Errorf("unexpected call to %s", to.Hex())
+		}
+		called, err := f.gameABI.MethodById(data[:4])
+		if err != nil {
+			return nil, err
+		}
+		switch called.Name {
+		case "status":
+			if f.games[index].failStatus {
+				return nil, fmt.Errorf("execution reverted")
+			}
+			return word(uint64(f.games[index].status)), nil
+		case "l2BlockNumber":
+			return word(uint64(f.games[index].l2BlockNumber)), nil
+		case "resolvedAt":
+			return word(f.games[index].resolvedAt), nil
+		}
+		return nil, fmt.Errorf("unexpected game call %s", called.Name)
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+func (f *mockDisputeGameFactory) servePortal(data hexutil.Bytes) (interface{}, error) {
+	called, err := f.portalABI.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	var result []byte
+	switch called.Name {
+	case "respectedGameType":
+		result, err = called.Outputs.Pack(f.respectedGameType)
+	case "respectedGameTypeUpdatedAt":
+		result, err = called.Outputs.Pack(f.respectedGameTypeUpdatedAt)
+	case "disputeGameBlacklist":
+		args, err := called.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		index := new(big.Int).Sub(args[0].(common.Address).Big(), big.NewInt(0x10000000)).Uint64()
+		if index >= uint64(len(f.games)) {
+			return nil, fmt.Errorf("unexpected blacklist lookup of %s", args[0])
+		}
+		result, err = called.Outputs.Pack(f.games[index].blacklisted)
+	default:
+		return nil, fmt.Errorf("unexpected portal call %s", called.Name)
+	}
+	return hexutil.Bytes(result), err
+}
+
+// mainnetLikeGames returns count games proposed every interval up to l1Time. Games younger than
+// the 3.5 day chess clocks are still in progress and every third older game was never resolved.
+func mainnetLikeGames(count int, interval time.Duration, l1Time uint64) []mockGame {
+	games := make([]mockGame, count)
+	clocks := uint64(302400)
+	for i := range games {
+		createdAt := l1Time - uint64(count-i)*uint64(interval/time.Second)
+		games[i] = mockGame{createdAt: createdAt, status: GameStatusDefenderWins, l2BlockNumber: int64(1000 * (i + 1))}
+		if createdAt > l1Time-clocks || i%3 == 0 {
+			games[i].status = GameStatusInProgress
+		}
+	}
+	return games
+}
+
+func TestOPStackCannonProver_FindLatestResolved_Newest(t *testing.T) {
+	const l1Time = 1700000000
+	day := uint64(24 * 60 * 60)
+	games := []mockGame{
+		{createdAt: l1Time - 10*day, status: GameStatusDefenderWins},
+		{createdAt: l1Time - 9*day, status: GameStatusDefenderWins},
+		{createdAt: l1Time - 8*day, status: GameStatusChallengerWins},
+		{createdAt: l1Time - 8*day, failStatus: true},
+		{createdAt: l1Time - 5*day, status: GameStatusDefenderWins},
+		{createdAt: l1Time - day, status: GameStatusInProgress},
+	}
+	f := newMockDisputeGameFactory(t, l1Time, games)
+
+	// The newest resolved game is used
+	index, addr, err := f.prover().FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), index)
+	assert.Equal(t, f.gameAddress(4), addr)
+
+	// Older games are used if no newer game resolved
+	f.games[4].status = GameStatusChallengerWins
+	index, addr, err = f.prover().FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), index)
+	assert.Equal(t, f.gameAddress(1), addr)
+
+	f.games[0].status = GameStatusInProgress
+	f.games[1].status = GameStatusChallengerWins
+	_, _, err = f.prover().FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.ErrorContains(t, err, "no resolved dispute games found at L1 block 5000")
+}
+
+func TestOPStackCannonProver_FindLatestResolved_Batched(t *testing.T) {
+	const l1Time = 1700000000
+	games := mainnetLikeGames(5000, 10*time.Minute, l1Time)
+	f := newMockDisputeGameFactory(t, l1Time, games)
+	prover := f.prover()
+
+	// The newest resolved game
+	expected := len(games) - 1
+	for games[expected].status != GameStatusDefenderWins {
+		expected--
+	}
+	index, _, err := prover.FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(int64(expected)), index)
+	// The head and a few pages of games, most of them still in progress
+	assert.LessOrEqual(t, f.batches.Load(), int64(16))
+	first := f.batches.Load()
+
+	// A second search only re-reads the head and the games that were still in progress
+	f.batches.Store(0)
+	f.requests.Store(0)
+	index, _, err = prover.FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(int64(expected)), index)
+	assert.Less(t, f.batches.Load(), first)
+	assert.Less(t, f.requests.Load(), int64(len(games)/5))
+}
+
+func TestDisputeGameCache_Status(t *testing.T) {
+	cache := newDisputeGameCache()
+	game := common.HexToAddress("0x1")
+
+	cache.setStatus(game, GameStatusInProgress, 100)
+	_, ok := cache.status(game, 100)
+	assert.False(t, ok, "in progress games are not memoized")
+
+	cache.setStatus(game, GameStatusDefenderWins, 100)
+	status, ok := cache.status(game, 101)
+	require.True(t, ok)
+	assert.Equal(t, GameStatusDefenderWins, status)
+
+	// The game had not resolved yet as far as we know at an older L1 block
+	_, ok = cache.status(game, 99)
+	assert.False(t, ok)
+
+	cache.setStatus(game, GameStatusDefenderWins, 90)
+	_, ok = cache.status(game, 95)
+	assert.True(t, ok)
+}
+
+func BenchmarkOPStackCannonProver_FindLatestResolved(b *testing.B) {
+	const l1Time = 1700000000
+	for _, count := range []int{2000, 10000} {
+		games := mainnetLikeGames(count, 10*time.Minute, l1Time)
+
+		b.Run(fmt.Sprintf("Cold/%d", count), func(b *testing.B) {
+			f := newMockDisputeGameFactory(b, l1Time, games)
+			for i := 0; i < b.N; i++ {
+				if _, _, err := f.prover().FindLatestResolved(context.Background(), f.config(), f.l1Header.Number); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(f.batches.Load())/float64(b.N), "batches/op")
+			b.ReportMetric(float64(f.requests.Load())/float64(b.N), "requests/op")
+		})
+
+		b.Run(fmt.Sprintf("Memoized/%d", count), func(b *testing.B) {
+			f := newMockDisputeGameFactory(b, l1Time, games)
+			prover := f.prover()
+			if _, _, err := prover.FindLatestResolved(context.Background(), f.config(), f.l1Header.Number); err != nil {
+				b.Fatal(err)
+			}
+			f.batches.Store(0)
+			f.requests.Store(0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := prover.FindLatestResolved(context.Background(), f.config(), f.l1Header.Number); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(f.batches.Load())/float64(b.N), "batches/op")
+			b.ReportMetric(float64(f.requests.Load())/float64(b.N), "requests/op")
+		})
+	}
+}
+
+func BenchmarkOPStackCannonProver_FindResolvedAtOrAfter(b *testing.B) {
+	const l1Time = 1700000000
+	games := mainnetLikeGames(10000, 10*time.Minute, l1Time)
+	f := newMockDisputeGameFactory(b, l1Time, games)
+	// A target a few hundred games behind the newest resolved one
+	target := big.NewInt(games[len(games)-1500].l2BlockNumber)
+
+	for i := 0; i < b.N; i++ {
+		if _, _, err := f.prover().FindResolvedAtOrAfter(context.Background(), f.config(), target, f.l1Header.Number); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(f.batches.Load())/float64(b.N), "batches/op")
+	b.ReportMetric(float64(f.requests.Load())/float64(b.N), "requests/op")
+}
+
+func TestOPStackCannonProver_GameRules(t *testing.T) {
+	const l1Time = 1700000000
+	day := uint64(24 * 60 * 60)
+	games := []mockGame{
+		{gameType: 1, createdAt: l1Time - 12*day, resolvedAt: l1Time - 8*day, l2BlockNumber: 100},
+		{gameType: 1, createdAt: l1Time - 11*day, resolvedAt: l1Time - 7*day, l2BlockNumber: 200},
+		{gameType: 1, createdAt: l1Time - 10*day, resolvedAt: l1Time - 6*day, l2BlockNumber: 300},
+		{gameType: 0, createdAt: l1Time - 9*day, resolvedAt: l1Time - 5*day, l2BlockNumber: 400},
+		{gameType: 1, createdAt: l1Time - 8*day, resolvedAt: l1Time - 4*day, l2BlockNumber: 500, blacklisted: true},
+		{gameType: 1, createdAt: l1Time - 7*day, resolvedAt: l1Time - day, l2BlockNumber: 600},
+	}
+	for i := range games {
+		games[i].status = GameStatusDefenderWins
+	}
+	f := newMockDisputeGameFactory(t, l1Time, games)
+	f.respectedGameType = 1
+	f.respectedGameTypeUpdatedAt = l1Time - 11*day
+
+	rules := CannonGameRules{OptimismPortal: f.portal, FinalityDelaySeconds: 3 * day}
+	newProver := func(rules CannonGameRules) *OPStackCannonProver {
+		prover := f.prover()
+		prover.SetGameRules(rules)
+		return prover
+	}
+
+	// Without rules the newest resolved game is used
+	index, _, err := newProver(CannonGameRules{}).FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), index)
+
+	// Game 5 is inside the finality delay, game 4 is blacklisted and game 3 is of another type
+	index, addr, err := newProver(rules).FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2), index)
+	assert.Equal(t, f.gameAddress(2), addr)
+
+	// Game 0 was created before the respected game type was last updated
+	index, _, err = newProver(rules).FindResolvedAtOrAfter(context.Background(), f.config(), big.NewInt(50), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), index)
+
+	index, _, err = newProver(rules).FindResolvedAtOrAfter(context.Background(), f.config(), big.NewInt(350), f.l1Header.Number)
+	require.Error(t, err)
+	assert.Nil(t, index)
+
+	// Without a portal only the finality delay applies
+	index, _, err = newProver(CannonGameRules{FinalityDelaySeconds: 3 * day}).FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), index)
+
+	f.respectedGameTypeUpdatedAt = l1Time
+	_, _, err = newProver(rules).FindLatestResolved(context.Background(), f.config(), f.l1Header.Number)
+	require.ErrorContains(t, err, "no resolved dispute games")
+}
@@ -18,6 +18,18 @@ type IEthClient interface {
 	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
 	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// ITxClient is the subset of an Ethereum client needed to sign, send and await transactions
+type ITxClient interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 }
 
 type IRPCClient interface {
@@ -43,6 +55,12 @@ type IStorageProver interface {
 		slot common.Hash,
 		blockNumber *big.Int,
 	) (*t.StorageProofResult, error)
+	GetStorageProofs(
+		ctx context.Context,
+		address common.Address,
+		slots []common.Hash,
+		blockNumber *big.Int,
+	) (*t.StorageProofResult, error)
 	GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (string, error)
 }
 
@@ -71,12 +89,25 @@ type INativeProver interface {
 }
 
 type ISettledStateProver interface {
+	// FindLatestResolved returns the latest settled index as seen at L1 block l1BlockNumber,
+	// the block the settled state proof is generated against
 	FindLatestResolved(
 		ctx context.Context,
-		config *t.L2ConfigInfo) (*big.Int, common.Address, error)
+		config *t.L2ConfigInfo,
+		l1BlockNumber *big.Int,
+	) (*big.Int, common.Address, error)
+	// FindResolvedAtOrAfter returns the earliest settled index covering l2BlockNumber that is
+	// provable against L1 block l1BlockNumber
+	FindResolvedAtOrAfter(
+		ctx context.Context,
+		config *t.L2ConfigInfo,
+		l2BlockNumber *big.Int,
+		l1BlockNumber *big.Int,
+	) (*big.Int, common.Address, error)
 	GenerateSettledStateProof(
 		ctx context.Context,
-		l1BlockNumber, outputIndex *big.Int,
+		l1Header *types.Header,
+		outputIndex *big.Int,
 		rootAddress common.Address,
 		config *t.L2ConfigInfo,
 	) ([]byte, *types.Header, error)
@@ -85,7 +116,26 @@ type ISettledStateProver interface {
 type IRegistryProver interface {
 	GetL2Configuration(ctx context.Context, chainID uint64) (*t.L2ConfigInfo, error)
 	GetL1BlockHashOracle(ctx context.Context, chainID uint64) (common.Address, error)
-	GetL2ConfigurationForUpdate(ctx context.Context, chainID uint64) (*t.L2Configuration, error)
-	GetRegistryStorageProof(ctx context.Context, chainID uint64) ([][]byte, []byte, [][]byte, error)
-	GenerateUpdateL2ConfigArgs(ctx context.Context, chainID uint64) (*t.UpdateL2ConfigArgs, error)
+	GetL2ConfigurationForUpdate(ctx context.Context, chainID uint64, blockNumber *big.Int) (*t.L2Configuration, error)
+	GetRegistryStorageProof(
+		ctx context.Context,
+		chainID uint64,
+		l1Header *types.Header,
+	) ([][]byte, []byte, [][]byte, error)
+	GenerateUpdateL2ConfigArgs(
+		ctx context.Context,
+		chainID uint64,
+		l1Header *types.Header,
+	) (*t.UpdateL2ConfigArgs, error)
+	GetL1ConfigurationForUpdate(ctx context.Context, chainID uint64, blockNumber *big.Int) (*t.L1Configuration, error)
+	GetRegistryL1ConfigStorageProof(
+		ctx context.Context,
+		chainID uint64,
+		l1Header *types.Header,
+	) ([][]byte, []byte, [][]byte, error)
+	GenerateUpdateL1ConfigArgs(
+		ctx context.Context,
+		chainID uint64,
+		l1Header *types.Header,
+	) (*t.UpdateL1ConfigArgs, error)
 }
@@ -3,11 +3,6 @@ package provers
 import (
 	"context"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -39,30 +34,7 @@ func NewL1OriginProver(l1Client IEthClient, l2Client IEthClient) *L1OriginProver
 
 // getIL1BlockABI loads and parses the IL1Block ABI from file
 func getIL1BlockABI() (abi.ABI, error) {
-	// Get the absolute path of the current file
-	_, thisFile, _, _ := runtime.Caller(0)
-	// Construct the path to the ABI file
-	abiPath := filepath.Join(filepath.Dir(thisFile), "abis", "IL1Block.abi.json")
-
-	// Read the ABI file
-	abiFile, err := os.Open(abiPath)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to open IL1Block ABI file: %w", err)
-	}
-	defer abiFile.Close()
-
-	abiBytes, err := io.ReadAll(abiFile)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to read IL1Block ABI file: %w", err)
-	}
-
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(string(abiBytes)))
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to parse IL1Block ABI: %w", err)
-	}
-
-	return parsedABI, nil
+	return getBundledABI("IL1Block")
 }
 
 func (l *L1OriginProver) GetL1OriginHash(ctx context.Context, l1OracleAddress common.Address) (common.Hash, error) {
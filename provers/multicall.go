@@ -0,0 +1,84 @@
+package provers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most chains
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// Call3 is a single call of a Multicall3 aggregate3 bundle
+type Call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Multicall3 encodes calls to the Multicall3 contract
+type Multicall3 struct {
+	abi abi.ABI
+}
+
+// NewMulticall3 creates a new Multicall3 encoder
+func NewMulticall3() (*Multicall3, error) {
+	multicallABI, err := getMulticall3ABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Multicall3 ABI: %w", err)
+	}
+	return &Multicall3{abi: multicallABI}, nil
+}
+
+// getMulticall3ABI returns the ABI for the aggregate3 function of the Multicall3 contract
+func getMulticall3ABI() (abi.ABI, error) {
+	return abi.JSON(strings.NewReader(`[
+		{
+			"inputs": [
+				{
+					"components": [
+						{"internalType": "address", "name": "target", "type": "address"},
+						{"internalType": "bool", "name": "allowFailure", "type": "bool"},
+						{"internalType": "bytes", "name": "callData", "type": "bytes"}
+					],
+					"internalType": "struct Multicall3.Call3[]",
+					"name": "calls",
+					"type": "tuple[]"
+				}
+			],
+			"name": "aggregate3",
+			"outputs": [
+				{
+					"components": [
+						{"internalType": "bool", "name": "success", "type": "bool"},
+						{"internalType": "bytes", "name": "returnData", "type": "bytes"}
+					],
+					"internalType": "struct Multicall3.Result[]",
+					"name": "returnData",
+					"type": "tuple[]"
+				}
+			],
+			"stateMutability": "payable",
+			"type": "function"
+		}
+	]`))
+}
+
+// EncodeAggregate3 encodes the calls into calldata for the Multicall3.aggregate3() function
+func (m *Multicall3) EncodeAggregate3(calls []Call3) ([]byte, error) {
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("no calls to aggregate")
+	}
+	calldata, err := m.abi.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack aggregate3 calldata: %w", err)
+	}
+	return calldata, nil
+}
+
+// GetABI returns the Multicall3 ABI
+func (m *Multicall3) GetABI() abi.ABI {
+	return m.abi
+}
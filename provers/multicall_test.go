@@ -0,0 +1,42 @@
+package provers
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMulticall3_EncodeAggregate3(t *testing.T) {
+	multicall, err := NewMulticall3()
+	require.NoError(t, err)
+
+	target := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	calls := []Call3{
+		{Target: target, AllowFailure: false, CallData: []byte{0x01, 0x02}},
+		{Target: target, AllowFailure: true, CallData: []byte{0x03}},
+	}
+	calldata, err := multicall.EncodeAggregate3(calls)
+	require.NoError(t, err)
+
+	// aggregate3((address,bool,bytes)[])
+	assert.Equal(t, common.FromHex("0x82ad56cb"), calldata[:4])
+
+	args, err := multicall.GetABI().Methods["aggregate3"].Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	decoded := args[0].([]struct {
+		Target       common.Address `json:"target"`
+		AllowFailure bool           `json:"allowFailure"`
+		CallData     []byte         `json:"callData"`
+	})
+	require.Len(t, decoded, 2)
+	for i, call := range calls {
+		assert.Equal(t, call.Target, decoded[i].Target)
+		assert.Equal(t, call.AllowFailure, decoded[i].AllowFailure)
+		assert.Equal(t, call.CallData, decoded[i].CallData)
+	}
+
+	_, err = multicall.EncodeAggregate3(nil)
+	require.ErrorContains(t, err, "no calls")
+}
@@ -1,14 +1,13 @@
 package provers
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	t "github.com/polymerdao/fallback_prover/types"
 )
 
@@ -16,7 +15,8 @@ var _ INativeProver = &NativeProver{}
 
 // NativeProver is responsible for encoding the calldata for the prove function
 type NativeProver struct {
-	abi abi.ABI
+	abi           abi.ABI
+	revertDecoder *RevertDecoder
 }
 
 // NewNativeProver creates a new NativeProver
@@ -26,37 +26,20 @@ func NewNativeProver() (*NativeProver, error) {
 		return nil, fmt.Errorf("failed to get NativeProver ABI: %w", err)
 	}
 
+	revertDecoder, err := NewRevertDecoder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create revert decoder: %w", err)
+	}
+
 	return &NativeProver{
-		abi: nativeProverABI,
+		abi:           nativeProverABI,
+		revertDecoder: revertDecoder,
 	}, nil
 }
 
 // getNativeProverABI loads and parses the NativeProver ABI from file
 func getNativeProverABI() (abi.ABI, error) {
-	// Get the absolute path of the current file
-	_, thisFile, _, _ := runtime.Caller(0)
-	// Construct the path to the ABI file
-	abiPath := filepath.Join(filepath.Dir(thisFile), "abis", "NativeProver.abi.json")
-
-	// Read the ABI file
-	abiFile, err := os.Open(abiPath)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to open NativeProver ABI file: %w", err)
-	}
-	defer abiFile.Close()
-
-	abiBytes, err := io.ReadAll(abiFile)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to read NativeProver ABI file: %w", err)
-	}
-
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(string(abiBytes)))
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to parse NativeProver ABI: %w", err)
-	}
-
-	return parsedABI, nil
+	return getBundledABI("NativeProver")
 }
 
 // EncodeProveNativeCalldata encodes the parameters for the NativeProver.proveNative() function call
@@ -101,6 +84,111 @@ func (np *NativeProver) EncodeProveL1NativeCalldata(
 	)
 }
 
+// GetL1Configuration reads the L1_CONFIGURATION() of the NativeProver at nativeProverAddress
+func (np *NativeProver) GetL1Configuration(
+	ctx context.Context,
+	client IEthClient,
+	nativeProverAddress common.Address,
+) (*t.L1Configuration, error) {
+	data, err := np.abi.Pack("L1_CONFIGURATION")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack L1_CONFIGURATION: %w", err)
+	}
+	output, err := client.CallContract(ctx, ethereum.CallMsg{
+		To:   &nativeProverAddress,
+		Data: data,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call L1_CONFIGURATION: %w", err)
+	}
+
+	var config t.L1Configuration
+	if err := np.abi.UnpackIntoInterface(&config, "L1_CONFIGURATION", output); err != nil {
+		return nil, fmt.Errorf("failed to unpack L1_CONFIGURATION: %w", err)
+	}
+	return &config, nil
+}
+
+// proveNativeCallABI mirrors t.ProveNativeCall with the L2 type as the uint8 the ABI decodes
+type proveNativeCallABI struct {
+	UpdateArgs struct {
+		Config struct {
+			Prover               common.Address
+			Addresses            []common.Address
+			StorageSlots         []*big.Int
+			VersionNumber        *big.Int
+			FinalityDelaySeconds *big.Int
+			L2Type               uint8
+		}
+		L1StorageProof                [][]byte
+		RlpEncodedRegistryAccountData []byte
+		L1RegistryProof               [][]byte
+	}
+	ProveArgs                 t.ProveScalarArgs
+	RlpEncodedL1Header        []byte
+	RlpEncodedL2Header        []byte
+	SettledStateProof         []byte
+	L2StorageProof            [][]byte
+	RlpEncodedContractAccount []byte
+	L2AccountProof            [][]byte
+}
+
+// DecodeProveCalldata decodes the arguments of proveNative or proveL1Native calldata. It returns
+// the method name and a *t.ProveNativeCall or *t.ProveL1NativeCall.
+func (np *NativeProver) DecodeProveCalldata(calldata []byte) (string, interface{}, error) {
+	if len(calldata) < 4 {
+		return "", nil, fmt.Errorf("calldata too short: %d bytes", len(calldata))
+	}
+	method, err := np.abi.MethodById(calldata[:4])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find NativeProver method: %w", err)
+	}
+	if method.Name != "proveNative" && method.Name != "proveL1Native" {
+		return "", nil, fmt.Errorf("calldata is for %s, not a prove function", method.Name)
+	}
+
+	values, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to unpack %s calldata: %w", method.Name, err)
+	}
+
+	if method.Name == "proveL1Native" {
+		var call t.ProveL1NativeCall
+		if err := method.Inputs.Copy(&call, values); err != nil {
+			return "", nil, fmt.Errorf("failed to decode %s calldata: %w", method.Name, err)
+		}
+		return method.Name, &call, nil
+	}
+
+	var decoded proveNativeCallABI
+	if err := method.Inputs.Copy(&decoded, values); err != nil {
+		return "", nil, fmt.Errorf("failed to decode %s calldata: %w", method.Name, err)
+	}
+	config := decoded.UpdateArgs.Config
+	return method.Name, &t.ProveNativeCall{
+		UpdateArgs: t.UpdateL2ConfigArgs{
+			Config: t.L2Configuration{
+				Prover:               config.Prover,
+				Addresses:            config.Addresses,
+				StorageSlots:         config.StorageSlots,
+				VersionNumber:        config.VersionNumber,
+				FinalityDelaySeconds: config.FinalityDelaySeconds,
+				L2Type:               t.L2Type(config.L2Type),
+			},
+			L1StorageProof:                decoded.UpdateArgs.L1StorageProof,
+			RlpEncodedRegistryAccountData: decoded.UpdateArgs.RlpEncodedRegistryAccountData,
+			L1RegistryProof:               decoded.UpdateArgs.L1RegistryProof,
+		},
+		ProveArgs:                 decoded.ProveArgs,
+		RlpEncodedL1Header:        decoded.RlpEncodedL1Header,
+		RlpEncodedL2Header:        decoded.RlpEncodedL2Header,
+		SettledStateProof:         decoded.SettledStateProof,
+		L2StorageProof:            decoded.L2StorageProof,
+		RlpEncodedContractAccount: decoded.RlpEncodedContractAccount,
+		L2AccountProof:            decoded.L2AccountProof,
+	}, nil
+}
+
 // GetABI returns the ABI for the NativeProver
 // This is mainly used for testing purposes
 func (np *NativeProver) GetABI() abi.ABI {
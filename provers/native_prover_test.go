@@ -1,14 +1,17 @@
 package provers
 
 import (
+	"context"
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/polymerdao/fallback_prover/testutil"
 	"github.com/polymerdao/fallback_prover/types"
 )
 
@@ -211,4 +214,71 @@ func TestNativeProver_EncodeProveNativeCalldata(t *testing.T) {
 	assert.Equal(t, rlpEncodedL1Header, unpackedMap["_rlpEncodedL1Header"].([]byte), "RlpEncodedL1Header should match")
 	assert.Equal(t, rlpEncodedL2Header, unpackedMap["_rlpEncodedL2Header"].([]byte), "RlpEncodedL2Header should match")
 	assert.Equal(t, settledStateProof, unpackedMap["_settledStateProof"].([]byte), "SettledStateProof should match")
+
+	// DecodeProveCalldata returns the arguments as they were encoded
+	method, call, err := prover.DecodeProveCalldata(calldata)
+	require.NoError(t, err)
+	assert.Equal(t, "proveNative", method)
+	assert.Equal(t, &types.ProveNativeCall{
+		UpdateArgs:                updateArgs,
+		ProveArgs:                 proveArgs,
+		RlpEncodedL1Header:        rlpEncodedL1Header,
+		RlpEncodedL2Header:        rlpEncodedL2Header,
+		SettledStateProof:         settledStateProof,
+		L2StorageProof:            l2StorageProof,
+		RlpEncodedContractAccount: rlpEncodedContractAccount,
+		L2AccountProof:            l2AccountProof,
+	}, call)
+
+	l1Args := types.ProveL1ScalarArgs{
+		ContractAddr:     contractAddr,
+		StorageSlot:      storageSlot,
+		StorageValue:     storageValue,
+		L1WorldStateRoot: l2WorldStateRoot,
+	}
+	calldata, err = prover.EncodeProveL1NativeCalldata(l1Args, rlpEncodedL1Header, l2StorageProof, rlpEncodedContractAccount, l2AccountProof)
+	require.NoError(t, err)
+	method, call, err = prover.DecodeProveCalldata(calldata)
+	require.NoError(t, err)
+	assert.Equal(t, "proveL1Native", method)
+	assert.Equal(t, &types.ProveL1NativeCall{
+		Args:                      l1Args,
+		RlpEncodedL1Header:        rlpEncodedL1Header,
+		L1StorageProof:            l2StorageProof,
+		RlpEncodedContractAccount: rlpEncodedContractAccount,
+		L1AccountProof:            l2AccountProof,
+	}, call)
+
+	_, _, err = prover.DecodeProveCalldata(calldata[:3])
+	assert.ErrorContains(t, err, "calldata too short")
+}
+
+func TestNativeProver_GetL1Configuration(t *testing.T) {
+	prover, err := NewNativeProver()
+	require.NoError(t, err)
+	nativeProverAddr := common.HexToAddress("0x5678")
+	expected := types.L1Configuration{
+		BlockHashOracle:                       common.HexToAddress("0x4200000000000000000000000000000000000015"),
+		SettlementBlocksDelay:                 big.NewInt(25),
+		SettlementRegistry:                    common.HexToAddress("0x1234"),
+		SettlementRegistryL2ConfigMappingSlot: big.NewInt(2),
+		SettlementRegistryL1ConfigMappingSlot: big.NewInt(3),
+	}
+
+	client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, nativeProverAddr, *msg.To)
+			require.Equal(t, prover.abi.Methods["L1_CONFIGURATION"].ID, msg.Data)
+			return prover.abi.Methods["L1_CONFIGURATION"].Outputs.Pack(
+				expected.BlockHashOracle,
+				expected.SettlementBlocksDelay,
+				expected.SettlementRegistry,
+				expected.SettlementRegistryL2ConfigMappingSlot,
+				expected.SettlementRegistryL1ConfigMappingSlot,
+			)
+		},
+	}
+	config, err := prover.GetL1Configuration(context.Background(), client, nativeProverAddr)
+	require.NoError(t, err)
+	assert.Equal(t, &expected, config)
 }
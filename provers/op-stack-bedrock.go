@@ -5,15 +5,12 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math/big"
-	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/rpc"
 
+	"github.com/polymerdao/fallback_prover/provers/verify"
 	"github.com/polymerdao/fallback_prover/types"
 
 	"github.com/ethereum/go-ethereum"
@@ -21,6 +18,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	types2 "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -33,6 +31,44 @@ type OPStackBedrockProver struct {
 	l2RPC             IRPCClient
 	abi               abi.ABI
 	l2OutputOracleABI abi.ABI
+	// finalityDelaySeconds is how long an output must have been proposed at the L1 block before
+	// it can be proven against, from the chain's L2Configuration
+	finalityDelaySeconds uint64
+}
+
+// OutputNotFinalError is returned when no output that would be selected is final at the L1 block yet
+type OutputNotFinalError struct {
+	L1BlockNumber *big.Int
+	// OutputIndex is the oldest output that is not final yet and FinalAt the L1 timestamp it
+	// becomes final at
+	OutputIndex *big.Int
+	FinalAt     uint64
+}
+
+func (e *OutputNotFinalError) Error() string {
+	return fmt.Sprintf(
+		"no final output at L1 block %s: output %s becomes final at timestamp %d",
+		e.L1BlockNumber,
+		e.OutputIndex,
+		e.FinalAt,
+	)
+}
+
+// OutputSlotMismatchError is returned when the proven L2OutputOracle slot of an output disagrees
+// with the output root getL2Output returns for it
+type OutputSlotMismatchError struct {
+	Index  *big.Int
+	Proven common.Hash
+	Called common.Hash
+}
+
+func (e *OutputSlotMismatchError) Error() string {
+	return fmt.Sprintf(
+		"output %s proven output root %s does not match called output root %s",
+		e.Index,
+		e.Proven.Hex(),
+		e.Called.Hex(),
+	)
 }
 
 // NewOPStackBedrockProver creates a new prover instance for OP Stack Bedrock
@@ -58,30 +94,7 @@ func NewOPStackBedrockProver(l1Client IEthClient, l1RPC, l2RPC IRPCClient) (*OPS
 
 // getOPStackBedrockProverABI loads and parses the OPStackBedrockProver ABI from file
 func getOPStackBedrockProverABI() (abi.ABI, error) {
-	// Get the absolute path of the current file
-	_, thisFile, _, _ := runtime.Caller(0)
-	// Construct the path to the ABI file
-	abiPath := filepath.Join(filepath.Dir(thisFile), "abis", "OPStackBedrockProver.abi.json")
-
-	// Read the ABI file
-	abiFile, err := os.Open(abiPath)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to open OPStackBedrockProver ABI file: %w", err)
-	}
-	defer abiFile.Close()
-
-	abiBytes, err := io.ReadAll(abiFile)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to read OPStackBedrockProver ABI file: %w", err)
-	}
-
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(string(abiBytes)))
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to parse OPStackBedrockProver ABI: %w", err)
-	}
-
-	return parsedABI, nil
+	return getBundledABI("OPStackBedrockProver")
 }
 
 // getL2OutputOracleABI loads and returns the ABI for the L2OutputOracle contract
@@ -158,15 +171,23 @@ func getL2OutputOracleABI() (abi.ABI, error) {
 	]`))
 }
 
+// SetFinalityDelay sets how long an output must have been proposed before it is selected
+func (p *OPStackBedrockProver) SetFinalityDelay(seconds uint64) {
+	p.finalityDelaySeconds = seconds
+}
+
 var (
 	L2MessagePasserAddress = common.HexToAddress(
 		"0x4200000000000000000000000000000000000016",
 	) // Standard address on OP Stack
 )
 
+// FindLatestResolved returns the newest output that is final at l1BlockNumber. When the latest
+// output is still inside the finality delay it walks back to the newest one that is not.
 func (p *OPStackBedrockProver) FindLatestResolved(
 	ctx context.Context,
 	config *types.L2ConfigInfo,
+	l1BlockNumber *big.Int,
 ) (*big.Int, common.Address, error) {
 	if len(config.Addresses) == 0 || len(config.StorageSlots) == 0 {
 		return nil, common.Address{}, fmt.Errorf("invalid config: addresses or slots are empty")
@@ -174,7 +195,7 @@ func (p *OPStackBedrockProver) FindLatestResolved(
 
 	latestOutputIndexData, err := p.l2OutputOracleABI.Pack("latestOutputIndex")
 	if err != nil {
-		return nil, common.Address{}, fmt.Errorf("failed to pack getL2OutputIndexAfter: %w", err)
+		return nil, common.Address{}, fmt.Errorf("failed to pack latestOutputIndex: %w", err)
 	}
 
 	l2OutputOracleAddr := config.Addresses[0]
@@ -182,25 +203,198 @@ func (p *OPStackBedrockProver) FindLatestResolved(
 	latestOutputIndexResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
 		To:   &l2OutputOracleAddr,
 		Data: latestOutputIndexData,
-	}, nil)
+	}, l1BlockNumber)
 	if err != nil {
-		return nil, common.Address{}, fmt.Errorf("failed to call getL2OutputIndexAfter: %w", err)
+		return nil, common.Address{}, fmt.Errorf("failed to call latestOutputIndex at L1 block %s: %w", l1BlockNumber, err)
 	}
 
 	latestOutputIndex := new(big.Int).SetBytes(latestOutputIndexResult)
 	if latestOutputIndex.Cmp(big.NewInt(0)) < 0 {
 		return nil, common.Address{}, fmt.Errorf("invalid latestOutputIndex: %s", latestOutputIndex.String())
 	}
+	if p.finalityDelaySeconds == 0 {
+		return latestOutputIndex, l2OutputOracleAddr, nil
+	}
+
+	l1Timestamp, err := p.l1Timestamp(ctx, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	latest, err := p.getL2Output(ctx, l2OutputOracleAddr, latestOutputIndex, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if p.isFinal(latest, l1Timestamp) {
+		return latestOutputIndex, l2OutputOracleAddr, nil
+	}
+
+	// Outputs are proposed in order, so their timestamps never decrease and the final outputs
+	// are a prefix of the oracle. Find the first output that is not final in [0, latest].
+	lo, hi := uint64(0), latestOutputIndex.Uint64()
+	notFinal := latest
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		output, err := p.getL2Output(ctx, l2OutputOracleAddr, new(big.Int).SetUint64(mid), l1BlockNumber)
+		if err != nil {
+			return nil, common.Address{}, err
+		}
+		if p.isFinal(output, l1Timestamp) {
+			lo = mid + 1
+		} else {
+			hi = mid
+			notFinal = output
+		}
+	}
+	if lo == 0 {
+		return nil, common.Address{}, &OutputNotFinalError{
+			L1BlockNumber: l1BlockNumber,
+			OutputIndex:   big.NewInt(0),
+			FinalAt:       p.finalAt(notFinal),
+		}
+	}
+
+	outputIndex := new(big.Int).SetUint64(lo - 1)
+	log.Info(
+		"Latest output is inside the finality delay, using an older output",
+		"latest", latestOutputIndex,
+		"index", outputIndex,
+		"nextIndex", lo,
+		"nextFinalAt", p.finalAt(notFinal),
+	)
+	return outputIndex, l2OutputOracleAddr, nil
+}
+
+// l2OutputProposal is an output proposal as stored by the L2OutputOracle
+type l2OutputProposal struct {
+	OutputRoot    common.Hash
+	Timestamp     *big.Int
+	L2BlockNumber *big.Int
+}
+
+// getL2Output reads the output proposal at outputIndex from the L2OutputOracle at l1BlockNumber
+func (p *OPStackBedrockProver) getL2Output(
+	ctx context.Context,
+	l2OutputOracleAddr common.Address,
+	outputIndex *big.Int,
+	l1BlockNumber *big.Int,
+) (*l2OutputProposal, error) {
+	l2OutputData, err := p.l2OutputOracleABI.Pack("getL2Output", outputIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getL2Output: %w", err)
+	}
 
-	return latestOutputIndex, l2OutputOracleAddr, nil
+	l2OutputResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &l2OutputOracleAddr,
+		Data: l2OutputData,
+	}, l1BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getL2Output: %w", err)
+	}
+
+	// OutputProposal struct has 3 fields: outputRoot, timestamp, l2BlockNumber
+	var outputProposal l2OutputProposal
+	if len(l2OutputResult) >= 96 { // 32 bytes for outputRoot, 32 bytes for timestamp, 32 bytes for l2BlockNumber
+		copy(outputProposal.OutputRoot[:], l2OutputResult[:32])
+		outputProposal.Timestamp = new(big.Int).SetBytes(l2OutputResult[32:64])
+		outputProposal.L2BlockNumber = new(big.Int).SetBytes(l2OutputResult[64:96])
+	} else {
+		// Only try the ABI unpacking as a fallback
+		if err := p.l2OutputOracleABI.UnpackIntoInterface(&outputProposal, "getL2Output", l2OutputResult); err != nil {
+			return nil, fmt.Errorf("failed to unpack output proposal: %w", err)
+		}
+	}
+	return &outputProposal, nil
+}
+
+// l1Timestamp returns the timestamp of the given L1 block
+func (p *OPStackBedrockProver) l1Timestamp(ctx context.Context, l1BlockNumber *big.Int) (uint64, error) {
+	block, err := p.l1Client.BlockByNumber(ctx, l1BlockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get L1 block %s: %w", l1BlockNumber, err)
+	}
+	return block.Time(), nil
+}
+
+// finalAt returns the L1 timestamp an output becomes final at
+func (p *OPStackBedrockProver) finalAt(output *l2OutputProposal) uint64 {
+	return output.Timestamp.Uint64() + p.finalityDelaySeconds
+}
+
+// isFinal reports whether the output is past the finality delay at l1Timestamp
+func (p *OPStackBedrockProver) isFinal(output *l2OutputProposal, l1Timestamp uint64) bool {
+	return p.finalAt(output) <= l1Timestamp
+}
+
+// FindResolvedAtOrAfter returns the index of the first output proposed for l2BlockNumber or a
+// later block, as recorded by the L2OutputOracle at l1BlockNumber. The output must be final at
+// l1BlockNumber.
+func (p *OPStackBedrockProver) FindResolvedAtOrAfter(
+	ctx context.Context,
+	config *types.L2ConfigInfo,
+	l2BlockNumber *big.Int,
+	l1BlockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	if len(config.Addresses) == 0 || len(config.StorageSlots) == 0 {
+		return nil, common.Address{}, fmt.Errorf("invalid config: addresses or slots are empty")
+	}
+
+	outputIndexAfterData, err := p.l2OutputOracleABI.Pack("getL2OutputIndexAfter", l2BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("failed to pack getL2OutputIndexAfter: %w", err)
+	}
+
+	l2OutputOracleAddr := config.Addresses[0]
+
+	// The oracle reverts when no output covering the block has been proposed yet
+	outputIndexAfterResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &l2OutputOracleAddr,
+		Data: outputIndexAfterData,
+	}, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf(
+			"failed to find an output for L2 block %s at L1 block %s: %w",
+			l2BlockNumber,
+			l1BlockNumber,
+			err,
+		)
+	}
+	if len(outputIndexAfterResult) != 32 {
+		return nil, common.Address{}, fmt.Errorf(
+			"unexpected getL2OutputIndexAfter result length %d",
+			len(outputIndexAfterResult),
+		)
+	}
+
+	outputIndex := new(big.Int).SetBytes(outputIndexAfterResult)
+	if p.finalityDelaySeconds == 0 {
+		return outputIndex, l2OutputOracleAddr, nil
+	}
+
+	l1Timestamp, err := p.l1Timestamp(ctx, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	output, err := p.getL2Output(ctx, l2OutputOracleAddr, outputIndex, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	if !p.isFinal(output, l1Timestamp) {
+		return nil, common.Address{}, &OutputNotFinalError{
+			L1BlockNumber: l1BlockNumber,
+			OutputIndex:   outputIndex,
+			FinalAt:       p.finalAt(output),
+		}
+	}
+	return outputIndex, l2OutputOracleAddr, nil
 }
 
 // GenerateSettledStateProof creates a proof for an OPStack Bedrock L2 against L1
 func (p *OPStackBedrockProver) GenerateSettledStateProof(
 	ctx context.Context,
-	l1BlockNumber *big.Int, outputIndex *big.Int,
+	l1Header *types2.Header, outputIndex *big.Int,
 	l2OutputOracleAddr common.Address,
 	config *types.L2ConfigInfo) ([]byte, *types2.Header, error) {
+	l1BlockNumber := l1Header.Number
 	if len(config.Addresses) == 0 || len(config.StorageSlots) == 0 {
 		return nil, nil, fmt.Errorf("invalid config: addresses or slots are empty")
 	}
@@ -233,43 +427,26 @@ func (p *OPStackBedrockProver) GenerateSettledStateProof(
 	}
 
 	// process the eth_getProof result
-	l1StorageProof, rlpEncodedOutputOracleData, l1AccountProof, err := processAccountAndProofs(&proof)
+	l1StorageProof, rlpEncodedOutputOracleData, l1AccountProof, err := processAccountAndProofs(&proof, l1Header.Root)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to process account and proofs: %w", err)
 	}
 
-	l2OutputData, err := p.l2OutputOracleABI.Pack("getL2Output", outputIndex)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to pack getL2Output: %w", err)
-	}
-
-	l2OutputResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
-		To:   &l2OutputOracleAddr,
-		Data: l2OutputData,
-	}, l1BlockNumber)
+	outputProposal, err := p.getL2Output(ctx, l2OutputOracleAddr, outputIndex, l1BlockNumber)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to call getL2Output: %w", err)
+		return nil, nil, err
 	}
 
-	// process the eth_call result
-	// OutputProposal struct has 3 fields: outputRoot, timestamp, l2BlockNumber
-	type OutputProposal struct {
-		OutputRoot    common.Hash
-		Timestamp     *big.Int
-		L2BlockNumber *big.Int
+	// The output root is the first slot of the proposal, so the proven value must match it
+	var provenOutputRoot common.Hash
+	if value := proof.StorageProof[0].Value; value != nil {
+		provenOutputRoot = common.BigToHash(value.ToInt())
 	}
-	// First try to unpack directly into a struct
-	var outputProposal OutputProposal
-
-	// Try the byte-by-byte approach
-	if len(l2OutputResult) >= 96 { // 32 bytes for outputRoot, 32 bytes for timestamp, 32 bytes for l2BlockNumber
-		copy(outputProposal.OutputRoot[:], l2OutputResult[:32])
-		outputProposal.Timestamp = new(big.Int).SetBytes(l2OutputResult[32:64])
-		outputProposal.L2BlockNumber = new(big.Int).SetBytes(l2OutputResult[64:96])
-	} else {
-		// Only try the ABI unpacking as a fallback
-		if err := p.l2OutputOracleABI.UnpackIntoInterface(&outputProposal, "getL2Output", l2OutputResult); err != nil {
-			return nil, nil, fmt.Errorf("failed to unpack output proposal: %w", err)
+	if provenOutputRoot != outputProposal.OutputRoot {
+		return nil, nil, &OutputSlotMismatchError{
+			Index:  outputIndex,
+			Proven: provenOutputRoot,
+			Called: outputProposal.OutputRoot,
 		}
 	}
 
@@ -316,10 +493,23 @@ func (p *OPStackBedrockProver) GenerateSettledStateProof(
 	if err := json.Unmarshal(rawL2Proof, &messagePasserProof); err != nil {
 		return nil, nil, fmt.Errorf("failed to unmarshal message passer proof: %w", err)
 	}
+	if err := verify.ProofResult(l2Header.Root, &messagePasserProof); err != nil {
+		return nil, nil, fmt.Errorf("failed to verify message passer proof: %w", err)
+	}
 
 	// The storageHash from the proof is the L2ToL1MessagePasser root we need
 	messagePasserRoot := messagePasserProof.StorageHash
 
+	// Rebuild the output root from the source L2 and check it against the L2OutputOracle
+	computedOutputRoot := ComputeOutputRootV0(l2Header.Root, messagePasserRoot, l2Header.Hash())
+	if computedOutputRoot != outputProposal.OutputRoot {
+		return nil, nil, &OutputRootMismatchError{
+			L2BlockNumber: outputProposal.L2BlockNumber,
+			Settled:       outputProposal.OutputRoot,
+			Computed:      computedOutputRoot,
+		}
+	}
+
 	outputIndexBytes := make([]byte, 32)
 	binary.BigEndian.PutUint64(outputIndexBytes[24:32], outputIndex.Uint64())
 
@@ -341,7 +531,12 @@ func (p *OPStackBedrockProver) GenerateSettledStateProof(
 	return settledStateProof, &l2Header, nil
 }
 
-func processAccountAndProofs(proof *types.StorageProofResult) ([][]byte, []byte, [][]byte, error) {
+// processAccountAndProofs verifies an eth_getProof result against the given state root
+// and converts it into the storage proof, RLP encoded account and account proof
+func processAccountAndProofs(
+	proof *types.StorageProofResult,
+	stateRoot common.Hash,
+) ([][]byte, []byte, [][]byte, error) {
 	if len(proof.StorageProof) == 0 {
 		return nil, nil, nil, fmt.Errorf("StorageProofResult.StorageProof is nil")
 	}
@@ -351,6 +546,9 @@ func processAccountAndProofs(proof *types.StorageProofResult) ([][]byte, []byte,
 	if proof.Balance == nil {
 		return nil, nil, nil, fmt.Errorf("StorageProofResult balance is nil")
 	}
+	if err := verify.ProofResult(stateRoot, proof); err != nil {
+		return nil, nil, nil, err
+	}
 	l1StorageProof := make([][]byte, len(proof.StorageProof[0].Proof))
 	for i, p := range proof.StorageProof[0].Proof {
 		l1StorageProof[i] = common.FromHex(p)
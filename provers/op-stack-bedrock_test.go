@@ -3,6 +3,7 @@ package provers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math/big"
 	"testing"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/polymerdao/fallback_prover/testutil"
 	types2 "github.com/polymerdao/fallback_prover/types"
 	"github.com/stretchr/testify/assert"
@@ -37,8 +40,9 @@ func TestOPStackBedrockProver_FindLatestResolved(t *testing.T) {
 	// Create mock L1 client
 	mockL1Client := &testutil.MockEthClient{
 		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
-			// Check that we're calling the right contract
+			// Check that we're calling the right contract at the L1 origin block
 			require.Equal(t, l2OutputOracleAddr.Hex(), msg.To.Hex())
+			require.Equal(t, big.NewInt(5000), blockNumber)
 
 			// Return the latestOutputIndex
 			return common.LeftPadBytes(latestOutputIndex.Bytes(), 32), nil
@@ -50,7 +54,7 @@ func TestOPStackBedrockProver_FindLatestResolved(t *testing.T) {
 	require.NoError(t, err)
 
 	// Call the method being tested
-	outputIndex, addr, err := prover.FindLatestResolved(context.Background(), config)
+	outputIndex, addr, err := prover.FindLatestResolved(context.Background(), config, big.NewInt(5000))
 	require.NoError(t, err)
 
 	// Verify the results
@@ -58,6 +62,134 @@ func TestOPStackBedrockProver_FindLatestResolved(t *testing.T) {
 	assert.Equal(t, l2OutputOracleAddr.Hex(), addr.Hex())
 }
 
+func TestOPStackBedrockProver_FindResolvedAtOrAfter(t *testing.T) {
+	l2OutputOracleAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	l1BlockNumber := big.NewInt(5000)
+	config := &types2.L2ConfigInfo{
+		ConfigType:   "OPStackBedrock",
+		Addresses:    []common.Address{l2OutputOracleAddr},
+		StorageSlots: []*big.Int{big.NewInt(0x123)},
+	}
+
+	l2OutputOracleABI, err := getL2OutputOracleABI()
+	require.NoError(t, err)
+	method := l2OutputOracleABI.Methods["getL2OutputIndexAfter"]
+
+	// Outputs are proposed every 100 L2 blocks up to block 1000
+	mockL1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, l2OutputOracleAddr, *msg.To)
+			require.Equal(t, method.ID, msg.Data[:4])
+			require.Equal(t, l1BlockNumber, blockNumber)
+
+			args, err := method.Inputs.Unpack(msg.Data[4:])
+			require.NoError(t, err)
+			target := args[0].(*big.Int)
+			if target.Cmp(big.NewInt(1000)) > 0 {
+				return nil, fmt.Errorf("execution reverted: L2OutputOracle: cannot get output for a block that has not been proposed")
+			}
+			index := new(big.Int).Div(new(big.Int).Add(target, big.NewInt(99)), big.NewInt(100))
+			return common.LeftPadBytes(index.Bytes(), 32), nil
+		},
+	}
+
+	prover, err := NewOPStackBedrockProver(mockL1Client, nil, nil)
+	require.NoError(t, err)
+
+	outputIndex, addr, err := prover.FindResolvedAtOrAfter(context.Background(), config, big.NewInt(250), l1BlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(3), outputIndex)
+	assert.Equal(t, l2OutputOracleAddr, addr)
+
+	_, _, err = prover.FindResolvedAtOrAfter(context.Background(), config, big.NewInt(1001), l1BlockNumber)
+	require.ErrorContains(t, err, "failed to find an output for L2 block 1001 at L1 block 5000")
+}
+
+func TestOPStackBedrockProver_FinalityDelay(t *testing.T) {
+	l2OutputOracleAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	l1BlockNumber := big.NewInt(5000)
+	config := &types2.L2ConfigInfo{
+		ConfigType:   "OPStackBedrock",
+		Addresses:    []common.Address{l2OutputOracleAddr},
+		StorageSlots: []*big.Int{big.NewInt(0x123)},
+	}
+
+	l2OutputOracleABI, err := getL2OutputOracleABI()
+	require.NoError(t, err)
+
+	// Output i covers L2 block 100*i and was proposed at timestamp 1000*(i+1)
+	const latestOutputIndex = 9
+	var l1Time uint64
+	var getL2OutputCalls int
+	mockL1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, l2OutputOracleAddr, *msg.To)
+			require.Equal(t, l1BlockNumber, blockNumber)
+			method, err := l2OutputOracleABI.MethodById(msg.Data[:4])
+			require.NoError(t, err)
+			args, err := method.Inputs.Unpack(msg.Data[4:])
+			require.NoError(t, err)
+			switch method.Name {
+			case "latestOutputIndex":
+				return common.LeftPadBytes(big.NewInt(latestOutputIndex).Bytes(), 32), nil
+			case "getL2OutputIndexAfter":
+				index := new(big.Int).Div(new(big.Int).Add(args[0].(*big.Int), big.NewInt(99)), big.NewInt(100))
+				return common.LeftPadBytes(index.Bytes(), 32), nil
+			case "getL2Output":
+				getL2OutputCalls++
+				index := args[0].(*big.Int).Int64()
+				require.LessOrEqual(t, index, int64(latestOutputIndex))
+				output := make([]byte, 96)
+				copy(output[:32], crypto.Keccak256(big.NewInt(index).Bytes()))
+				copy(output[32:64], common.LeftPadBytes(big.NewInt(1000*(index+1)).Bytes(), 32))
+				copy(output[64:], common.LeftPadBytes(big.NewInt(100*index).Bytes(), 32))
+				return output, nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", method.Name)
+		},
+		BlockByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Block, error) {
+			require.Equal(t, l1BlockNumber, number)
+			return types.NewBlockWithHeader(&types.Header{Number: number, Time: l1Time}), nil
+		},
+	}
+
+	prover, err := NewOPStackBedrockProver(mockL1Client, nil, nil)
+	require.NoError(t, err)
+	prover.SetFinalityDelay(3500)
+
+	// Outputs proposed up to timestamp 5500 are final, so output 4 is the newest final one
+	l1Time = 9000
+	outputIndex, addr, err := prover.FindLatestResolved(context.Background(), config, l1BlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), outputIndex)
+	assert.Equal(t, l2OutputOracleAddr, addr)
+
+	outputIndex, _, err = prover.FindResolvedAtOrAfter(context.Background(), config, big.NewInt(350), l1BlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(4), outputIndex)
+
+	var notFinal *OutputNotFinalError
+	_, _, err = prover.FindResolvedAtOrAfter(context.Background(), config, big.NewInt(550), l1BlockNumber)
+	require.ErrorAs(t, err, &notFinal)
+	assert.Equal(t, big.NewInt(6), notFinal.OutputIndex)
+	assert.Equal(t, uint64(10500), notFinal.FinalAt)
+
+	// The latest output is used as is once it is final
+	l1Time = 13500
+	getL2OutputCalls = 0
+	outputIndex, _, err = prover.FindLatestResolved(context.Background(), config, l1BlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(latestOutputIndex), outputIndex)
+	assert.Equal(t, 1, getL2OutputCalls)
+
+	// Before the first output is final the error reports when it will be
+	l1Time = 4000
+	_, _, err = prover.FindLatestResolved(context.Background(), config, l1BlockNumber)
+	require.ErrorAs(t, err, &notFinal)
+	assert.Equal(t, big.NewInt(0), notFinal.OutputIndex)
+	assert.Equal(t, uint64(4500), notFinal.FinalAt)
+}
+
 func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 	// Parse the L2OutputOracle ABI
 	l2OutputOracleABI, err := getL2OutputOracleABI()
@@ -66,10 +198,23 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 	// Create test data
 	l2OutputOracleAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
 	outputIndex := big.NewInt(123)
-	messagePasserRoot := common.HexToHash("0xfedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321")
+	outputSlot := crypto.Keccak256Hash(
+		common.LeftPadBytes(outputIndex.Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(0x123).Bytes(), 32),
+	)
 
-	// Create a test header and block
+	// Build real L2 state and the output root committing to it
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(L2MessagePasserAddress, common.HexToHash("0x1"), common.HexToHash("0x1"))
 	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Root = l2State.Root(t)
+	outputRoot := ComputeOutputRootV0(l2Header.Root, l2State.StorageRoot(t, L2MessagePasserAddress), l2Header.Hash())
+
+	// Build real L1 state holding the output proposal
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(l2OutputOracleAddr, outputSlot, outputRoot)
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
 
 	// Create L2 config
 	config := &types2.L2ConfigInfo{
@@ -85,6 +230,7 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 	// Create mock L1 client
 	// Create L1BlockNumber for testing
 	expectedL1BlockNumber := big.NewInt(12345)
+	l1Header.Number = expectedL1BlockNumber
 
 	mockL1Client := &testutil.MockEthClient{
 		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
@@ -109,7 +255,6 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 			// getL2Output method
 			if methodSigHex == hexutil.Encode(getL2OutputMethodID) {
 				// Instead of using packing, create a byte array directly
-				outputRoot := common.HexToHash("0x9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba")
 				timestamp := big.NewInt(1000000000)
 				l2BlockNumber := big.NewInt(12345)
 
@@ -131,12 +276,7 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
 			if method == "eth_getProof" {
 				// Mock a storage proof result
-				mockProof := testutil.MockStorageProofResult(
-					t,
-					l2OutputOracleAddr,
-					common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000123"),
-					big.NewInt(456),
-				)
+				mockProof := l1State.GetProof(t, l2OutputOracleAddr, outputSlot)
 
 				// Marshal to JSON and unmarshal into the result
 				mockProofJSON, err := json.Marshal(mockProof)
@@ -151,12 +291,7 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 				elem := &b[i]
 				if elem.Method == "eth_getProof" {
 					// Mock a storage proof result
-					mockProof := testutil.MockStorageProofResult(
-						t,
-						l2OutputOracleAddr,
-						common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000123"),
-						big.NewInt(456),
-					)
+					mockProof := l1State.GetProof(t, l2OutputOracleAddr, outputSlot)
 
 					// Marshal to JSON and unmarshal into the result
 					mockProofJSON, err := json.Marshal(mockProof)
@@ -167,7 +302,6 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 					}
 				} else if elem.Method == "eth_call" {
 					// Mock call result for getL2Output
-					outputRoot := common.HexToHash("0x9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba")
 					timestamp := big.NewInt(1000000000)
 					l2BlockNumber := big.NewInt(12345)
 
@@ -196,15 +330,7 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
 			if method == "eth_getProof" {
 				// Mock a message passer proof result with the specific storage hash we want
-				mockProof := map[string]interface{}{
-					"address":      "0x4200000000000000000000000000000000000016",
-					"accountProof": []string{"0xproof1", "0xproof2"},
-					"balance":      "0x0",
-					"codeHash":     "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-					"nonce":        "0x0",
-					"storageHash":  messagePasserRoot.Hex(),
-					"storageProof": []interface{}{},
-				}
+				mockProof := l2State.GetProof(t, L2MessagePasserAddress)
 
 				// Marshal to JSON and unmarshal into the result
 				mockProofJSON, err := json.Marshal(mockProof)
@@ -219,15 +345,7 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 				elem := &b[i]
 				if elem.Method == "eth_getProof" {
 					// Mock a message passer proof result with the specific storage hash we want
-					mockProof := map[string]interface{}{
-						"address":      "0x4200000000000000000000000000000000000016",
-						"accountProof": []string{"0xproof1", "0xproof2"},
-						"balance":      "0x0",
-						"codeHash":     "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-						"nonce":        "0x0",
-						"storageHash":  messagePasserRoot.Hex(),
-						"storageProof": []interface{}{},
-					}
+					mockProof := l2State.GetProof(t, L2MessagePasserAddress)
 
 					// Marshal to JSON and unmarshal into the result
 					mockProofJSON, err := json.Marshal(mockProof)
@@ -257,7 +375,7 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 	// Call the method being tested
 	settledStateProof, l2Header, err := prover.GenerateSettledStateProof(
 		context.Background(),
-		expectedL1BlockNumber,
+		l1Header,
 		outputIndex,
 		l2OutputOracleAddr,
 		config,
@@ -267,4 +385,105 @@ func TestOPStackBedrockProver_GenerateSettledStateProof(t *testing.T) {
 	// Verify the results
 	assert.NotNil(t, settledStateProof)
 	assert.Equal(t, l2Header.Root.Hex(), l2Header.Root.Hex())
+
+	// The proof decodes back to the proven output
+	decoded, err := DecodeBedrockSettledStateProof(settledStateProof)
+	require.NoError(t, err)
+	assert.Equal(t, common.LeftPadBytes(outputIndex.Bytes(), 32), decoded.OutputIndex)
+	settledRoot, ok, err := SettledOutputRoot(config.ConfigType, settledStateProof, l2Header)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, outputRoot, settledRoot)
+}
+
+func TestOPStackBedrockProver_GenerateSettledStateProof_OutputRootMismatch(t *testing.T) {
+	l2OutputOracleAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	outputIndex := big.NewInt(7)
+	l2BlockNumber := big.NewInt(12345)
+	outputSlot := crypto.Keccak256Hash(
+		common.LeftPadBytes(outputIndex.Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(0x123).Bytes(), 32),
+	)
+	config := &types2.L2ConfigInfo{
+		ConfigType:   "OPStackBedrock",
+		Addresses:    []common.Address{l2OutputOracleAddr},
+		StorageSlots: []*big.Int{big.NewInt(0x123)},
+	}
+
+	// The canonical L2 state was settled on L1...
+	canonicalL2State := testutil.NewProofState()
+	canonicalL2State.SetStorage(L2MessagePasserAddress, common.HexToHash("0x1"), common.HexToHash("0x1"))
+	canonicalHeader := testutil.CreateTestHeader(t)
+	canonicalHeader.Root = canonicalL2State.Root(t)
+	settledOutputRoot := ComputeOutputRootV0(
+		canonicalHeader.Root,
+		canonicalL2State.StorageRoot(t, L2MessagePasserAddress),
+		canonicalHeader.Hash(),
+	)
+
+	// ...but the source L2 RPC serves a forked state
+	forkedL2State := testutil.NewProofState()
+	forkedL2State.SetStorage(L2MessagePasserAddress, common.HexToHash("0x1"), common.HexToHash("0x2"))
+	forkedHeader := testutil.CreateTestHeader(t)
+	forkedHeader.Root = forkedL2State.Root(t)
+
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(l2OutputOracleAddr, outputSlot, settledOutputRoot)
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
+
+	oracleOutputRoot := settledOutputRoot
+	mockL1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			responseData := make([]byte, 96)
+			copy(responseData[0:32], oracleOutputRoot.Bytes())
+			copy(responseData[32:64], common.LeftPadBytes(big.NewInt(1000000000).Bytes(), 32))
+			copy(responseData[64:96], common.LeftPadBytes(l2BlockNumber.Bytes(), 32))
+			return responseData, nil
+		},
+	}
+	mockL1RPC := &testutil.MockRPCClient{
+		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			proofJSON, err := json.Marshal(l1State.GetProof(t, l2OutputOracleAddr, outputSlot))
+			require.NoError(t, err)
+			return json.Unmarshal(proofJSON, result)
+		},
+	}
+	mockL2RPC := &testutil.MockRPCClient{
+		BatchCallContextFunc: func(ctx context.Context, b []rpc.BatchElem) error {
+			for i := range b {
+				var data interface{} = forkedHeader
+				if b[i].Method == "eth_getProof" {
+					data = forkedL2State.GetProof(t, L2MessagePasserAddress)
+				}
+				raw, err := json.Marshal(data)
+				require.NoError(t, err)
+				*(b[i].Result.(*json.RawMessage)) = raw
+			}
+			return nil
+		},
+	}
+
+	prover, err := NewOPStackBedrockProver(mockL1Client, mockL1RPC, mockL2RPC)
+	require.NoError(t, err)
+
+	_, _, err = prover.GenerateSettledStateProof(context.Background(), l1Header, outputIndex, l2OutputOracleAddr, config)
+	var mismatchErr *OutputRootMismatchError
+	require.ErrorAs(t, err, &mismatchErr)
+	assert.Equal(t, settledOutputRoot, mismatchErr.Settled)
+	assert.Equal(
+		t,
+		ComputeOutputRootV0(forkedHeader.Root, forkedL2State.StorageRoot(t, L2MessagePasserAddress), forkedHeader.Hash()),
+		mismatchErr.Computed,
+	)
+	assert.Equal(t, l2BlockNumber.String(), mismatchErr.L2BlockNumber.String())
+
+	// The proven oracle slot must hold the output root read from the oracle
+	oracleOutputRoot = common.HexToHash("0xbad")
+	_, _, err = prover.GenerateSettledStateProof(context.Background(), l1Header, outputIndex, l2OutputOracleAddr, config)
+	var slotErr *OutputSlotMismatchError
+	require.ErrorAs(t, err, &slotErr)
+	assert.Equal(t, outputIndex, slotErr.Index)
+	assert.Equal(t, settledOutputRoot, slotErr.Proven)
+	assert.Equal(t, oracleOutputRoot, slotErr.Called)
 }
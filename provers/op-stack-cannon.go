@@ -2,19 +2,18 @@ package provers
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math/big"
-	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/rpc"
 
 	types2 "github.com/ethereum/go-ethereum/core/types"
 
+	"github.com/polymerdao/fallback_prover/provers/verify"
 	"github.com/polymerdao/fallback_prover/types"
 
 	"github.com/ethereum/go-ethereum"
@@ -35,6 +34,11 @@ type OPStackCannonProver struct {
 	abi        abi.ABI
 	factoryABI abi.ABI
 	gameABI    abi.ABI
+	portalABI  abi.ABI
+	rules      CannonGameRules
+	games      *disputeGameCache
+	// portalWarning warns once that the portal rules are disabled
+	portalWarning sync.Once
 }
 
 // struct DisputeGameFactoryProofData {
@@ -82,6 +86,64 @@ type FaultDisputeGameStatusSlot struct {
 	L2BlockNumberChallenged bool
 }
 
+// Byte offsets of the fields packed into the FaultDisputeGame status slot, counted from the
+// low-order end of the slot as laid out by solc
+const (
+	statusSlotCreatedAtOffset               = 0
+	statusSlotResolvedAtOffset              = 8
+	statusSlotGameStatusOffset              = 16
+	statusSlotInitializedOffset             = 17
+	statusSlotL2BlockNumberChallengedOffset = 18
+)
+
+// DecodeFaultDisputeGameStatusSlot unpacks the createdAt, resolvedAt, status, initialized and
+// l2BlockNumberChallenged fields the FaultDisputeGame packs into a single storage slot
+func DecodeFaultDisputeGameStatusSlot(value common.Hash) FaultDisputeGameStatusSlot {
+	// field returns the size bytes stored offset bytes above the low-order end of the slot
+	field := func(offset, size int) []byte {
+		end := common.HashLength - offset
+		return value[end-size : end]
+	}
+	return FaultDisputeGameStatusSlot{
+		CreatedAt:               binary.BigEndian.Uint64(field(statusSlotCreatedAtOffset, 8)),
+		ResolvedAt:              binary.BigEndian.Uint64(field(statusSlotResolvedAtOffset, 8)),
+		GameStatus:              field(statusSlotGameStatusOffset, 1)[0],
+		Initialized:             field(statusSlotInitializedOffset, 1)[0] != 0,
+		L2BlockNumberChallenged: field(statusSlotL2BlockNumberChallengedOffset, 1)[0] != 0,
+	}
+}
+
+// GameStatusSlotMismatchError is returned when a field of the proven status slot disagrees
+// with the value the dispute game returns for it
+type GameStatusSlotMismatchError struct {
+	GameAddress common.Address
+	Field       string
+	Proven      uint64
+	Called      uint64
+}
+
+func (e *GameStatusSlotMismatchError) Error() string {
+	return fmt.Sprintf(
+		"game %s proven %s %d does not match called %s %d",
+		e.GameAddress.Hex(),
+		e.Field,
+		e.Proven,
+		e.Field,
+		e.Called,
+	)
+}
+
+// GameNotDefenderWinsError is returned when the proven status of the selected dispute game
+// is not DEFENDER_WINS, so its root claim cannot be relied upon
+type GameNotDefenderWinsError struct {
+	GameAddress common.Address
+	Status      GameStatus
+}
+
+func (e *GameNotDefenderWinsError) Error() string {
+	return fmt.Sprintf("game %s has proven status %s, expected %s", e.GameAddress.Hex(), e.Status, GameStatusDefenderWins)
+}
+
 type FaultDisputeGameProof struct {
 	FaultDisputeGameStateRoot             [32]byte
 	FaultDisputeGameRootClaimStorageProof [][]byte
@@ -91,7 +153,7 @@ type FaultDisputeGameProof struct {
 	FaultDisputeGameAccountProof          [][]byte
 }
 
-var DisputeGameFactoryProofDataType, err = abi.NewType("tuple", "DisputeGameFactoryProofData", []abi.ArgumentMarshaling{
+var DisputeGameFactoryProofDataType, _ = abi.NewType("tuple", "DisputeGameFactoryProofData", []abi.ArgumentMarshaling{
 	{Name: "messagePasserStateRoot", Type: "bytes32", InternalType: "bytes32"},
 	{Name: "latestBlockHash", Type: "bytes32", InternalType: "bytes32"},
 	{Name: "gameIndex", Type: "uint256", InternalType: "uint256"},
@@ -179,6 +241,11 @@ func NewOPStackCannonProver(l1Client IEthClient, l1RPC, l2RPC IRPCClient) (*OPSt
 		return nil, err
 	}
 
+	portalABIObj, err := getOptimismPortal2ABI()
+	if err != nil {
+		return nil, err
+	}
+
 	return &OPStackCannonProver{
 		l1Client:   l1Client,
 		l1RPC:      l1RPC,
@@ -186,35 +253,15 @@ func NewOPStackCannonProver(l1Client IEthClient, l1RPC, l2RPC IRPCClient) (*OPSt
 		abi:        abiObj,
 		factoryABI: factoryAbiObj,
 		gameABI:    gameABIObj,
+		portalABI:  portalABIObj,
+
+		games: newDisputeGameCache(),
 	}, nil
 }
 
 // getOPStackCannonProverABI loads and parses the OPStackCannonProver ABI from file
 func getOPStackCannonProverABI() (abi.ABI, error) {
-	// Get the absolute path of the current file
-	_, thisFile, _, _ := runtime.Caller(0)
-	// Construct the path to the ABI file
-	abiPath := filepath.Join(filepath.Dir(thisFile), "abis", "OPStackCannonProver.abi.json")
-
-	// Read the ABI file
-	abiFile, err := os.Open(abiPath)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to open OPStackCannonProver ABI file: %w", err)
-	}
-	defer abiFile.Close()
-
-	abiBytes, err := io.ReadAll(abiFile)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to read OPStackCannonProver ABI file: %w", err)
-	}
-
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(string(abiBytes)))
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to parse OPStackCannonProver ABI: %w", err)
-	}
-
-	return parsedABI, nil
+	return getBundledABI("OPStackCannonProver")
 }
 
 // getDisputeGameFactoryABI returns the ABI for the DisputeGameFactory contract
@@ -355,137 +402,172 @@ func getFaultDisputeGameABI() (abi.ABI, error) {
 	]`))
 }
 
+// GameStatus mirrors the GameStatus enum of the FaultDisputeGame contract
+type GameStatus uint8
+
+const (
+	GameStatusInProgress GameStatus = iota
+	GameStatusChallengerWins
+	GameStatusDefenderWins
+)
+
+func (s GameStatus) String() string {
+	switch s {
+	case GameStatusInProgress:
+		return "IN_PROGRESS"
+	case GameStatusChallengerWins:
+		return "CHALLENGER_WINS"
+	case GameStatusDefenderWins:
+		return "DEFENDER_WINS"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", uint8(s))
+	}
+}
+
 // Constants for OP Stack Cannon
 const (
 	// Standard address for L2ToL1MessagePasser in OP Stack
 	CannonL2MessagePasserAddress = "0x4200000000000000000000000000000000000016"
 )
 
+// UnpackGameId splits a DisputeGameFactory GameId into its packed fields:
+// gameType (uint32) << 224 | timestamp (uint64) << 160 | proxy address
+func UnpackGameId(gameId common.Hash) (uint32, uint64, common.Address) {
+	gameType := binary.BigEndian.Uint32(gameId[0:4])
+	timestamp := binary.BigEndian.Uint64(gameId[4:12])
+	return gameType, timestamp, common.BytesToAddress(gameId[12:])
+}
+
+// GameIdMismatchError is returned when the GameId read from the factory is not the one the
+// proven factory slot holds, or does not belong to the dispute game selected for the proof
+type GameIdMismatchError struct {
+	GameIndex    *big.Int
+	GameId       common.Hash
+	ProvenGameId common.Hash
+	GameAddress  common.Address
+}
+
+func (e *GameIdMismatchError) Error() string {
+	if e.ProvenGameId != e.GameId {
+		return fmt.Sprintf(
+			"proven factory slot of game index %s holds game id %s, expected %s",
+			e.GameIndex,
+			e.ProvenGameId.Hex(),
+			e.GameId.Hex(),
+		)
+	}
+	_, _, idAddress := UnpackGameId(e.GameId)
+	return fmt.Sprintf(
+		"game id %s at index %s encodes game %s, expected %s",
+		e.GameId.Hex(),
+		e.GameIndex,
+		idAddress.Hex(),
+		e.GameAddress.Hex(),
+	)
+}
+
+// FindLatestResolved returns the newest DEFENDER_WINS game known to the factory at l1BlockNumber
+// that passes the CannonGameRules. Games are scanned newest first in batches, and what cannot
+// change anymore is memoized across calls.
 func (p *OPStackCannonProver) FindLatestResolved(
 	ctx context.Context,
 	config *types.L2ConfigInfo,
+	l1BlockNumber *big.Int,
 ) (*big.Int, common.Address, error) {
-	if len(config.Addresses) < 1 || len(config.StorageSlots) < 3 {
-		return nil, common.Address{}, fmt.Errorf("invalid config: addresses or slots are insufficient")
-	}
-
-	// Get addresses and slots from the config
-	disputeGameFactoryAddr := config.Addresses[0]
-
-	// Get the total number of games
-	gameCountData, err := p.factoryABI.Pack("gameCount")
+	search, err := p.newGameSearch(ctx, config, l1BlockNumber)
 	if err != nil {
-		return nil, common.Address{}, fmt.Errorf("failed to pack gameCount call: %w", err)
+		return nil, common.Address{}, err
 	}
-	gameCountResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
-		To:   &disputeGameFactoryAddr,
-		Data: gameCountData,
-	}, nil)
-	if err != nil {
-		return nil, common.Address{}, fmt.Errorf("failed to call gameCount: %w", err)
+	if search.gameCount == 0 {
+		return nil, common.Address{}, fmt.Errorf("no dispute games at L1 block %s", l1BlockNumber)
 	}
 
-	// Handle the case where we receive raw bytes or properly ABI-encoded data
-	var gameCount *big.Int
+	log.Debug("Searching dispute games", "count", search.gameCount, "l1Block", l1BlockNumber)
 
-	// First, try to parse it as raw bytes
-	if len(gameCountResult) == 32 {
-		gameCount = new(big.Int).SetBytes(gameCountResult)
-		log.Debug(
-			"Parsed gameCount from bytes",
-			"count",
-			gameCount,
-			"len",
-			len(gameCountResult),
-			"bytes",
-			fmt.Sprintf("%x", gameCountResult),
-		)
-	} else {
-		log.Debug("Received empty gameCountResult", "len", len(gameCountResult))
-		return nil, common.Address{}, fmt.Errorf("empty game count result from contract")
+	game, err := p.newestGame(ctx, search, 0, search.gameCount)
+	if err != nil {
+		return nil, common.Address{}, err
 	}
-	if gameCount.Cmp(big.NewInt(0)) <= 0 {
-		return nil, common.Address{}, fmt.Errorf("invalid game count %s", gameCount.String())
+	if game == nil {
+		return nil, common.Address{}, fmt.Errorf("no resolved dispute games found at L1 block %s", l1BlockNumber)
 	}
+	return new(big.Int).SetUint64(game.index), game.address(), nil
+}
 
-	// Find the latest resolved dispute game with a valid L2 output
-	var gameIndex *big.Int
-	var gameAddress common.Address
+// FindResolvedAtOrAfter returns the DEFENDER_WINS game with the lowest L2 block number that is
+// at or after l2BlockNumber and passes the CannonGameRules, as seen by the factory at
+// l1BlockNumber. Honest proposals are made for increasing L2 blocks, so the search walks back
+// from the newest game and stops at the first valid game below the target.
+func (p *OPStackCannonProver) FindResolvedAtOrAfter(
+	ctx context.Context,
+	config *types.L2ConfigInfo,
+	l2BlockNumber *big.Int,
+	l1BlockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	search, err := p.newGameSearch(ctx, config, l1BlockNumber)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
 
-	// Start from the most recent game and work backwards
-	for i := new(big.Int).Sub(gameCount, big.NewInt(1)); i.Sign() >= 0; i.Sub(i, big.NewInt(1)) {
-		// Get the game address
-		gameAtIndexData, err := p.factoryABI.Pack("gameAtIndex", i)
-		if err != nil {
-			return nil, common.Address{}, fmt.Errorf("failed to pack gameAtIndex call for index %v: %w", i, err)
+	var games []*disputeGame
+	var selected *disputeGame
+	var selectedL2BlockNumber *big.Int
+search:
+	for end := search.gameCount; end > 0; end -= uint64(len(games)) {
+		start := uint64(0)
+		if end > disputeGameBatchSize {
+			start = end - disputeGameBatchSize
 		}
-
-		gameAtIndexResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
-			To:   &disputeGameFactoryAddr,
-			Data: gameAtIndexData,
-		}, nil)
+		games, err = p.loadGames(ctx, search, start, end)
 		if err != nil {
-			return nil, common.Address{}, fmt.Errorf("failed to call gameAtIndex for index %v: %w", i, err)
-		}
-
-		var currentGameAddress common.Address
-		if len(gameAtIndexResult) > 0 {
-			copy(currentGameAddress[:], gameAtIndexResult[len(gameAtIndexResult)-20:]) // Take last 20 bytes
-		} else {
-			log.Debug("Received empty gameAtIndexResult", "index", i)
-			continue
+			return nil, common.Address{}, err
 		}
-		log.Debug("Game address from factory", "index", i, "address", currentGameAddress.Hex())
-
-		statusData, err := p.gameABI.Pack("status")
+		eligible, err := p.eligibleGames(ctx, search, games)
 		if err != nil {
-			return nil, common.Address{}, fmt.Errorf(
-				"failed to pack status call for game %s: %w",
-				currentGameAddress.Hex(),
-				err,
-			)
+			return nil, common.Address{}, err
 		}
-
-		statusResult, err := p.l1Client.CallContract(ctx, ethereum.CallMsg{
-			To:   &currentGameAddress,
-			Data: statusData,
-		}, nil)
+		l2BlockNumbers, err := p.loadL2BlockNumbers(ctx, search, eligible)
 		if err != nil {
-			log.Debug("Failed to call status for game", "address", currentGameAddress.Hex(), "error", err)
-			continue
+			return nil, common.Address{}, err
 		}
 
-		var gameStatus uint8
-		if len(statusResult) > 0 {
-			gameStatus = statusResult[len(statusResult)-1] // Take last byte
-			log.Debug("Game status", "index", i, "address", currentGameAddress.Hex(), "status", gameStatus)
-
-			// Check if the game is resolved (status = 2 is typically RESOLVED/FINALIZED)
-			// The exact status enum may vary, so adjust as needed
-			if gameStatus == 2 { // Assuming 2 is RESOLVED status
-				gameIndex = new(big.Int).Set(i)
-				gameAddress = currentGameAddress
-				break
+		for i, game := range eligible {
+			if l2BlockNumbers[i] == nil {
+				continue
+			}
+			log.Debug("Resolved game", "index", game.index, "address", game.address().Hex(), "l2BlockNumber", l2BlockNumbers[i])
+			if l2BlockNumbers[i].Cmp(l2BlockNumber) < 0 {
+				break search
+			}
+			if selectedL2BlockNumber == nil || l2BlockNumbers[i].Cmp(selectedL2BlockNumber) < 0 {
+				selected = game
+				selectedL2BlockNumber = l2BlockNumbers[i]
 			}
 		}
 	}
 
-	if gameIndex == nil || gameIndex.Sign() < 0 {
-		return nil, common.Address{}, fmt.Errorf("no suitable resolved dispute games found")
+	if selected == nil {
+		return nil, common.Address{}, fmt.Errorf(
+			"no resolved dispute game at or after L2 block %s found at L1 block %s",
+			l2BlockNumber,
+			l1BlockNumber,
+		)
 	}
-	return gameIndex, gameAddress, nil
+	return new(big.Int).SetUint64(selected.index), selected.address(), nil
 }
 
 // GenerateSettledStateProof creates a proof for an OPStack Cannon L2 against L1
 func (p *OPStackCannonProver) GenerateSettledStateProof(
 	ctx context.Context,
-	l1BlockNumber, gameIndex *big.Int,
+	l1Header *types2.Header, gameIndex *big.Int,
 	gameAddress common.Address,
 	config *types.L2ConfigInfo) ([]byte, *types2.Header, error) {
 	if len(config.Addresses) < 1 || len(config.StorageSlots) < 3 {
 		return nil, nil, fmt.Errorf("invalid config: addresses or slots are insufficient")
 	}
 
+	l1BlockNumber := l1Header.Number
+
 	// Get addresses and slots from the config
 	disputeGameFactoryAddr := config.Addresses[0]
 	disputeGameFactoryListSlot := common.BigToHash(config.StorageSlots[0])
@@ -493,7 +575,6 @@ func (p *OPStackCannonProver) GenerateSettledStateProof(
 	faultDisputeGameStatusSlot := common.BigToHash(config.StorageSlots[2])
 
 	log.Debug("Using game", "index", gameIndex, "address", gameAddress.Hex())
-	fmt.Printf("Using game index %d, address %s \n", gameIndex, gameAddress.Hex())
 
 	// Get storage proof for the dispute game factory
 	// Calculate the storage slot for the game index
@@ -518,7 +599,7 @@ func (p *OPStackCannonProver) GenerateSettledStateProof(
 	// Query the storage slot
 	// Use eth_storageAt to query the storage slot
 	var gameId string
-	err = p.l1RPC.CallContext(
+	err := p.l1RPC.CallContext(
 		ctx,
 		&gameId,
 		"eth_getStorageAt",
@@ -551,22 +632,20 @@ func (p *OPStackCannonProver) GenerateSettledStateProof(
 	}
 	for _, elem := range l1BatchElems {
 		if elem.Error != nil {
-			fmt.Println("ERRORR 1")
 			return nil, nil, fmt.Errorf("l1 RPC batch request error for method %s: %w", elem.Method, elem.Error)
 		}
 		if elem.Result == nil {
-			fmt.Println("ERRORR")
 			return nil, nil, fmt.Errorf("l1 RPC batch request result is nil for method %s", elem.Method)
 		}
 	}
 
-	fmt.Println("done batching things")
-
 	var faultDisputeGameProof types.StorageProofResult
 	if err := json.Unmarshal(rawGameProof, &faultDisputeGameProof); err != nil {
-		fmt.Println("ERRORR 1")
 		return nil, nil, fmt.Errorf("failed to unmarshal game proof: %w", err)
 	}
+	if err := verify.ProofResult(l1Header.Root, &faultDisputeGameProof); err != nil {
+		return nil, nil, fmt.Errorf("failed to verify game proof: %w", err)
+	}
 	var disputeGameFactoryProof types.StorageProofResult
 	if err := json.Unmarshal(rawFactoryProof, &disputeGameFactoryProof); err != nil {
 		return nil, nil, fmt.Errorf("failed to unmarshal factory proof: %w", err)
@@ -617,11 +696,27 @@ func (p *OPStackCannonProver) GenerateSettledStateProof(
 	// Convert storage proof to bytes
 	disputeGameFactoryStorageProof, rlpEncodedDisputeGameFactoryData, disputeGameFactoryAccountProof, err := processAccountAndProofs(
 		&disputeGameFactoryProof,
+		l1Header.Root,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to process account and proofs: %w", err)
 	}
 
+	// The proven factory slot must hold the GameId we encode, and it must point at the selected game
+	gameIdHash := common.HexToHash(gameId)
+	var provenGameId common.Hash
+	if value := disputeGameFactoryProof.StorageProof[0].Value; value != nil {
+		provenGameId = common.BigToHash(value.ToInt())
+	}
+	if _, _, idAddress := UnpackGameId(gameIdHash); provenGameId != gameIdHash || idAddress != gameAddress {
+		return nil, nil, &GameIdMismatchError{
+			GameIndex:    gameIndex,
+			GameId:       gameIdHash,
+			ProvenGameId: provenGameId,
+			GameAddress:  gameAddress,
+		}
+	}
+
 	// Decode the L2 block number result
 	var l2BlockNumber *big.Int
 	if len(l2BlockNumberResult) == 32 {
@@ -713,8 +808,40 @@ func (p *OPStackCannonProver) GenerateSettledStateProof(
 		return nil, nil, fmt.Errorf("invalid resolvedAt %d", resolvedAt)
 	}
 
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to RLP encode status data: %w", err)
+	// Decode the status slot from the proof, which is what the verifier checks the encoded fields against
+	var statusSlotValue common.Hash
+	if value := faultDisputeGameProof.StorageProof[statusProofIndex].Value; value != nil {
+		statusSlotValue = common.BigToHash(value.ToInt())
+	}
+	statusData := DecodeFaultDisputeGameStatusSlot(statusSlotValue)
+	log.Debug("Decoded status slot",
+		"createdAt", statusData.CreatedAt,
+		"resolvedAt", statusData.ResolvedAt,
+		"status", GameStatus(statusData.GameStatus),
+		"initialized", statusData.Initialized,
+		"l2BlockNumberChallenged", statusData.L2BlockNumberChallenged)
+
+	if statusData.CreatedAt != createdAt {
+		return nil, nil, &GameStatusSlotMismatchError{
+			GameAddress: gameAddress,
+			Field:       "createdAt",
+			Proven:      statusData.CreatedAt,
+			Called:      createdAt,
+		}
+	}
+	if statusData.ResolvedAt != resolvedAt {
+		return nil, nil, &GameStatusSlotMismatchError{
+			GameAddress: gameAddress,
+			Field:       "resolvedAt",
+			Proven:      statusData.ResolvedAt,
+			Called:      resolvedAt,
+		}
+	}
+	if GameStatus(statusData.GameStatus) != GameStatusDefenderWins {
+		return nil, nil, &GameNotDefenderWinsError{
+			GameAddress: gameAddress,
+			Status:      GameStatus(statusData.GameStatus),
+		}
 	}
 
 	// Create RLP encoded fault dispute game account
@@ -786,22 +913,31 @@ func (p *OPStackCannonProver) GenerateSettledStateProof(
 	if err := json.Unmarshal(rawL2Proof, &messagePasserProof); err != nil {
 		return nil, nil, fmt.Errorf("failed to unmarshal message passer proof: %w", err)
 	}
+	if err := verify.ProofResult(l2Header.Root, &messagePasserProof); err != nil {
+		return nil, nil, fmt.Errorf("failed to verify message passer proof: %w", err)
+	}
 
 	messagePasserRoot := messagePasserProof.StorageHash
 
-	statusData := FaultDisputeGameStatusSlot{
-		CreatedAt:               createdAt, // Example timestamp
-		ResolvedAt:              resolvedAt,
-		GameStatus:              2,     // Example status
-		Initialized:             true,  // must be true if the game resolved
-		L2BlockNumberChallenged: false, // must be false if the game resolved in favor of defender
+	// Rebuild the output root from the source L2 and check it against the proven root claim
+	var rootClaim common.Hash
+	if value := faultDisputeGameProof.StorageProof[rootClaimProofIndex].Value; value != nil {
+		rootClaim = common.BigToHash(value.ToInt())
+	}
+	computedOutputRoot := ComputeOutputRootV0(l2Header.Root, messagePasserRoot, l2Header.Hash())
+	if computedOutputRoot != rootClaim {
+		return nil, nil, &OutputRootMismatchError{
+			L2BlockNumber: l2BlockNumber,
+			Settled:       rootClaim,
+			Computed:      computedOutputRoot,
+		}
 	}
 
 	factoryData := DisputeGameFactoryProof{
 		MessagePasserStateRoot:           messagePasserRoot,
 		LatestBlockHash:                  l2Header.Hash(),
 		GameIndex:                        gameIndex,
-		GameId:                           common.HexToHash(gameId),
+		GameId:                           gameIdHash,
 		DisputeFaultGameStorageProof:     disputeGameFactoryStorageProof,
 		RlpEncodedDisputeGameFactoryData: rlpEncodedDisputeGameFactoryData,
 		DisputeGameFactoryAccountProof:   disputeGameFactoryAccountProof,
@@ -831,12 +967,9 @@ func encodeCannonProof(factoryData DisputeGameFactoryProof, faultData FaultDispu
 		faultData,
 	)
 	if err != nil {
-		fmt.Printf("failed to pack data: %v\n", err)
-		return nil, err
+		return nil, fmt.Errorf("failed to pack cannon proof: %w", err)
 	}
 
-	fmt.Printf("Encoded _proof (hex): 0x%x\n", encodedBytes)
-
 	return encodedBytes, nil
 
 }
@@ -2,14 +2,17 @@ package provers
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/polymerdao/fallback_prover/testutil"
@@ -19,99 +22,48 @@ import (
 )
 
 func TestOPStackCannonProver_FindLatestResolved(t *testing.T) {
-	// Create test data
-	disputeGameFactoryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
-	disputeGameAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
-	gameCount := big.NewInt(1) // Only have one game in the factory
-	gameIndex := big.NewInt(0) // The first and only game
-	gameStatus := uint8(2)     // RESOLVED status value (important for this test)
-
-	// Create L2 config
-	config := &types2.L2ConfigInfo{
-		ConfigType: "OPStackCannon",
-		Addresses: []common.Address{
-			disputeGameFactoryAddr,
-		},
-		StorageSlots: []*big.Int{
-			big.NewInt(0x123), // DisputeGameFactory list slot
-			big.NewInt(0x456), // FaultDisputeGame rootClaim slot
-			big.NewInt(0x789), // FaultDisputeGame status slot
-		},
-	}
-
-	// Create mock L1 client
-	mockL1Client := &testutil.MockEthClient{
-		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
-			// Check that we're calling one of the expected contracts
-			if msg.To.Hex() == disputeGameFactoryAddr.Hex() {
-				// Debug log the incoming message data
-				t.Logf("Calling factory contract with data: %x", msg.Data)
-
-				// Determine which method is being called by looking at the method signature
-				methodSig := msg.Data[:4]
-				methodSigHex := hexutil.Encode(methodSig)
-
-				// Get method signatures from the same ABI used in the code being tested
-				disputeGameFactoryABI, _ := getDisputeGameFactoryABI()
-				gameCountMethodID := disputeGameFactoryABI.Methods["gameCount"].ID
-				gameAtIndexMethodID := disputeGameFactoryABI.Methods["gameAtIndex"].ID
-
-				// gameCount method
-				if methodSigHex == hexutil.Encode(gameCountMethodID) {
-					t.Logf("Handling gameCount call...")
-					// Ensure we're returning a non-empty response
-					response := common.LeftPadBytes(gameCount.Bytes(), 32)
-					t.Logf("Returning gameCount response: %x (len: %d)", response, len(response))
-					return response, nil
-				}
-
-				// gameAtIndex method
-				if methodSigHex == hexutil.Encode(gameAtIndexMethodID) {
-					t.Logf("Handling gameAtIndex call...")
-					// Return the address as a 32-byte value
-					return common.LeftPadBytes(disputeGameAddr.Bytes(), 32), nil
-				}
-
-				return nil, fmt.Errorf("Unknown method signature: %s for contract  %s", methodSigHex, msg.To.Hex())
-			} else if msg.To.Hex() == disputeGameAddr.Hex() {
-				// Debug log the incoming message data
-				t.Logf("Calling dispute game contract with data: %x", msg.Data)
-
-				// Determine which method is being called by looking at the method signature
-				methodSig := msg.Data[:4]
-				methodSigHex := hexutil.Encode(methodSig)
-
-				// Get method signatures from the same ABI used in the code being tested
-				faultDisputeGameABI, _ := getFaultDisputeGameABI()
-				statusMethodID := faultDisputeGameABI.Methods["status"].ID
-
-				// status method
-				if methodSigHex == hexutil.Encode(statusMethodID) {
-					t.Logf("Handling status call...")
-					// Return the status as a byte
-					statusBytes := []byte{gameStatus}
-					return common.LeftPadBytes(statusBytes, 32), nil
-				}
-
-				return nil, fmt.Errorf("Unknown method signature: %s for contract  %s", methodSigHex, msg.To.Hex())
-			}
-
-			t.Logf("CallContract called with unknown address: %s", msg.To.Hex())
-			return nil, nil
-		},
-	}
-
-	// Create the OPStackCannonProver
-	prover, err := NewOPStackCannonProver(mockL1Client, nil, nil)
-	require.NoError(t, err)
+	f := newMockDisputeGameFactory(t, 1650000000, []mockGame{
+		{createdAt: 1640000000, status: GameStatusDefenderWins},
+	})
 
 	// Call the method being tested
-	outputIndex, addr, err := prover.FindLatestResolved(context.Background(), config)
+	outputIndex, addr, err := f.prover().FindLatestResolved(context.Background(), f.config(), big.NewInt(5000))
 	require.NoError(t, err)
 
 	// Verify the results
-	assert.Equal(t, gameIndex.String(), outputIndex.String())
-	assert.Equal(t, disputeGameAddr.Hex(), addr.Hex())
+	assert.Equal(t, "0", outputIndex.String())
+	assert.Equal(t, f.gameAddress(0).Hex(), addr.Hex())
+}
+
+func TestOPStackCannonProver_FindResolvedAtOrAfter(t *testing.T) {
+	f := newMockDisputeGameFactory(t, 1650000000, []mockGame{
+		{l2BlockNumber: 100, status: GameStatusDefenderWins},
+		{l2BlockNumber: 200, status: GameStatusDefenderWins},
+		{l2BlockNumber: 250, status: GameStatusChallengerWins},
+		{l2BlockNumber: 300, status: GameStatusDefenderWins},
+		{l2BlockNumber: 400, status: GameStatusInProgress},
+	})
+	prover := f.prover()
+
+	tests := []struct {
+		target int64
+		index  int64
+	}{
+		{target: 150, index: 1},
+		{target: 200, index: 1},
+		{target: 201, index: 3},
+		{target: 50, index: 0},
+	}
+	for _, tt := range tests {
+		gameIndex, addr, err := prover.FindResolvedAtOrAfter(context.Background(), f.config(), big.NewInt(tt.target), f.l1Header.Number)
+		require.NoError(t, err, "target %d", tt.target)
+		assert.Equal(t, big.NewInt(tt.index), gameIndex, "target %d", tt.target)
+		assert.Equal(t, f.gameAddress(uint64(tt.index)), addr, "target %d", tt.target)
+	}
+
+	// The in progress game at block 400 cannot be proven yet
+	_, _, err := prover.FindResolvedAtOrAfter(context.Background(), f.config(), big.NewInt(301), f.l1Header.Number)
+	require.ErrorContains(t, err, "no resolved dispute game at or after L2 block 301")
 }
 
 func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
@@ -120,14 +72,40 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 	disputeGameAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
 	gameCount := big.NewInt(1) // Only have one game in the factory
 	gameIndex := big.NewInt(0) // The first and only game
-	rootClaim := common.HexToHash("0x9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba")
-	gameStatus := uint8(2) // RESOLVED status value (important for this test)
-	messagePasserRoot := common.HexToHash("0xfedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321")
-	faultDisputeGameStateRoot := common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890")
+	gameStatus := uint8(2)     // RESOLVED status value (important for this test)
 	disputeGameABI, err := getFaultDisputeGameABI()
 	require.NoError(t, err)
-	// Create a test header and block
+
+	// Build real L1 and L2 state so the generated proofs verify
+	gameIndexSlot := common.BigToHash(new(big.Int).Add(
+		new(big.Int).SetBytes(crypto.Keccak256(common.BigToHash(big.NewInt(0x123)).Bytes())),
+		gameIndex,
+	))
+	gameId := packGameId(0, 1650000000, disputeGameAddr)
+	rootClaimSlot := common.BigToHash(big.NewInt(0x456))
+	statusSlot := common.BigToHash(big.NewInt(0x789))
+	// createdAt | resolvedAt << 64 | status << 128 | initialized << 136
+	statusSlotValue := new(big.Int).SetUint64(1650000000)
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(new(big.Int).SetUint64(1650001000), 64))
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(int64(gameStatus)), 128))
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(1), 136))
+
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(common.HexToAddress(CannonL2MessagePasserAddress), common.HexToHash("0x1"), common.HexToHash("0x1"))
 	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Root = l2State.Root(t)
+	rootClaim := ComputeOutputRootV0(
+		l2Header.Root,
+		l2State.StorageRoot(t, common.HexToAddress(CannonL2MessagePasserAddress)),
+		l2Header.Hash(),
+	)
+
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(disputeGameFactoryAddr, gameIndexSlot, gameId)
+	l1State.SetStorage(disputeGameAddr, rootClaimSlot, rootClaim)
+	l1State.SetStorage(disputeGameAddr, statusSlot, common.BigToHash(statusSlotValue))
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
 
 	// Create L2 config
 	config := &types2.L2ConfigInfo{
@@ -145,6 +123,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 	// Create mock L1 client
 	// Create L1BlockNumber for testing
 	expectedL1BlockNumber := big.NewInt(12345)
+	l1Header.Number = expectedL1BlockNumber
 
 	mockL1Client := &testutil.MockEthClient{
 		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
@@ -266,18 +245,17 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 	// Create mock RPC clients that handle eth_getProof
 	mockL1RPC := &testutil.MockRPCClient{
 		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if method == "eth_getStorageAt" {
+				*(result.(*string)) = gameId.Hex()
+				return nil
+			}
 			if method == "eth_getProof" {
 				// We need to handle proofs for both the dispute game factory and the fault dispute game
 				address := args[0].(string)
 
 				if address == disputeGameFactoryAddr.Hex() {
 					// Mock a dispute game factory proof
-					mockProof := testutil.MockStorageProofResult(
-						t,
-						disputeGameFactoryAddr,
-						common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000123"),
-						gameIndex,
-					)
+					mockProof := l1State.GetProof(t, disputeGameFactoryAddr, gameIndexSlot)
 
 					// Marshal to JSON and unmarshal into the result
 					mockProofJSON, err := json.Marshal(mockProof)
@@ -285,26 +263,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 					return json.Unmarshal(mockProofJSON, result)
 				} else if address == disputeGameAddr.Hex() {
 					// Mock a fault dispute game proof
-					mockProof := map[string]interface{}{
-						"address":      disputeGameAddr.Hex(),
-						"accountProof": []string{"0xproof1", "0xproof2"},
-						"balance":      "0x0",
-						"codeHash":     "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-						"nonce":        "0x0",
-						"storageHash":  faultDisputeGameStateRoot.Hex(),
-						"storageProof": []map[string]interface{}{
-							{
-								"key":   common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000456").Hex(),
-								"value": rootClaim.Hex(),
-								"proof": []string{"0xproof1", "0xproof2"},
-							},
-							{
-								"key":   common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000789").Hex(),
-								"value": "0x2", // Game status
-								"proof": []string{"0xproof1", "0xproof2"},
-							},
-						},
-					}
+					mockProof := l1State.GetProof(t, disputeGameAddr, rootClaimSlot, statusSlot)
 
 					// Marshal to JSON and unmarshal into the result
 					mockProofJSON, err := json.Marshal(mockProof)
@@ -331,12 +290,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 
 					if address == disputeGameFactoryAddr.Hex() {
 						// Mock a dispute game factory proof
-						mockProof := testutil.MockStorageProofResult(
-							t,
-							disputeGameFactoryAddr,
-							common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000123"),
-							gameIndex,
-						)
+						mockProof := l1State.GetProof(t, disputeGameFactoryAddr, gameIndexSlot)
 
 						// Marshal to JSON and unmarshal into the result
 						mockProofJSON, err := json.Marshal(mockProof)
@@ -347,26 +301,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 						}
 					} else if address == disputeGameAddr.Hex() {
 						// Mock a fault dispute game proof
-						mockProof := map[string]interface{}{
-							"address":      disputeGameAddr.Hex(),
-							"accountProof": []string{"0xproof1", "0xproof2"},
-							"balance":      "0x0",
-							"codeHash":     "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-							"nonce":        "0x0",
-							"storageHash":  faultDisputeGameStateRoot.Hex(),
-							"storageProof": []map[string]interface{}{
-								{
-									"key":   common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000456").Hex(),
-									"value": rootClaim.Hex(),
-									"proof": []string{"0xproof1", "0xproof2"},
-								},
-								{
-									"key":   common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000789").Hex(),
-									"value": "0x2", // Game status
-									"proof": []string{"0xproof1", "0xproof2"},
-								},
-							},
-						}
+						mockProof := l1State.GetProof(t, disputeGameAddr, rootClaimSlot, statusSlot)
 
 						// Marshal to JSON and unmarshal into the result
 						mockProofJSON, err := json.Marshal(mockProof)
@@ -433,15 +368,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
 			if method == "eth_getProof" {
 				// Mock a message passer proof result with the specific storage hash we want
-				mockProof := map[string]interface{}{
-					"address":      "0x4200000000000000000000000000000000000016",
-					"accountProof": []string{"0xproof1", "0xproof2"},
-					"balance":      "0x0",
-					"codeHash":     "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-					"nonce":        "0x0",
-					"storageHash":  messagePasserRoot.Hex(),
-					"storageProof": []interface{}{},
-				}
+				mockProof := l2State.GetProof(t, common.HexToAddress(CannonL2MessagePasserAddress))
 
 				// Marshal to JSON and unmarshal into the result
 				mockProofJSON, err := json.Marshal(mockProof)
@@ -456,15 +383,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 				elem := &b[i]
 				if elem.Method == "eth_getProof" {
 					// Mock a message passer proof result with the specific storage hash we want
-					mockProof := map[string]interface{}{
-						"address":      "0x4200000000000000000000000000000000000016",
-						"accountProof": []string{"0xproof1", "0xproof2"},
-						"balance":      "0x0",
-						"codeHash":     "0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef",
-						"nonce":        "0x0",
-						"storageHash":  messagePasserRoot.Hex(),
-						"storageProof": []interface{}{},
-					}
+					mockProof := l2State.GetProof(t, common.HexToAddress(CannonL2MessagePasserAddress))
 
 					// Marshal to JSON and unmarshal into the result
 					mockProofJSON, err := json.Marshal(mockProof)
@@ -498,7 +417,7 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 	// Call the method being tested
 	settledStateProof, l2Header, err := prover.GenerateSettledStateProof(
 		context.Background(),
-		expectedL1BlockNumber,
+		l1Header,
 		gameIndex,
 		disputeGameAddr,
 		config,
@@ -512,4 +431,216 @@ func TestOPStackCannonProver_GenerateSettledStateProof(t *testing.T) {
 	assert.NotNil(t, settledStateProof)
 	assert.Equal(t, l2Header.Root.Hex(), l2Header.Root.Hex())
 	assert.Equal(t, expectedRlpEncodedL2Header, rlpEncodedL2Header)
+
+	// The encoded status fields are the ones decoded from the proven slot
+	values, err := EncodedOpstackCannonProof.Unpack(settledStateProof)
+	require.NoError(t, err)
+	statusData := reflect.ValueOf(values[1]).FieldByName("FaultDisputeGameStatusSlotData")
+	assert.Equal(t, uint64(1650000000), statusData.FieldByName("CreatedAt").Interface())
+	assert.Equal(t, uint64(1650001000), statusData.FieldByName("ResolvedAt").Interface())
+	assert.Equal(t, gameStatus, statusData.FieldByName("GameStatus").Interface())
+	assert.Equal(t, true, statusData.FieldByName("Initialized").Interface())
+	assert.Equal(t, false, statusData.FieldByName("L2BlockNumberChallenged").Interface())
+}
+
+func TestOPStackCannonProver_GenerateSettledStateProof_Mismatch(t *testing.T) {
+	disputeGameFactoryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	disputeGameAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	gameIndex := big.NewInt(0)
+	createdAt := uint64(1650000000)
+	resolvedAt := uint64(1650001000)
+	l2BlockNumber := big.NewInt(12345)
+	config := &types2.L2ConfigInfo{
+		ConfigType:   "OPStackCannon",
+		Addresses:    []common.Address{disputeGameFactoryAddr},
+		StorageSlots: []*big.Int{big.NewInt(0x123), big.NewInt(0x456), big.NewInt(0x789)},
+	}
+	gameIndexSlot := common.BigToHash(new(big.Int).Add(
+		new(big.Int).SetBytes(crypto.Keccak256(common.BigToHash(big.NewInt(0x123)).Bytes())),
+		gameIndex,
+	))
+	rootClaimSlot := common.BigToHash(big.NewInt(0x456))
+	statusSlot := common.BigToHash(big.NewInt(0x789))
+
+	l2State := testutil.NewProofState()
+	l2State.SetStorage(common.HexToAddress(CannonL2MessagePasserAddress), common.HexToHash("0x1"), common.HexToHash("0x1"))
+	l2Header := testutil.CreateTestHeader(t)
+	l2Header.Number = l2BlockNumber
+	l2Header.Root = l2State.Root(t)
+	settledRootClaim := ComputeOutputRootV0(
+		l2Header.Root,
+		l2State.StorageRoot(t, common.HexToAddress(CannonL2MessagePasserAddress)),
+		l2Header.Hash(),
+	)
+
+	// The proven factory, root claim and status slots are rebuilt from these on every proof
+	gameId := packGameId(0, createdAt, disputeGameAddr)
+	rootClaim := settledRootClaim
+	gameStatus := uint8(GameStatusDefenderWins)
+	challenged := false
+	l1State := testutil.NewProofState()
+	l1Header := testutil.CreateTestHeader(t)
+
+	// The called game id and resolvedAt are served separately from the proven slots
+	servedGameId := gameId
+	servedResolvedAt := resolvedAt
+	disputeGameABI, err := getFaultDisputeGameABI()
+	require.NoError(t, err)
+	mockL1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, disputeGameAddr, *msg.To)
+			method, err := disputeGameABI.MethodById(msg.Data[:4])
+			require.NoError(t, err)
+			switch method.Name {
+			case "l2BlockNumber":
+				return common.LeftPadBytes(l2BlockNumber.Bytes(), 32), nil
+			case "createdAt":
+				return common.LeftPadBytes(new(big.Int).SetUint64(createdAt).Bytes(), 32), nil
+			case "resolvedAt":
+				return common.LeftPadBytes(new(big.Int).SetUint64(servedResolvedAt).Bytes(), 32), nil
+			case "status":
+				return common.LeftPadBytes([]byte{gameStatus}, 32), nil
+			}
+			return nil, fmt.Errorf("unexpected call %s", method.Name)
+		},
+	}
+	mockL1RPC := &testutil.MockRPCClient{
+		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			require.Equal(t, "eth_getStorageAt", method)
+			*(result.(*string)) = servedGameId.Hex()
+			return nil
+		},
+		BatchCallContextFunc: func(ctx context.Context, b []rpc.BatchElem) error {
+			for i := range b {
+				require.Equal(t, "eth_getProof", b[i].Method)
+				var slots []common.Hash
+				for _, slot := range b[i].Args[1].([]string) {
+					slots = append(slots, common.HexToHash(slot))
+				}
+				raw, err := json.Marshal(l1State.GetProof(t, common.HexToAddress(b[i].Args[0].(string)), slots...))
+				require.NoError(t, err)
+				*(b[i].Result.(*json.RawMessage)) = raw
+			}
+			return nil
+		},
+	}
+	mockL2RPC := &testutil.MockRPCClient{
+		BatchCallContextFunc: func(ctx context.Context, b []rpc.BatchElem) error {
+			for i := range b {
+				var data interface{} = l2Header
+				if b[i].Method == "eth_getProof" {
+					data = l2State.GetProof(t, common.HexToAddress(CannonL2MessagePasserAddress))
+				}
+				raw, err := json.Marshal(data)
+				require.NoError(t, err)
+				*(b[i].Result.(*json.RawMessage)) = raw
+			}
+			return nil
+		},
+	}
+
+	prover, err := NewOPStackCannonProver(mockL1Client, mockL1RPC, mockL2RPC)
+	require.NoError(t, err)
+	generate := func() error {
+		// createdAt | resolvedAt << 64 | status << 128 | initialized << 136 | l2BlockNumberChallenged << 144
+		statusSlotValue := new(big.Int).SetUint64(createdAt)
+		statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(new(big.Int).SetUint64(resolvedAt), 64))
+		statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(int64(gameStatus)), 128))
+		statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(1), 136))
+		if challenged {
+			statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(1), 144))
+		}
+		l1State.SetStorage(disputeGameFactoryAddr, gameIndexSlot, gameId)
+		l1State.SetStorage(disputeGameAddr, rootClaimSlot, rootClaim)
+		l1State.SetStorage(disputeGameAddr, statusSlot, common.BigToHash(statusSlotValue))
+		l1Header.Root = l1State.Root(t)
+
+		_, _, err := prover.GenerateSettledStateProof(context.Background(), l1Header, gameIndex, disputeGameAddr, config)
+		return err
+	}
+
+	require.NoError(t, generate())
+
+	// The game's root claim must be the output root of the L2 block it settles
+	rootClaim = common.HexToHash("0x9876543210fedcba9876543210fedcba9876543210fedcba9876543210fedcba")
+	var rootClaimErr *OutputRootMismatchError
+	require.ErrorAs(t, generate(), &rootClaimErr)
+	assert.Equal(t, rootClaim, rootClaimErr.Settled)
+	assert.Equal(t, settledRootClaim, rootClaimErr.Computed)
+	assert.Equal(t, l2BlockNumber.String(), rootClaimErr.L2BlockNumber.String())
+	rootClaim = settledRootClaim
+
+	// The factory must have created the game at the given index
+	otherGame := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	gameId = packGameId(0, createdAt, otherGame)
+	servedGameId = gameId
+	var gameIdErr *GameIdMismatchError
+	err = generate()
+	require.ErrorAs(t, err, &gameIdErr)
+	assert.Equal(t, gameId, gameIdErr.GameId)
+	assert.Equal(t, gameId, gameIdErr.ProvenGameId)
+	assert.Equal(t, disputeGameAddr, gameIdErr.GameAddress)
+	assert.Contains(t, err.Error(), otherGame.Hex())
+	gameId = packGameId(0, createdAt, disputeGameAddr)
+
+	// The proven factory slot must hold the game id that was read
+	servedGameId = packGameId(0, createdAt+1, disputeGameAddr)
+	err = generate()
+	require.ErrorAs(t, err, &gameIdErr)
+	assert.Equal(t, servedGameId, gameIdErr.GameId)
+	assert.Equal(t, gameId, gameIdErr.ProvenGameId)
+	assert.Contains(t, err.Error(), "proven factory slot of game index 0 holds game id")
+	servedGameId = gameId
+
+	// The proven status slot must agree with the called game fields
+	servedResolvedAt = resolvedAt + 1
+	var statusSlotErr *GameStatusSlotMismatchError
+	require.ErrorAs(t, generate(), &statusSlotErr)
+	assert.Equal(t, "resolvedAt", statusSlotErr.Field)
+	assert.Equal(t, resolvedAt, statusSlotErr.Proven)
+	assert.Equal(t, servedResolvedAt, statusSlotErr.Called)
+	servedResolvedAt = resolvedAt
+
+	// Only DEFENDER_WINS games settle the L2
+	gameStatus = uint8(GameStatusChallengerWins)
+	challenged = true
+	var statusErr *GameNotDefenderWinsError
+	require.ErrorAs(t, generate(), &statusErr)
+	assert.Equal(t, GameStatusChallengerWins, statusErr.Status)
+	assert.Equal(t, disputeGameAddr, statusErr.GameAddress)
+}
+
+// packGameId packs a DisputeGameFactory GameId the same way the factory does
+func packGameId(gameType uint32, timestamp uint64, gameAddr common.Address) common.Hash {
+	var gameId common.Hash
+	binary.BigEndian.PutUint32(gameId[0:4], gameType)
+	binary.BigEndian.PutUint64(gameId[4:12], timestamp)
+	copy(gameId[12:], gameAddr.Bytes())
+	return gameId
+}
+
+func TestUnpackGameId(t *testing.T) {
+	gameAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	gameType, timestamp, addr := UnpackGameId(packGameId(1, 1650000000, gameAddr))
+	assert.Equal(t, uint32(1), gameType)
+	assert.Equal(t, uint64(1650000000), timestamp)
+	assert.Equal(t, gameAddr, addr)
+}
+
+func TestDecodeFaultDisputeGameStatusSlot(t *testing.T) {
+	// createdAt | resolvedAt << 64 | status << 128 | initialized << 136 | l2BlockNumberChallenged << 144
+	statusSlotValue := new(big.Int).SetUint64(1650000000)
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(new(big.Int).SetUint64(1650001000), 64))
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(int64(GameStatusChallengerWins)), 128))
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(1), 136))
+	statusSlotValue.Or(statusSlotValue, new(big.Int).Lsh(big.NewInt(1), 144))
+
+	assert.Equal(t, FaultDisputeGameStatusSlot{
+		CreatedAt:               1650000000,
+		ResolvedAt:              1650001000,
+		GameStatus:              uint8(GameStatusChallengerWins),
+		Initialized:             true,
+		L2BlockNumberChallenged: true,
+	}, DecodeFaultDisputeGameStatusSlot(common.BigToHash(statusSlotValue)))
+	assert.Equal(t, FaultDisputeGameStatusSlot{}, DecodeFaultDisputeGameStatusSlot(common.Hash{}))
 }
@@ -0,0 +1,40 @@
+package provers
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// OutputRootVersionV0 is the only output root version used by OP Stack chains
+var OutputRootVersionV0 = common.Hash{}
+
+// ComputeOutputRootV0 rebuilds an OP Stack version 0 output root from its preimage:
+// keccak256(version ++ stateRoot ++ messagePasserStorageRoot ++ blockHash)
+func ComputeOutputRootV0(stateRoot, messagePasserStorageRoot, blockHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash(
+		OutputRootVersionV0.Bytes(),
+		stateRoot.Bytes(),
+		messagePasserStorageRoot.Bytes(),
+		blockHash.Bytes(),
+	)
+}
+
+// OutputRootMismatchError is returned when the output root rebuilt from the source L2
+// differs from the one settled on L1, typically because the source L2 RPC is forked or unsynced
+type OutputRootMismatchError struct {
+	L2BlockNumber *big.Int
+	Settled       common.Hash
+	Computed      common.Hash
+}
+
+func (e *OutputRootMismatchError) Error() string {
+	return fmt.Sprintf(
+		"output root mismatch at L2 block %s: settled %s, computed %s",
+		e.L2BlockNumber,
+		e.Settled.Hex(),
+		e.Computed.Hex(),
+	)
+}
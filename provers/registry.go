@@ -3,28 +3,36 @@ package provers
 import (
 	"context"
 	"fmt"
-	"io"
 	"math/big"
-	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
 
+	"github.com/polymerdao/fallback_prover/provers/verify"
 	t "github.com/polymerdao/fallback_prover/types"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+var _ IRegistryProver = &RegistryProver{}
+
+// DefaultL2ConfigMappingSlot is the storage slot of the l2ChainConfigurationHashMap mapping in
+// the Registry contract
+const DefaultL2ConfigMappingSlot = 2
+
 // RegistryProver handles interactions with the Registry contract on L1
 type RegistryProver struct {
 	l1Client     IEthClient
 	l1RPC        IRPCClient
 	registryAddr common.Address
 	abi          abi.ABI
+	// l2ConfigMappingSlot is the storage slot of l2ChainConfigurationHashMap
+	l2ConfigMappingSlot *big.Int
+	// l1ConfigMappingSlot is the storage slot of l1ChainConfigurationHashMap, nil until set
+	l1ConfigMappingSlot *big.Int
 }
 
 // NewRegistryProver creates a new RegistryProver
@@ -39,35 +47,21 @@ func NewRegistryProver(l1Client IEthClient, l1RPC IRPCClient, registryAddr commo
 		l1RPC:        l1RPC,
 		registryAddr: registryAddr,
 		abi:          registryABI,
+
+		l2ConfigMappingSlot: big.NewInt(DefaultL2ConfigMappingSlot),
 	}
 }
 
+// SetL2ConfigMappingSlot sets the storage slot of the l2ChainConfigurationHashMap mapping the
+// registry storage proofs are generated for, e.g. the settlementRegistryL2ConfigMappingSlot of
+// the destination NativeProver
+func (r *RegistryProver) SetL2ConfigMappingSlot(slot *big.Int) {
+	r.l2ConfigMappingSlot = slot
+}
+
 // getRegistryABI loads and parses the Registry ABI from file
 func getRegistryABI() (abi.ABI, error) {
-	// Get the absolute path of the current file
-	_, thisFile, _, _ := runtime.Caller(0)
-	// Construct the path to the ABI file
-	abiPath := filepath.Join(filepath.Dir(thisFile), "abis", "Registry.abi.json")
-
-	// Read the ABI file
-	abiFile, err := os.Open(abiPath)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to open Registry ABI file: %w", err)
-	}
-	defer abiFile.Close()
-
-	abiBytes, err := io.ReadAll(abiFile)
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to read Registry ABI file: %w", err)
-	}
-
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(string(abiBytes)))
-	if err != nil {
-		return abi.ABI{}, fmt.Errorf("failed to parse Registry ABI: %w", err)
-	}
-
-	return parsedABI, nil
+	return getBundledABI("Registry")
 }
 
 // convertTypeToString converts the L2 config enum value to a string
@@ -86,6 +80,47 @@ func convertTypeToString(typeValue uint8) string {
 	}
 }
 
+// ConfigTypeName returns the config type name of an L2 type, as in L2ConfigInfo.ConfigType
+func ConfigTypeName(l2Type t.L2Type) string {
+	return convertTypeToString(uint8(l2Type))
+}
+
+// ParseL2Type returns the L2 type of a config type name, as returned by ConfigTypeName
+func ParseL2Type(name string) (t.L2Type, error) {
+	for l2Type := t.OPStackBedrock; l2Type <= t.Nitro; l2Type++ {
+		if strings.EqualFold(name, ConfigTypeName(l2Type)) {
+			return l2Type, nil
+		}
+	}
+	return t.Unknown, fmt.Errorf(
+		"unknown L2 type %q: expected %s, %s or %s",
+		name,
+		ConfigTypeName(t.OPStackBedrock),
+		ConfigTypeName(t.OPStackCannon),
+		ConfigTypeName(t.Nitro),
+	)
+}
+
+// EncodeUpdateL2ChainConfigurationCalldata encodes the calldata of a Registry
+// updateL2ChainConfiguration call setting the configuration of chainID to config
+func (r *RegistryProver) EncodeUpdateL2ChainConfigurationCalldata(
+	chainID uint64,
+	config *t.L2Configuration,
+) ([]byte, error) {
+	calldata, err := r.abi.Pack("updateL2ChainConfiguration", new(big.Int).SetUint64(chainID), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack updateL2ChainConfiguration: %w", err)
+	}
+	return calldata, nil
+}
+
+// SetL1ConfigMappingSlot sets the storage slot of the l1ChainConfigurationHashMap mapping the
+// L1 configuration proofs are generated for, e.g. the settlementRegistryL1ConfigMappingSlot of
+// the destination NativeProver
+func (r *RegistryProver) SetL1ConfigMappingSlot(slot *big.Int) {
+	r.l1ConfigMappingSlot = slot
+}
+
 // GetL2Configuration fetches the L2 configuration for a given chain ID
 func (r *RegistryProver) GetL2Configuration(ctx context.Context, chainID uint64) (*t.L2ConfigInfo, error) {
 	// 1. Query for the L2 config type
@@ -183,8 +218,13 @@ func (r *RegistryProver) GetL1BlockHashOracle(ctx context.Context, chainID uint6
 	return oracleAddr, nil
 }
 
-// GetL2ConfigurationForUpdate retrieves the complete L2Configuration for generating update proofs
-func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainID uint64) (*t.L2Configuration, error) {
+// GetL2ConfigurationForUpdate retrieves the complete L2Configuration for generating update proofs,
+// as of blockNumber or the latest block if it is nil
+func (r *RegistryProver) GetL2ConfigurationForUpdate(
+	ctx context.Context,
+	chainID uint64,
+	blockNumber *big.Int,
+) (*t.L2Configuration, error) {
 	chainIDParam := big.NewInt(int64(chainID))
 
 	// Get L2 config type (enum value)
@@ -196,7 +236,7 @@ func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainI
 	configTypeResult, err := r.l1Client.CallContract(ctx, ethereum.CallMsg{
 		To:   &r.registryAddr,
 		Data: configTypeData,
-	}, nil)
+	}, blockNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call getL2ConfigType: %w", err)
 	}
@@ -213,6 +253,8 @@ func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainI
 		l2Type = t.OPStackBedrock
 	case 2: // OPStackCannon (based on the contract enum)
 		l2Type = t.OPStackCannon
+	case 3: // Arbitrum (based on the contract enum)
+		l2Type = t.Nitro
 	default:
 		return nil, fmt.Errorf("unsupported L2 type enum value: %d", l2TypeEnum)
 	}
@@ -226,7 +268,7 @@ func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainI
 	addressesResult, err := r.l1Client.CallContract(ctx, ethereum.CallMsg{
 		To:   &r.registryAddr,
 		Data: addressesData,
-	}, nil)
+	}, blockNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call getL2ConfigAddresses: %w", err)
 	}
@@ -245,7 +287,7 @@ func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainI
 	slotsResult, err := r.l1Client.CallContract(ctx, ethereum.CallMsg{
 		To:   &r.registryAddr,
 		Data: slotsData,
-	}, nil)
+	}, blockNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call getL2ConfigStorageSlots: %w", err)
 	}
@@ -264,7 +306,7 @@ func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainI
 	l2ConfigResult, err := r.l1Client.CallContract(ctx, ethereum.CallMsg{
 		To:   &r.registryAddr,
 		Data: l2ConfigData,
-	}, nil)
+	}, blockNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call l2ChainConfigurations: %w", err)
 	}
@@ -294,22 +336,67 @@ func (r *RegistryProver) GetL2ConfigurationForUpdate(ctx context.Context, chainI
 	}, nil
 }
 
-// GetRegistryStorageProof gets a storage proof for the registry contract
+// GetRegistryStorageProof gets a storage proof for the registry contract at the given L1 block
+// and verifies it against that block's state root
 func (r *RegistryProver) GetRegistryStorageProof(
 	ctx context.Context,
 	chainID uint64,
-	blockNum *big.Int,
+	l1Header *types.Header,
+) ([][]byte, []byte, [][]byte, error) {
+	proof, err := r.getMappingStorageProof(ctx, "L2", r.l2ConfigMappingSlot, chainID, l1Header)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return proof.storageProof, proof.rlpEncodedAccount, proof.accountProof, nil
+}
+
+// GetRegistryL1ConfigStorageProof gets a storage proof of l1ChainConfigurationHashMap[chainID] in
+// the registry contract at the given L1 block and verifies it against that block's state root
+func (r *RegistryProver) GetRegistryL1ConfigStorageProof(
+	ctx context.Context,
+	chainID uint64,
+	l1Header *types.Header,
 ) ([][]byte, []byte, [][]byte, error) {
-	// Calculate the storage slot for l2ChainConfigurationHashMap[chainID]
+	proof, err := r.getL1ConfigStorageProof(ctx, chainID, l1Header)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return proof.storageProof, proof.rlpEncodedAccount, proof.accountProof, nil
+}
+
+func (r *RegistryProver) getL1ConfigStorageProof(
+	ctx context.Context,
+	chainID uint64,
+	l1Header *types.Header,
+) (*mappingStorageProof, error) {
+	if r.l1ConfigMappingSlot == nil {
+		return nil, fmt.Errorf("registry L1 config mapping slot is not set, it is read from the NativeProver L1_CONFIGURATION()")
+	}
+	return r.getMappingStorageProof(ctx, "L1", r.l1ConfigMappingSlot, chainID, l1Header)
+}
+
+// mappingStorageProof is the proof of a configuration hash in a registry mapping
+type mappingStorageProof struct {
+	storageProof      [][]byte
+	rlpEncodedAccount []byte
+	accountProof      [][]byte
+	// value is the proven configuration hash
+	value common.Hash
+}
+
+// getMappingStorageProof gets a verified storage proof of the configuration hash of chainID in the
+// registry mapping at mappingSlot
+func (r *RegistryProver) getMappingStorageProof(
+	ctx context.Context,
+	configName string,
+	mappingSlot *big.Int,
+	chainID uint64,
+	l1Header *types.Header,
+) (*mappingStorageProof, error) {
 	// In Solidity, the storage slot for mapping(uint256 => bytes32) at position X is keccak256(key . X)
 	// where . is concatenation and X is the position (padded to 32 bytes)
-
-	// In the Registry contract, l2ChainConfigurationHashMap is at slot 2
 	chainIDBytes := common.LeftPadBytes(big.NewInt(int64(chainID)).Bytes(), 32)
-	mappingSlot := common.LeftPadBytes(big.NewInt(2).Bytes(), 32)
-
-	// Calculate the actual storage slot: keccak256(chainID + mappingSlot)
-	slotPreimage := append(chainIDBytes, mappingSlot...)
+	slotPreimage := append(chainIDBytes, common.LeftPadBytes(mappingSlot.Bytes(), 32)...)
 	slotHash := common.BytesToHash(crypto.Keccak256(slotPreimage))
 
 	// Use eth_getProof to generate the proof
@@ -320,10 +407,10 @@ func (r *RegistryProver) GetRegistryStorageProof(
 		"eth_getProof",
 		r.registryAddr,
 		[]string{slotHash.Hex()},
-		toBlockNumArg(blockNum),
+		toBlockNumArg(l1Header.Number),
 	)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to get storage proof from registry: %w", err)
+		return nil, fmt.Errorf("failed to get storage proof from registry: %w", err)
 	}
 
 	// Convert account proof to bytes
@@ -334,7 +421,13 @@ func (r *RegistryProver) GetRegistryStorageProof(
 
 	// Check if we have a storage proof
 	if len(result.StorageProof) == 0 {
-		return nil, nil, nil, fmt.Errorf("no storage proof found for L2 configuration in registry")
+		return nil, fmt.Errorf("no storage proof found for %s configuration in registry", configName)
+	}
+	if result.Nonce == nil || result.Balance == nil {
+		return nil, fmt.Errorf("incomplete account data in registry proof")
+	}
+	if err := verify.ProofResult(l1Header.Root, &result); err != nil {
+		return nil, fmt.Errorf("failed to verify registry proof: %w", err)
 	}
 
 	// Convert storage proof to bytes
@@ -354,38 +447,164 @@ func (r *RegistryProver) GetRegistryStorageProof(
 	// RLP encode the account
 	rlpEncodedAccount, err := rlp.EncodeToBytes(account)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to RLP encode registry account: %w", err)
+		return nil, fmt.Errorf("failed to RLP encode registry account: %w", err)
 	}
 
-	return storageProof, rlpEncodedAccount, accountProof, nil
+	return &mappingStorageProof{
+		storageProof:      storageProof,
+		rlpEncodedAccount: rlpEncodedAccount,
+		accountProof:      accountProof,
+		value:             common.BigToHash(result.StorageProof[0].Value.ToInt()),
+	}, nil
 }
 
-// GenerateUpdateL2ConfigArgs builds a complete UpdateL2ConfigArgs structure
+// GenerateUpdateL2ConfigArgs builds a complete UpdateL2ConfigArgs structure. The configuration is
+// read at the same L1 block as its proof and checked against the proven configuration hash.
 func (r *RegistryProver) GenerateUpdateL2ConfigArgs(
 	ctx context.Context,
 	chainID uint64,
-	blockNumber *big.Int,
+	l1Header *types.Header,
 ) (*t.UpdateL2ConfigArgs, error) {
 	// Get the L2 configuration
-	l2Config, err := r.GetL2ConfigurationForUpdate(ctx, chainID)
+	l2Config, err := r.GetL2ConfigurationForUpdate(ctx, chainID, l1Header.Number)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get L2 configuration: %w", err)
 	}
 
 	// Get the registry storage proof
-	l1StorageProof, rlpEncodedRegistryData, l1RegistryProof, err := r.GetRegistryStorageProof(
-		ctx,
-		chainID,
-		blockNumber,
-	)
+	proof, err := r.getMappingStorageProof(ctx, "L2", r.l2ConfigMappingSlot, chainID, l1Header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get registry storage proof: %w", err)
 	}
 
+	configHash, err := r.L2ConfigurationHash(l2Config)
+	if err != nil {
+		return nil, err
+	}
+	if configHash != proof.value {
+		return nil, &ConfigHashMismatchError{
+			Config:        "L2",
+			ChainID:       chainID,
+			L1BlockNumber: l1Header.Number,
+			Computed:      configHash,
+			Proven:        proof.value,
+		}
+	}
+
 	return &t.UpdateL2ConfigArgs{
 		Config:                        *l2Config,
-		L1StorageProof:                l1StorageProof,
-		RlpEncodedRegistryAccountData: rlpEncodedRegistryData,
-		L1RegistryProof:               l1RegistryProof,
+		L1StorageProof:                proof.storageProof,
+		RlpEncodedRegistryAccountData: proof.rlpEncodedAccount,
+		L1RegistryProof:               proof.accountProof,
+	}, nil
+}
+
+// GetL1ConfigurationForUpdate retrieves the L1Configuration of chainID from the registry for
+// generating update proofs, as of blockNumber or the latest block if it is nil
+func (r *RegistryProver) GetL1ConfigurationForUpdate(
+	ctx context.Context,
+	chainID uint64,
+	blockNumber *big.Int,
+) (*t.L1Configuration, error) {
+	configData, err := r.abi.Pack("l1ChainConfigurations", big.NewInt(int64(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack l1ChainConfigurations: %w", err)
+	}
+
+	configResult, err := r.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &r.registryAddr,
+		Data: configData,
+	}, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call l1ChainConfigurations: %w", err)
+	}
+
+	var config t.L1Configuration
+	if err := r.abi.UnpackIntoInterface(&config, "l1ChainConfigurations", configResult); err != nil {
+		return nil, fmt.Errorf("failed to unpack L1 configuration: %w", err)
+	}
+	return &config, nil
+}
+
+// GenerateUpdateL1ConfigArgs builds a complete UpdateL1ConfigArgs structure. The configuration is
+// read at the same L1 block as its proof and checked against the proven configuration hash.
+func (r *RegistryProver) GenerateUpdateL1ConfigArgs(
+	ctx context.Context,
+	chainID uint64,
+	l1Header *types.Header,
+) (*t.UpdateL1ConfigArgs, error) {
+	l1Config, err := r.GetL1ConfigurationForUpdate(ctx, chainID, l1Header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 configuration: %w", err)
+	}
+
+	proof, err := r.getL1ConfigStorageProof(ctx, chainID, l1Header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry L1 config storage proof: %w", err)
+	}
+
+	configHash, err := r.L1ConfigurationHash(l1Config)
+	if err != nil {
+		return nil, err
+	}
+	if configHash != proof.value {
+		return nil, &ConfigHashMismatchError{
+			Config:        "L1",
+			ChainID:       chainID,
+			L1BlockNumber: l1Header.Number,
+			Computed:      configHash,
+			Proven:        proof.value,
+		}
+	}
+
+	return &t.UpdateL1ConfigArgs{
+		Config:                        *l1Config,
+		L1StorageProof:                proof.storageProof,
+		RlpEncodedRegistryAccountData: proof.rlpEncodedAccount,
+		L1RegistryProof:               proof.accountProof,
 	}, nil
 }
+
+// ConfigHashMismatchError is returned when the hash of a configuration read from the registry is
+// not the configuration hash proven in its storage at the same L1 block
+type ConfigHashMismatchError struct {
+	// Config is L1 or L2
+	Config        string
+	ChainID       uint64
+	L1BlockNumber *big.Int
+	Computed      common.Hash
+	Proven        common.Hash
+}
+
+func (e *ConfigHashMismatchError) Error() string {
+	return fmt.Sprintf(
+		"%s configuration of chain %d hashes to %s, but the registry holds %s at L1 block %s",
+		e.Config,
+		e.ChainID,
+		e.Computed.Hex(),
+		e.Proven.Hex(),
+		e.L1BlockNumber,
+	)
+}
+
+// L2ConfigurationHash returns keccak256(abi.encode(config)), the hash the registry keeps in
+// l2ChainConfigurationHashMap
+func (r *RegistryProver) L2ConfigurationHash(config *t.L2Configuration) (common.Hash, error) {
+	return r.configurationHash("updateL2ChainConfiguration", config)
+}
+
+// L1ConfigurationHash returns keccak256(abi.encode(config)), the hash the registry keeps in
+// l1ChainConfigurationHashMap
+func (r *RegistryProver) L1ConfigurationHash(config *t.L1Configuration) (common.Hash, error) {
+	return r.configurationHash("updateL1ChainConfiguration", config)
+}
+
+// configurationHash ABI encodes config as the _config argument of the registry update method
+// and hashes it
+func (r *RegistryProver) configurationHash(method string, config interface{}) (common.Hash, error) {
+	encoded, err := abi.Arguments{r.abi.Methods[method].Inputs[1]}.Pack(config)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode %s config: %w", method, err)
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
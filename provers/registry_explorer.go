@@ -0,0 +1,302 @@
+package provers
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	t "github.com/polymerdao/fallback_prover/types"
+)
+
+// DefaultAdminRole is the DEFAULT_ADMIN_ROLE of the Registry access control
+var DefaultAdminRole = common.Hash{}
+
+// GetRegistryChains lists every chain with an L2ChainConfigurationUpdated or
+// L1ChainConfigurationUpdated log from L1 block fromBlock, with its configurations read at the
+// latest L1 block. Logs are requested in ranges of at most logBlockRange
+// blocks, or all at once if it is zero.
+func (r *RegistryProver) GetRegistryChains(
+	ctx context.Context,
+	fromBlock uint64,
+	logBlockRange uint64,
+) (*t.RegistryChains, error) {
+	head, err := r.latestBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := r.filterRegistryLogs(
+		ctx,
+		fromBlock,
+		head,
+		logBlockRange,
+		"L2ChainConfigurationUpdated",
+		"L1ChainConfigurationUpdated",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make(map[uint64]*t.RegistryChain)
+	for _, log := range logs {
+		chainID, err := logChainID(log)
+		if err != nil {
+			return nil, err
+		}
+		chain, ok := chains[chainID]
+		if !ok {
+			chain = &t.RegistryChain{
+				ChainID:                chainID,
+				L2ConfigurationUpdates: []t.RegistryConfigUpdate{},
+				L1ConfigurationUpdates: []t.RegistryConfigUpdate{},
+			}
+			chains[chainID] = chain
+		}
+		update := t.RegistryConfigUpdate{
+			ConfigHash:  log.Topics[2],
+			BlockNumber: log.BlockNumber,
+			TxHash:      log.TxHash,
+		}
+		if log.Topics[0] == r.abi.Events["L2ChainConfigurationUpdated"].ID {
+			chain.L2ConfigurationUpdates = append(chain.L2ConfigurationUpdates, update)
+		} else {
+			chain.L1ConfigurationUpdates = append(chain.L1ConfigurationUpdates, update)
+		}
+	}
+
+	result := &t.RegistryChains{
+		Registry:    r.registryAddr,
+		FromBlock:   fromBlock,
+		BlockNumber: head,
+		Chains:      make([]t.RegistryChain, 0, len(chains)),
+	}
+	chainIDs := make([]uint64, 0, len(chains))
+	for chainID := range chains {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Slice(chainIDs, func(i, j int) bool { return chainIDs[i] < chainIDs[j] })
+
+	blockNumber := new(big.Int).SetUint64(head)
+	for _, chainID := range chainIDs {
+		chain := chains[chainID]
+		if len(chain.L2ConfigurationUpdates) > 0 {
+			chain.L2Configuration, err = r.GetL2ConfigurationForUpdate(ctx, chainID, blockNumber)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get L2 configuration of chain %d: %w", chainID, err)
+			}
+			chain.ConfigType = ConfigTypeName(chain.L2Configuration.L2Type)
+		}
+		if len(chain.L1ConfigurationUpdates) > 0 {
+			chain.L1Configuration, err = r.GetL1ConfigurationForUpdate(ctx, chainID, blockNumber)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get L1 configuration of chain %d: %w", chainID, err)
+			}
+		}
+		result.Chains = append(result.Chains, *chain)
+	}
+	return result, nil
+}
+
+// GetRegistryAccess reads the owner, paused state, irrevocable grantees and role members of the
+// registry. Grantees and roles are found in the NewIrrevocableGrantee and role logs from L1 block
+// fromBlock, and role members are the accounts of those logs that still hold the role at the
+// latest L1 block. Logs are requested in ranges of at most logBlockRange blocks, or all at once
+// if it is zero.
+func (r *RegistryProver) GetRegistryAccess(
+	ctx context.Context,
+	fromBlock uint64,
+	logBlockRange uint64,
+) (*t.RegistryAccess, error) {
+	head, err := r.latestBlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	blockNumber := new(big.Int).SetUint64(head)
+
+	result := &t.RegistryAccess{
+		Registry:    r.registryAddr,
+		FromBlock:   fromBlock,
+		BlockNumber: head,
+		Grantees:    []t.RegistryGrantee{},
+		Roles:       []t.RegistryRole{},
+	}
+	if err := r.callRegistry(ctx, &result.Owner, "owner", blockNumber); err != nil {
+		return nil, err
+	}
+	if err := r.callRegistry(ctx, &result.Paused, "paused", blockNumber); err != nil {
+		return nil, err
+	}
+
+	logs, err := r.filterRegistryLogs(
+		ctx,
+		fromBlock,
+		head,
+		logBlockRange,
+		"NewIrrevocableGrantee",
+		"RoleGranted",
+		"RoleRevoked",
+		"RoleAdminChanged",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Roles and their candidate members, in the order they were first logged
+	var roles []common.Hash
+	candidates := make(map[common.Hash][]common.Address)
+	for _, log := range logs {
+		if log.Topics[0] == r.abi.Events["NewIrrevocableGrantee"].ID {
+			chainID, err := logChainID(log)
+			if err != nil {
+				return nil, err
+			}
+			result.Grantees = append(result.Grantees, t.RegistryGrantee{
+				ChainID:     chainID,
+				Grantee:     common.BytesToAddress(log.Topics[2].Bytes()),
+				BlockNumber: log.BlockNumber,
+				TxHash:      log.TxHash,
+			})
+			continue
+		}
+
+		if len(log.Topics) < 3 {
+			return nil, fmt.Errorf("malformed registry role log in tx %s", log.TxHash)
+		}
+		role := log.Topics[1]
+		if _, ok := candidates[role]; !ok {
+			roles = append(roles, role)
+			candidates[role] = []common.Address{}
+		}
+		if log.Topics[0] == r.abi.Events["RoleAdminChanged"].ID {
+			continue
+		}
+		account := common.BytesToAddress(log.Topics[2].Bytes())
+		if !containsAddress(candidates[role], account) {
+			candidates[role] = append(candidates[role], account)
+		}
+	}
+
+	for _, role := range roles {
+		registryRole := t.RegistryRole{Role: role, Members: []common.Address{}}
+		if role == DefaultAdminRole {
+			registryRole.Name = "DEFAULT_ADMIN_ROLE"
+		}
+		if err := r.callRegistry(ctx, &registryRole.AdminRole, "getRoleAdmin", blockNumber, role); err != nil {
+			return nil, err
+		}
+		for _, account := range candidates[role] {
+			var hasRole bool
+			if err := r.callRegistry(ctx, &hasRole, "hasRole", blockNumber, role, account); err != nil {
+				return nil, err
+			}
+			if hasRole {
+				registryRole.Members = append(registryRole.Members, account)
+			}
+		}
+		result.Roles = append(result.Roles, registryRole)
+	}
+	return result, nil
+}
+
+// latestBlockNumber returns the number of the latest L1 block, which the registry state is read at
+func (r *RegistryProver) latestBlockNumber(ctx context.Context) (uint64, error) {
+	block, err := r.l1Client.BlockByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+	return block.NumberU64(), nil
+}
+
+// filterRegistryLogs returns the registry logs of the given events between L1 blocks fromBlock
+// and toBlock, in ranges of at most blockRange blocks or all at once if it is zero
+func (r *RegistryProver) filterRegistryLogs(
+	ctx context.Context,
+	fromBlock uint64,
+	toBlock uint64,
+	blockRange uint64,
+	events ...string,
+) ([]types.Log, error) {
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("from block %d is after the latest L1 block %d", fromBlock, toBlock)
+	}
+	topics := make([]common.Hash, len(events))
+	for i, event := range events {
+		topics[i] = r.abi.Events[event].ID
+	}
+
+	var logs []types.Log
+	for start := fromBlock; ; {
+		end := toBlock
+		if blockRange > 0 && end-start >= blockRange {
+			end = start + blockRange - 1
+		}
+		rangeLogs, err := r.l1Client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{r.registryAddr},
+			Topics:    [][]common.Hash{topics},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get registry logs of L1 blocks %d to %d: %w", start, end, err)
+		}
+		for _, log := range rangeLogs {
+			if !log.Removed {
+				logs = append(logs, log)
+			}
+		}
+		if end == toBlock {
+			return logs, nil
+		}
+		start = end + 1
+	}
+}
+
+// callRegistry calls a registry view method at blockNumber and unpacks its single result into out
+func (r *RegistryProver) callRegistry(
+	ctx context.Context,
+	out interface{},
+	method string,
+	blockNumber *big.Int,
+	args ...interface{},
+) error {
+	data, err := r.abi.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+	result, err := r.l1Client.CallContract(ctx, ethereum.CallMsg{
+		To:   &r.registryAddr,
+		Data: data,
+	}, blockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	if err := r.abi.UnpackIntoInterface(out, method, result); err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+	return nil
+}
+
+// logChainID returns the indexed chain ID of a registry config or grantee log
+func logChainID(log types.Log) (uint64, error) {
+	if len(log.Topics) != 3 {
+		return 0, fmt.Errorf("malformed registry log in tx %s: expected 3 topics, got %d", log.TxHash, len(log.Topics))
+	}
+	chainID := log.Topics[1].Big()
+	if !chainID.IsUint64() {
+		return 0, fmt.Errorf("registry log in tx %s has chain ID %s out of range", log.TxHash, chainID)
+	}
+	return chainID.Uint64(), nil
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package provers
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
+)
+
+// registryLogClient serves the registry logs within each requested block range and answers
+// registry calls with call, checking they are made at the head block
+func registryLogClient(
+	t *testing.T,
+	head uint64,
+	logs []types.Log,
+	call func(method string, args []interface{}) []interface{},
+) (*testutil.MockEthClient, *[][2]uint64) {
+	registryABI, err := getRegistryABI()
+	require.NoError(t, err)
+
+	var ranges [][2]uint64
+	return &testutil.MockEthClient{
+		BlockByNumberFunc: func(ctx context.Context, number *big.Int) (*types.Block, error) {
+			require.Nil(t, number)
+			return types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(head)}), nil
+		},
+		FilterLogsFunc: func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+			from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+			ranges = append(ranges, [2]uint64{from, to})
+			var inRange []types.Log
+			for _, log := range logs {
+				if log.BlockNumber >= from && log.BlockNumber <= to && containsHash(q.Topics[0], log.Topics[0]) {
+					inRange = append(inRange, log)
+				}
+			}
+			return inRange, nil
+		},
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, new(big.Int).SetUint64(head), blockNumber)
+			method, err := registryABI.MethodById(msg.Data[:4])
+			require.NoError(t, err)
+			args, err := method.Inputs.Unpack(msg.Data[4:])
+			require.NoError(t, err)
+			return method.Outputs.Pack(call(method.Name, args)...)
+		},
+	}, &ranges
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func registryLog(t *testing.T, event string, blockNumber uint64, topics ...common.Hash) types.Log {
+	registryABI, err := getRegistryABI()
+	require.NoError(t, err)
+	return types.Log{
+		Topics:      append([]common.Hash{registryABI.Events[event].ID}, topics...),
+		BlockNumber: blockNumber,
+		TxHash:      common.BigToHash(new(big.Int).SetUint64(blockNumber)),
+	}
+}
+
+func TestRegistryProver_GetRegistryChains(t *testing.T) {
+	registryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	chainID := func(id int64) common.Hash { return common.BigToHash(big.NewInt(id)) }
+	logs := []types.Log{
+		registryLog(t, "L2ChainConfigurationUpdated", 5, chainID(10), common.HexToHash("0x01")),
+		registryLog(t, "L1ChainConfigurationUpdated", 6, chainID(1), common.HexToHash("0x02")),
+		registryLog(t, "NewIrrevocableGrantee", 7, chainID(42), common.HexToHash("0x03")),
+		registryLog(t, "L2ChainConfigurationUpdated", 50, chainID(10), common.HexToHash("0x04")),
+		registryLog(t, "L2ChainConfigurationUpdated", 90, chainID(8453), common.HexToHash("0x05")),
+	}
+	prover := common.HexToAddress("0xabcd")
+	l1Config := types2.L1Configuration{
+		BlockHashOracle:                       common.HexToAddress("0x4200000000000000000000000000000000000015"),
+		SettlementBlocksDelay:                 big.NewInt(25),
+		SettlementRegistry:                    registryAddr,
+		SettlementRegistryL2ConfigMappingSlot: big.NewInt(2),
+		SettlementRegistryL1ConfigMappingSlot: big.NewInt(5),
+	}
+	client, ranges := registryLogClient(t, 100, logs, func(method string, args []interface{}) []interface{} {
+		switch method {
+		case "getL2ConfigType":
+			if args[0].(*big.Int).Int64() == 10 {
+				return []interface{}{uint8(types2.OPStackCannon)}
+			}
+			return []interface{}{uint8(types2.OPStackBedrock)}
+		case "getL2ConfigAddresses":
+			return []interface{}{[]common.Address{common.HexToAddress("0x1111")}}
+		case "getL2ConfigStorageSlots":
+			return []interface{}{[]*big.Int{big.NewInt(3)}}
+		case "l2ChainConfigurations":
+			return []interface{}{prover, big.NewInt(2), big.NewInt(604800), uint8(0)}
+		case "l1ChainConfigurations":
+			return []interface{}{
+				l1Config.BlockHashOracle,
+				l1Config.SettlementBlocksDelay,
+				l1Config.SettlementRegistry,
+				l1Config.SettlementRegistryL2ConfigMappingSlot,
+				l1Config.SettlementRegistryL1ConfigMappingSlot,
+			}
+		}
+		t.Fatalf("unexpected registry call %s", method)
+		return nil
+	})
+
+	registry := NewRegistryProver(client, nil, registryAddr)
+	chains, err := registry.GetRegistryChains(context.Background(), 0, 40)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][2]uint64{{0, 39}, {40, 79}, {80, 100}}, *ranges)
+	assert.Equal(t, registryAddr, chains.Registry)
+	assert.Equal(t, uint64(100), chains.BlockNumber)
+	require.Len(t, chains.Chains, 3)
+
+	l1Chain := chains.Chains[0]
+	assert.Equal(t, uint64(1), l1Chain.ChainID)
+	assert.Nil(t, l1Chain.L2Configuration)
+	assert.Empty(t, l1Chain.L2ConfigurationUpdates)
+	assert.Equal(t, &l1Config, l1Chain.L1Configuration)
+	assert.Equal(t, []types2.RegistryConfigUpdate{
+		{ConfigHash: common.HexToHash("0x02"), BlockNumber: 6, TxHash: logs[1].TxHash},
+	}, l1Chain.L1ConfigurationUpdates)
+
+	l2Chain := chains.Chains[1]
+	assert.Equal(t, uint64(10), l2Chain.ChainID)
+	assert.Equal(t, "OPStackCannon", l2Chain.ConfigType)
+	require.NotNil(t, l2Chain.L2Configuration)
+	assert.Equal(t, prover, l2Chain.L2Configuration.Prover)
+	assert.Equal(t, []common.Address{common.HexToAddress("0x1111")}, l2Chain.L2Configuration.Addresses)
+	assert.Equal(t, "604800", l2Chain.L2Configuration.FinalityDelaySeconds.String())
+	assert.Equal(t, []types2.RegistryConfigUpdate{
+		{ConfigHash: common.HexToHash("0x01"), BlockNumber: 5, TxHash: logs[0].TxHash},
+		{ConfigHash: common.HexToHash("0x04"), BlockNumber: 50, TxHash: logs[3].TxHash},
+	}, l2Chain.L2ConfigurationUpdates)
+	assert.Nil(t, l2Chain.L1Configuration)
+
+	assert.Equal(t, uint64(8453), chains.Chains[2].ChainID)
+	assert.Equal(t, "OPStackBedrock", chains.Chains[2].ConfigType)
+
+	// Blocks past the head have no logs to read
+	_, err = registry.GetRegistryChains(context.Background(), 101, 0)
+	require.ErrorContains(t, err, "from block 101 is after the latest L1 block 100")
+}
+
+func TestRegistryProver_GetRegistryAccess(t *testing.T) {
+	registryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	owner := common.HexToAddress("0x0a")
+	admin := common.HexToAddress("0x0b")
+	revoked := common.HexToAddress("0x0c")
+	operator := common.HexToAddress("0x0d")
+	grantee := common.HexToAddress("0x0e")
+	operatorRole := common.HexToHash("0x0f")
+	logs := []types.Log{
+		registryLog(t, "RoleGranted", 1, DefaultAdminRole, common.BytesToHash(admin.Bytes()), common.Hash{}),
+		registryLog(t, "RoleGranted", 2, operatorRole, common.BytesToHash(revoked.Bytes()), common.Hash{}),
+		registryLog(t, "NewIrrevocableGrantee", 3, common.BigToHash(big.NewInt(10)), common.BytesToHash(grantee.Bytes())),
+		registryLog(t, "RoleRevoked", 4, operatorRole, common.BytesToHash(revoked.Bytes()), common.Hash{}),
+		registryLog(t, "RoleGranted", 5, operatorRole, common.BytesToHash(operator.Bytes()), common.Hash{}),
+		registryLog(t, "L2ChainConfigurationUpdated", 6, common.BigToHash(big.NewInt(10)), common.Hash{}),
+	}
+	client, _ := registryLogClient(t, 10, logs, func(method string, args []interface{}) []interface{} {
+		switch method {
+		case "owner":
+			return []interface{}{owner}
+		case "paused":
+			return []interface{}{true}
+		case "getRoleAdmin":
+			return []interface{}{[32]byte(DefaultAdminRole)}
+		case "hasRole":
+			return []interface{}{args[1].(common.Address) != revoked}
+		}
+		t.Fatalf("unexpected registry call %s", method)
+		return nil
+	})
+
+	access, err := NewRegistryProver(client, nil, registryAddr).GetRegistryAccess(context.Background(), 0, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, owner, access.Owner)
+	assert.True(t, access.Paused)
+	assert.Equal(t, []types2.RegistryGrantee{
+		{ChainID: 10, Grantee: grantee, BlockNumber: 3, TxHash: logs[2].TxHash},
+	}, access.Grantees)
+	assert.Equal(t, []types2.RegistryRole{
+		{Role: DefaultAdminRole, Name: "DEFAULT_ADMIN_ROLE", AdminRole: DefaultAdminRole, Members: []common.Address{admin}},
+		{Role: operatorRole, AdminRole: DefaultAdminRole, Members: []common.Address{operator}},
+	}, access.Roles)
+}
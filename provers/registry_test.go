@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -248,7 +249,7 @@ func TestRegistryProver_GetL2ConfigurationForUpdate(t *testing.T) {
 	prover := NewRegistryProver(mockEthClient, mockRPCClient, registryAddr)
 
 	// Call the method being tested
-	config, err := prover.GetL2ConfigurationForUpdate(context.Background(), chainID)
+	config, err := prover.GetL2ConfigurationForUpdate(context.Background(), chainID, nil)
 	require.NoError(t, err)
 
 	// Verify the results
@@ -271,25 +272,23 @@ func TestRegistryProver_GetRegistryStorageProof(t *testing.T) {
 	chainID := uint64(42161) // Arbitrum One Chain ID
 	registryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
 
-	// Create test storage proof data
-	mockAccountProof := []string{"0xproof1", "0xproof2"}
-	mockStorageProofEntry := types.StorageProofEntry{
-		Key:   common.HexToHash("0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"),
-		Value: (*hexutil.Big)(big.NewInt(0x123)),
-		Proof: []string{"0xsproof1", "0xsproof2"},
-	}
-
-	// Create mock storage proof result that properly implements the type
-	mockProofResult := types.StorageProofResult{
-		Address:      registryAddr,
-		Balance:      (*hexutil.Big)(big.NewInt(100)),
-		CodeHash:     common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"),
-		Nonce:        (*hexutil.Uint64)(new(uint64)),
-		StorageHash:  common.HexToHash("0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"),
-		AccountProof: mockAccountProof,
-		StorageProof: []types.StorageProofEntry{mockStorageProofEntry},
-	}
-	*mockProofResult.Nonce = 1
+	// Build a real L1 state holding the registry's config hash
+	l1State := testutil.NewProofState()
+	l1State.SetAccount(
+		registryAddr,
+		1,
+		big.NewInt(100),
+		common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"),
+	)
+	configSlot := crypto.Keccak256Hash(
+		common.LeftPadBytes(big.NewInt(int64(chainID)).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(2).Bytes(), 32),
+	)
+	l1State.SetStorage(registryAddr, configSlot, common.HexToHash("0x123"))
+	mockProofResult := l1State.GetProofResult(t, registryAddr, configSlot)
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Number = big.NewInt(2)
+	l1Header.Root = l1State.Root(t)
 
 	// Create mock RPC client that implements our IRPCClient
 	mockRPCClient := &testutil.MockRPCClient{
@@ -338,7 +337,7 @@ func TestRegistryProver_GetRegistryStorageProof(t *testing.T) {
 	storageProof, rlpEncodedAccount, accountProof, err := prover.GetRegistryStorageProof(
 		context.Background(),
 		chainID,
-		big.NewInt(2),
+		l1Header,
 	)
 
 	// Verify the results
@@ -390,8 +389,9 @@ func TestRegistryProver_GenerateUpdateL2ConfigArgs(t *testing.T) {
 	// Create mock eth client for contract calls
 	mockEthClient := &testutil.MockEthClient{
 		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
-			// Check that we're calling the right contract
+			// Check that we're calling the right contract at the proven L1 block
 			testutil.RequireAddressEq(t, registryAddr, *msg.To)
+			assert.Equal(t, big.NewInt(2), blockNumber)
 
 			// Determine which method is being called by looking at the first 4 bytes of the calldata
 			methodSig := msg.Data[:4]
@@ -438,25 +438,34 @@ func TestRegistryProver_GenerateUpdateL2ConfigArgs(t *testing.T) {
 		},
 	}
 
-	// Create mock storage proof data
-	mockAccountProof := []string{"0xproof1", "0xproof2"}
-	mockStorageProofEntry := types.StorageProofEntry{
-		Key:   common.HexToHash("0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"),
-		Value: (*hexutil.Big)(big.NewInt(0x123)),
-		Proof: []string{"0xsproof1", "0xsproof2"},
-	}
-
-	// Create mock storage proof result
-	mockProofResult := types.StorageProofResult{
-		Address:      registryAddr,
-		Balance:      (*hexutil.Big)(big.NewInt(100)),
-		CodeHash:     common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"),
-		Nonce:        (*hexutil.Uint64)(new(uint64)),
-		StorageHash:  common.HexToHash("0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef"),
-		AccountProof: mockAccountProof,
-		StorageProof: []types.StorageProofEntry{mockStorageProofEntry},
-	}
-	*mockProofResult.Nonce = 1
+	// Build a real L1 state holding the registry's config hash
+	l1State := testutil.NewProofState()
+	l1State.SetAccount(
+		registryAddr,
+		1,
+		big.NewInt(100),
+		common.HexToHash("0xabcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"),
+	)
+	configSlot := crypto.Keccak256Hash(
+		common.LeftPadBytes(big.NewInt(int64(chainID)).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(2).Bytes(), 32),
+	)
+	encodedConfig, err := abi.Arguments{registryABI.Methods["updateL2ChainConfiguration"].Inputs[1]}.Pack(
+		types.L2Configuration{
+			Prover:               proverAddr,
+			Addresses:            addresses,
+			StorageSlots:         slots,
+			VersionNumber:        versionNumber,
+			FinalityDelaySeconds: finalityDelaySeconds,
+			L2Type:               types.OPStackBedrock,
+		},
+	)
+	require.NoError(t, err)
+	l1State.SetStorage(registryAddr, configSlot, crypto.Keccak256Hash(encodedConfig))
+	mockProofResult := l1State.GetProofResult(t, registryAddr, configSlot)
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Number = big.NewInt(2)
+	l1Header.Root = l1State.Root(t)
 
 	// Create mock RPC client for eth_getProof calls
 	mockRPCClient := &testutil.MockRPCClient{
@@ -501,7 +510,7 @@ func TestRegistryProver_GenerateUpdateL2ConfigArgs(t *testing.T) {
 	prover := NewRegistryProver(mockEthClient, mockRPCClient, registryAddr)
 
 	// Call the method being tested
-	updateArgs, err := prover.GenerateUpdateL2ConfigArgs(context.Background(), chainID, big.NewInt(2))
+	updateArgs, err := prover.GenerateUpdateL2ConfigArgs(context.Background(), chainID, l1Header)
 	require.NoError(t, err)
 
 	// Verify the results
@@ -545,3 +554,156 @@ func TestRegistryProver_GenerateUpdateL2ConfigArgs(t *testing.T) {
 
 	assert.Equal(t, expectedRlpEncodedAccount, updateArgs.RlpEncodedRegistryAccountData)
 }
+
+func TestRegistryProver_GetRegistryStorageProof_MappingSlot(t *testing.T) {
+	chainID := uint64(10)
+	registryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	configSlot := crypto.Keccak256Hash(
+		common.LeftPadBytes(big.NewInt(int64(chainID)).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(7).Bytes(), 32),
+	)
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(registryAddr, configSlot, common.HexToHash("0x123"))
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
+
+	var requested []string
+	prover := NewRegistryProver(nil, &testutil.MockRPCClient{
+		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			requested = args[1].([]string)
+			*(result.(*types.StorageProofResult)) = l1State.GetProofResult(t, registryAddr, common.HexToHash(requested[0]))
+			return nil
+		},
+	}, registryAddr)
+	prover.SetL2ConfigMappingSlot(big.NewInt(7))
+
+	_, _, _, err := prover.GetRegistryStorageProof(context.Background(), chainID, l1Header)
+	require.NoError(t, err)
+	assert.Equal(t, []string{configSlot.Hex()}, requested)
+}
+
+func TestRegistryProver_GenerateUpdateL1ConfigArgs(t *testing.T) {
+	chainID := uint64(1)
+	registryAddr := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	config := types.L1Configuration{
+		BlockHashOracle:                       common.HexToAddress("0x4200000000000000000000000000000000000015"),
+		SettlementBlocksDelay:                 big.NewInt(25),
+		SettlementRegistry:                    registryAddr,
+		SettlementRegistryL2ConfigMappingSlot: big.NewInt(2),
+		SettlementRegistryL1ConfigMappingSlot: big.NewInt(5),
+	}
+	configSlot := crypto.Keccak256Hash(
+		common.LeftPadBytes(big.NewInt(int64(chainID)).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(5).Bytes(), 32),
+	)
+	// The static config tuple encodes as one word per field
+	configHash := crypto.Keccak256Hash(
+		common.LeftPadBytes(config.BlockHashOracle.Bytes(), 32),
+		common.LeftPadBytes(config.SettlementBlocksDelay.Bytes(), 32),
+		common.LeftPadBytes(config.SettlementRegistry.Bytes(), 32),
+		common.LeftPadBytes(config.SettlementRegistryL2ConfigMappingSlot.Bytes(), 32),
+		common.LeftPadBytes(config.SettlementRegistryL1ConfigMappingSlot.Bytes(), 32),
+	)
+	l1State := testutil.NewProofState()
+	l1State.SetStorage(registryAddr, configSlot, configHash)
+	l1Header := testutil.CreateTestHeader(t)
+	l1Header.Root = l1State.Root(t)
+
+	registryABI, err := getRegistryABI()
+	require.NoError(t, err)
+	method := registryABI.Methods["l1ChainConfigurations"]
+	l1Client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			require.Equal(t, registryAddr, *msg.To)
+			require.Equal(t, method.ID, msg.Data[:4])
+			require.Equal(t, l1Header.Number, blockNumber)
+			return method.Outputs.Pack(
+				config.BlockHashOracle,
+				config.SettlementBlocksDelay,
+				config.SettlementRegistry,
+				config.SettlementRegistryL2ConfigMappingSlot,
+				config.SettlementRegistryL1ConfigMappingSlot,
+			)
+		},
+	}
+	var requested []string
+	l1RPC := &testutil.MockRPCClient{
+		CallContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			requested = args[1].([]string)
+			*(result.(*types.StorageProofResult)) = l1State.GetProofResult(t, registryAddr, common.HexToHash(requested[0]))
+			return nil
+		},
+	}
+	prover := NewRegistryProver(l1Client, l1RPC, registryAddr)
+
+	// The L1 config mapping slot has no default
+	_, err = prover.GenerateUpdateL1ConfigArgs(context.Background(), chainID, l1Header)
+	require.ErrorContains(t, err, "registry L1 config mapping slot is not set")
+
+	prover.SetL1ConfigMappingSlot(big.NewInt(5))
+	args, err := prover.GenerateUpdateL1ConfigArgs(context.Background(), chainID, l1Header)
+	require.NoError(t, err)
+	assert.Equal(t, config, args.Config)
+	assert.Equal(t, []string{configSlot.Hex()}, requested)
+	storageProof, rlpEncodedAccount, accountProof := l1State.ProofBytes(t, registryAddr, configSlot)
+	assert.Equal(t, storageProof, args.L1StorageProof)
+	assert.Equal(t, rlpEncodedAccount, args.RlpEncodedRegistryAccountData)
+	assert.Equal(t, accountProof, args.L1RegistryProof)
+
+	// A configuration that does not hash to the proven value fails fast
+	l1State.SetStorage(registryAddr, configSlot, common.HexToHash("0x123"))
+	l1Header.Root = l1State.Root(t)
+	_, err = prover.GenerateUpdateL1ConfigArgs(context.Background(), chainID, l1Header)
+	var mismatch *ConfigHashMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "L1", mismatch.Config)
+	assert.Equal(t, configHash, mismatch.Computed)
+	assert.Equal(t, common.HexToHash("0x123"), mismatch.Proven)
+	assert.Equal(t, l1Header.Number, mismatch.L1BlockNumber)
+}
+
+func TestParseL2Type(t *testing.T) {
+	for _, l2Type := range []types.L2Type{types.OPStackBedrock, types.OPStackCannon, types.Nitro} {
+		parsed, err := ParseL2Type(strings.ToLower(ConfigTypeName(l2Type)))
+		require.NoError(t, err)
+		assert.Equal(t, l2Type, parsed)
+	}
+	_, err := ParseL2Type("Unknown")
+	require.ErrorContains(t, err, `unknown L2 type "Unknown"`)
+}
+
+func TestRegistryProver_EncodeUpdateL2ChainConfigurationCalldata(t *testing.T) {
+	config := &types.L2Configuration{
+		Prover:               common.HexToAddress("0xaa"),
+		Addresses:            []common.Address{common.HexToAddress("0xbb")},
+		StorageSlots:         []*big.Int{big.NewInt(0x68)},
+		VersionNumber:        big.NewInt(4),
+		FinalityDelaySeconds: big.NewInt(302400),
+		L2Type:               types.OPStackCannon,
+	}
+	calldata, err := NewRegistryProver(nil, nil, common.Address{}).EncodeUpdateL2ChainConfigurationCalldata(10, config)
+	require.NoError(t, err)
+
+	registryABI, err := getRegistryABI()
+	require.NoError(t, err)
+	method := registryABI.Methods["updateL2ChainConfiguration"]
+	require.Equal(t, method.ID, calldata[:4])
+	args, err := method.Inputs.Unpack(calldata[4:])
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), args[0])
+	var decoded struct {
+		Prover               common.Address
+		Addresses            []common.Address
+		StorageSlots         []*big.Int
+		VersionNumber        *big.Int
+		FinalityDelaySeconds *big.Int
+		L2Type               uint8
+	}
+	abi.ConvertType(args[1], &decoded)
+	assert.Equal(t, config.Prover, decoded.Prover)
+	assert.Equal(t, config.Addresses, decoded.Addresses)
+	assert.Equal(t, config.StorageSlots, decoded.StorageSlots)
+	assert.Equal(t, config.VersionNumber, decoded.VersionNumber)
+	assert.Equal(t, config.FinalityDelaySeconds, decoded.FinalityDelaySeconds)
+	assert.Equal(t, uint8(types.OPStackCannon), decoded.L2Type)
+}
@@ -0,0 +1,324 @@
+package provers
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// revertABIs lists the bundled ABIs searched for custom errors, in match order
+var revertABIs = []string{
+	"NativeProver",
+	"OPStackCannonProver",
+	"OPStackBedrockProver",
+	"Registry",
+}
+
+// HintedError is implemented by decoded revert errors that can suggest a remediation
+type HintedError interface {
+	error
+	Hint() string
+}
+
+// NeedLaterBlockError is the NativeProver NeedLaterBlock revert: the L1 block used for the
+// proof is not yet past the settlement delay
+type NeedLaterBlockError struct {
+	InputBlockNumber        *big.Int
+	NextProvableBlockNumber *big.Int
+}
+
+func (e *NeedLaterBlockError) Error() string {
+	return fmt.Sprintf(
+		"NeedLaterBlock: L1 block %s is before the next provable block %s",
+		e.InputBlockNumber,
+		e.NextProvableBlockNumber,
+	)
+}
+
+func (e *NeedLaterBlockError) Hint() string {
+	return fmt.Sprintf(
+		"wait for the destination L2 to have an L1 origin at or after block %s, then regenerate the proof",
+		e.NextProvableBlockNumber,
+	)
+}
+
+// OutdatedBlockError is the NativeProver OutdatedBlock revert: a state at a newer block
+// has already been proven
+type OutdatedBlockError struct {
+	InputBlockNumber  *big.Int
+	LatestBlockNumber *big.Int
+}
+
+func (e *OutdatedBlockError) Error() string {
+	return fmt.Sprintf(
+		"OutdatedBlock: block %s is older than the latest proven block %s",
+		e.InputBlockNumber,
+		e.LatestBlockNumber,
+	)
+}
+
+func (e *OutdatedBlockError) Hint() string {
+	return fmt.Sprintf(
+		"a newer state is already proven; regenerate the proof against an L1 origin newer than block %s",
+		e.LatestBlockNumber,
+	)
+}
+
+// InvalidSettledStateProofError is the NativeProver InvalidSettledStateProof revert: the
+// settled state prover rejected the proof linking the L2 state root to L1
+type InvalidSettledStateProofError struct {
+	ChainID          *big.Int
+	L2WorldStateRoot common.Hash
+}
+
+func (e *InvalidSettledStateProofError) Error() string {
+	return fmt.Sprintf(
+		"InvalidSettledStateProof: chain %s state root %s is not proven settled",
+		e.ChainID,
+		e.L2WorldStateRoot.Hex(),
+	)
+}
+
+func (e *InvalidSettledStateProofError) Hint() string {
+	return "check that the source L2 RPC is synced and that the selected dispute game or output is " +
+		"resolved and matches the registry configuration, then regenerate the proof"
+}
+
+// FaultDisputeGameUnresolvedError is the OPStackCannonProver FaultDisputeGameUnresolved
+// revert: the proven game did not resolve in favour of the defender
+type FaultDisputeGameUnresolvedError struct {
+	GameStatus GameStatus
+}
+
+func (e *FaultDisputeGameUnresolvedError) Error() string {
+	return fmt.Sprintf("FaultDisputeGameUnresolved: dispute game status is %s", e.GameStatus)
+}
+
+func (e *FaultDisputeGameUnresolvedError) Hint() string {
+	return fmt.Sprintf(
+		"only games resolved as %s can be proven; wait for the game to resolve or use an older resolved game",
+		GameStatusDefenderWins,
+	)
+}
+
+// BlockBeforeFinalityPeriodError is the OPStackBedrockProver BlockBeforeFinalityPeriod revert:
+// the proven output is still inside the chain's finality delay
+type BlockBeforeFinalityPeriodError struct {
+	BlockTimestamp         *big.Int
+	FinalityDelayTimestamp *big.Int
+}
+
+func (e *BlockBeforeFinalityPeriodError) Error() string {
+	return fmt.Sprintf(
+		"BlockBeforeFinalityPeriod: output timestamp %s is not final until %s",
+		e.BlockTimestamp,
+		e.FinalityDelayTimestamp,
+	)
+}
+
+func (e *BlockBeforeFinalityPeriodError) Hint() string {
+	final := time.Unix(e.FinalityDelayTimestamp.Int64(), 0).UTC()
+	return fmt.Sprintf(
+		"wait until the L1 origin is past %s (%s) or prove against an older output",
+		e.FinalityDelayTimestamp,
+		final.Format(time.RFC3339),
+	)
+}
+
+// ErrorArg is a decoded custom error argument
+type ErrorArg struct {
+	Name  string
+	Value interface{}
+}
+
+// ContractError is a custom error declared in a bundled ABI without a dedicated Go type
+type ContractError struct {
+	Contract string
+	Name     string
+	Args     []ErrorArg
+	hint     string
+}
+
+func (e *ContractError) Error() string {
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = fmt.Sprintf("%s: %s", arg.Name, formatErrorArg(arg.Value))
+	}
+	return fmt.Sprintf("%s.%s(%s)", e.Contract, e.Name, strings.Join(args, ", "))
+}
+
+func (e *ContractError) Hint() string {
+	return e.hint
+}
+
+// ReasonRevertError is a revert with a Solidity Error(string) or Panic(uint256) payload
+type ReasonRevertError struct {
+	Reason string
+}
+
+func (e *ReasonRevertError) Error() string {
+	return fmt.Sprintf("execution reverted: %s", e.Reason)
+}
+
+// UnknownRevertError is a revert payload that matches none of the bundled ABIs
+type UnknownRevertError struct {
+	Data []byte
+}
+
+func (e *UnknownRevertError) Error() string {
+	if len(e.Data) == 0 {
+		return "execution reverted without data"
+	}
+	return fmt.Sprintf("unknown revert data %s", hexutil.Encode(e.Data))
+}
+
+func (e *UnknownRevertError) Hint() string {
+	if len(e.Data) < 4 {
+		return "the node returned no revert selector; retry against a node that returns revert data"
+	}
+	return fmt.Sprintf(
+		"selector %s is not declared in any bundled ABI; the call may have reverted in another contract",
+		hexutil.Encode(e.Data[:4]),
+	)
+}
+
+// contractErrorHints holds remediation hints for custom errors without a dedicated Go type
+var contractErrorHints = map[string]string{
+	"InvalidStorageProof": "a storage proof does not verify against its root; the RPC node may have " +
+		"served a proof for a different block",
+	"InvalidAccountProof": "an account proof does not verify against its state root; the RPC node may have " +
+		"served a proof for a different block",
+	"IncorrectContractStorageRoot": "the proven contract account has a different storage root; " +
+		"regenerate the proof against a single block",
+	"InvalidRLPEncodedBlock": "the RLP encoded header does not hash to the expected block hash; " +
+		"the L1 origin may have changed, regenerate the proof with --wait-for-new-epoch",
+	"DestinationChainStateRootNotProved": "the L2 state root used by the proof is not the one proven " +
+		"by the settled state proof",
+	"SettlementChainStateRootNotProven": "the L1 state root does not match the L1 block hash oracle; " +
+		"regenerate the proof against the current L1 origin",
+	"InvalidL2ConfigurationProof": "the L2 configuration does not match the registry at the L1 block; " +
+		"check --l1-registry-address and regenerate the proof",
+	"InvalidL1ConfigurationProof": "the L1 configuration does not match the registry at the L1 block; " +
+		"check --l1-registry-address and regenerate the proof",
+	"InvalidBedrockProof": "the output root does not commit to the L2 state; check the source L2 RPC is synced",
+	"InvalidCannonProof":  "the game root claim does not commit to the L2 state; check the source L2 RPC is synced",
+	"IncorrectOutputOracleStorageRoot": "the L2OutputOracle account proof does not match the L1 state root; " +
+		"check the registry configuration for the source chain",
+	"IncorrectDisputeGameFactoryStateRoot": "the DisputeGameFactory account proof does not match the L1 state root; " +
+		"check the registry configuration for the source chain",
+	"InvalidRange": "the start chain ID must not be greater than the stop chain ID",
+}
+
+type namedABI struct {
+	name string
+	abi  abi.ABI
+}
+
+// RevertDecoder matches revert payloads against the custom errors of every bundled ABI
+type RevertDecoder struct {
+	abis []namedABI
+}
+
+// NewRevertDecoder creates a RevertDecoder loaded with the bundled ABIs
+func NewRevertDecoder() (*RevertDecoder, error) {
+	decoder := &RevertDecoder{}
+	for _, name := range revertABIs {
+		parsed, err := getBundledABI(name)
+		if err != nil {
+			return nil, err
+		}
+		decoder.abis = append(decoder.abis, namedABI{name: name, abi: parsed})
+	}
+	return decoder, nil
+}
+
+// Decode turns a revert payload into a Go error. Known custom errors are returned as their
+// typed errors, other bundled custom errors as *ContractError, Error(string) and Panic(uint256)
+// as *ReasonRevertError and anything else as *UnknownRevertError.
+func (d *RevertDecoder) Decode(data []byte) error {
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return &ReasonRevertError{Reason: reason}
+	}
+	if len(data) < 4 {
+		return &UnknownRevertError{Data: data}
+	}
+
+	var selector [4]byte
+	copy(selector[:], data[:4])
+	for _, named := range d.abis {
+		abiErr, err := named.abi.ErrorByID(selector)
+		if err != nil {
+			continue
+		}
+		values, err := abiErr.Inputs.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		return newDecodedError(named.name, abiErr, values)
+	}
+	return &UnknownRevertError{Data: data}
+}
+
+// newDecodedError builds the typed error for a decoded custom error
+func newDecodedError(contract string, abiErr *abi.Error, values []interface{}) error {
+	switch abiErr.Sig {
+	case "NeedLaterBlock(uint256,uint256)":
+		return &NeedLaterBlockError{
+			InputBlockNumber:        values[0].(*big.Int),
+			NextProvableBlockNumber: values[1].(*big.Int),
+		}
+	case "OutdatedBlock(uint256,uint256)":
+		return &OutdatedBlockError{
+			InputBlockNumber:  values[0].(*big.Int),
+			LatestBlockNumber: values[1].(*big.Int),
+		}
+	case "InvalidSettledStateProof(uint256,bytes32)":
+		return &InvalidSettledStateProofError{
+			ChainID:          values[0].(*big.Int),
+			L2WorldStateRoot: values[1].([32]byte),
+		}
+	case "FaultDisputeGameUnresolved(uint8)":
+		return &FaultDisputeGameUnresolvedError{GameStatus: GameStatus(values[0].(uint8))}
+	case "BlockBeforeFinalityPeriod(uint256,uint256)":
+		return &BlockBeforeFinalityPeriodError{
+			BlockTimestamp:         values[0].(*big.Int),
+			FinalityDelayTimestamp: values[1].(*big.Int),
+		}
+	}
+
+	args := make([]ErrorArg, len(values))
+	for i, value := range values {
+		args[i] = ErrorArg{Name: abiErr.Inputs[i].Name, Value: value}
+	}
+	return &ContractError{
+		Contract: contract,
+		Name:     abiErr.Name,
+		Args:     args,
+		hint:     contractErrorHints[abiErr.Name],
+	}
+}
+
+// formatErrorArg renders a decoded ABI value, hex encoding byte types
+func formatErrorArg(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return hexutil.Encode(v)
+	case [32]byte:
+		return common.Hash(v).Hex()
+	case [][]byte:
+		encoded := make([]string, len(v))
+		for i, b := range v {
+			encoded[i] = hexutil.Encode(b)
+		}
+		return "[" + strings.Join(encoded, ", ") + "]"
+	case common.Address:
+		return v.Hex()
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
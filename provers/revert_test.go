@@ -0,0 +1,118 @@
+package provers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// packRevert builds a custom error revert payload from its signature and arguments
+func packRevert(t *testing.T, contract string, name string, args ...interface{}) []byte {
+	parsed, err := getBundledABI(contract)
+	require.NoError(t, err)
+	abiErr, ok := parsed.Errors[name]
+	require.True(t, ok, "error %s not found in %s", name, contract)
+
+	encoded, err := abiErr.Inputs.Pack(args...)
+	require.NoError(t, err)
+	return append(common.CopyBytes(abiErr.ID[:4]), encoded...)
+}
+
+func TestRevertDecoder_Decode(t *testing.T) {
+	decoder, err := NewRevertDecoder()
+	require.NoError(t, err)
+
+	t.Run("NeedLaterBlock", func(t *testing.T) {
+		err := decoder.Decode(packRevert(t, "NativeProver", "NeedLaterBlock", big.NewInt(100), big.NewInt(164)))
+		var typed *NeedLaterBlockError
+		require.ErrorAs(t, err, &typed)
+		assert.Equal(t, big.NewInt(100), typed.InputBlockNumber)
+		assert.Equal(t, big.NewInt(164), typed.NextProvableBlockNumber)
+		assert.Contains(t, typed.Hint(), "at or after block 164")
+	})
+
+	t.Run("OutdatedBlock", func(t *testing.T) {
+		err := decoder.Decode(packRevert(t, "NativeProver", "OutdatedBlock", big.NewInt(100), big.NewInt(120)))
+		var typed *OutdatedBlockError
+		require.ErrorAs(t, err, &typed)
+		assert.Equal(t, big.NewInt(120), typed.LatestBlockNumber)
+		assert.Contains(t, typed.Hint(), "newer than block 120")
+	})
+
+	t.Run("InvalidSettledStateProof", func(t *testing.T) {
+		root := common.HexToHash("0xabcd")
+		err := decoder.Decode(packRevert(t, "NativeProver", "InvalidSettledStateProof", big.NewInt(10), root))
+		var typed *InvalidSettledStateProofError
+		require.ErrorAs(t, err, &typed)
+		assert.Equal(t, big.NewInt(10), typed.ChainID)
+		assert.Equal(t, root, typed.L2WorldStateRoot)
+	})
+
+	t.Run("FaultDisputeGameUnresolved", func(t *testing.T) {
+		err := decoder.Decode(packRevert(t, "OPStackCannonProver", "FaultDisputeGameUnresolved", uint8(1)))
+		var typed *FaultDisputeGameUnresolvedError
+		require.ErrorAs(t, err, &typed)
+		assert.Equal(t, GameStatusChallengerWins, typed.GameStatus)
+		assert.Contains(t, err.Error(), "CHALLENGER_WINS")
+	})
+
+	t.Run("BlockBeforeFinalityPeriod", func(t *testing.T) {
+		err := decoder.Decode(packRevert(
+			t,
+			"OPStackBedrockProver",
+			"BlockBeforeFinalityPeriod",
+			big.NewInt(1700000000),
+			big.NewInt(1700604800),
+		))
+		var typed *BlockBeforeFinalityPeriodError
+		require.ErrorAs(t, err, &typed)
+		assert.Equal(t, big.NewInt(1700604800), typed.FinalityDelayTimestamp)
+		assert.Contains(t, typed.Hint(), "2023-11-21T22:13:20Z")
+	})
+
+	t.Run("ContractError", func(t *testing.T) {
+		err := decoder.Decode(packRevert(
+			t,
+			"NativeProver",
+			"InvalidRLPEncodedBlock",
+			common.HexToHash("0x01"),
+			common.HexToHash("0x02"),
+		))
+		var typed *ContractError
+		require.ErrorAs(t, err, &typed)
+		assert.Equal(t, "NativeProver", typed.Contract)
+		assert.Equal(t, "InvalidRLPEncodedBlock", typed.Name)
+		require.Len(t, typed.Args, 2)
+		assert.Equal(t, "_expectedBlockHash", typed.Args[0].Name)
+		assert.Contains(t, err.Error(), common.HexToHash("0x02").Hex())
+		assert.NotEmpty(t, typed.Hint())
+	})
+
+	t.Run("ErrorString", func(t *testing.T) {
+		stringType, err := abi.NewType("string", "", nil)
+		require.NoError(t, err)
+		encoded, err := abi.Arguments{{Type: stringType}}.Pack("Ownable: caller is not the owner")
+		require.NoError(t, err)
+		data := append(crypto.Keccak256([]byte("Error(string)"))[:4], encoded...)
+
+		var typed *ReasonRevertError
+		require.ErrorAs(t, decoder.Decode(data), &typed)
+		assert.Equal(t, "Ownable: caller is not the owner", typed.Reason)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		data := hexutil.MustDecode("0xdeadbeef")
+		var typed *UnknownRevertError
+		require.ErrorAs(t, decoder.Decode(data), &typed)
+		assert.Contains(t, typed.Hint(), "0xdeadbeef")
+
+		require.ErrorAs(t, decoder.Decode(nil), &typed)
+		assert.Equal(t, "execution reverted without data", typed.Error())
+	})
+}
@@ -0,0 +1,120 @@
+package provers
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// BedrockSettledStateProof is the RLP list an OP Stack Bedrock settled state proof encodes
+type BedrockSettledStateProof struct {
+	MessagePasserStateRoot     common.Hash
+	OutputIndex                []byte
+	L1StorageProof             [][]byte
+	RlpEncodedOutputOracleData []byte
+	L1AccountProof             [][]byte
+}
+
+// DecodeBedrockSettledStateProof decodes a settled state proof generated by OPStackBedrockProver
+func DecodeBedrockSettledStateProof(proof []byte) (*BedrockSettledStateProof, error) {
+	var decoded BedrockSettledStateProof
+	if err := rlp.DecodeBytes(proof, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Bedrock settled state proof: %w", err)
+	}
+	return &decoded, nil
+}
+
+// NitroSettledStateProof is the RLP list an Arbitrum Nitro settled state proof encodes. It holds
+// the account proof of the rollup against the L1 state root, the storage proofs of
+// _latestConfirmed and of the node's confirmData against the rollup's storage root, and the
+// SendRoot that, with the L2 block hash, hashes to confirmData. No deployed verifier checks this
+// encoding yet, see NitroUnverifiedEncodingError.
+type NitroSettledStateProof struct {
+	SendRoot common.Hash
+	// NodeNum is the confirmed node number, left padded to 32 bytes
+	NodeNum                     []byte
+	LatestConfirmedSlotValue    common.Hash
+	LatestConfirmedStorageProof [][]byte
+	ConfirmDataStorageProof     [][]byte
+	RlpEncodedRollupData        []byte
+	RollupAccountProof          [][]byte
+}
+
+// DecodeNitroSettledStateProof decodes a settled state proof generated by ArbitrumNitroProver
+func DecodeNitroSettledStateProof(proof []byte) (*NitroSettledStateProof, error) {
+	var decoded NitroSettledStateProof
+	if err := rlp.DecodeBytes(proof, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode Nitro settled state proof: %w", err)
+	}
+	return &decoded, nil
+}
+
+// DecodeCannonSettledStateProof decodes a settled state proof generated by OPStackCannonProver
+func DecodeCannonSettledStateProof(proof []byte) (*DisputeGameFactoryProof, *FaultDisputeGameProof, error) {
+	values, err := EncodedOpstackCannonProof.Unpack(proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode Cannon settled state proof: %w", err)
+	}
+	factoryData := *abi.ConvertType(values[0], new(DisputeGameFactoryProof)).(*DisputeGameFactoryProof)
+	faultData := *abi.ConvertType(values[1], new(FaultDisputeGameProof)).(*FaultDisputeGameProof)
+	return &factoryData, &faultData, nil
+}
+
+// SettledOutputRoot returns the output root an OP Stack settled state proof was generated for,
+// rebuilt from the message passer root it carries and the settled L2 header. It returns false
+// for chain types without output roots.
+func SettledOutputRoot(configType string, proof []byte, l2Header *types.Header) (common.Hash, bool, error) {
+	switch configType {
+	case "OPStackBedrock":
+		decoded, err := DecodeBedrockSettledStateProof(proof)
+		if err != nil {
+			return common.Hash{}, false, err
+		}
+		return ComputeOutputRootV0(l2Header.Root, decoded.MessagePasserStateRoot, l2Header.Hash()), true, nil
+	case "OPStackCannon":
+		factoryData, _, err := DecodeCannonSettledStateProof(proof)
+		if err != nil {
+			return common.Hash{}, false, err
+		}
+		return ComputeOutputRootV0(l2Header.Root, factoryData.MessagePasserStateRoot, factoryData.LatestBlockHash), true, nil
+	}
+	return common.Hash{}, false, nil
+}
+
+// EncodeSettledStateProof decodes a settled state proof and encodes it again, so that a saved
+// proof is repacked by the current encoder. Proofs of chain types without a decoder are
+// returned as they are.
+func EncodeSettledStateProof(configType string, proof []byte) ([]byte, error) {
+	switch configType {
+	case "OPStackBedrock":
+		decoded, err := DecodeBedrockSettledStateProof(proof)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := rlp.EncodeToBytes(decoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode Bedrock settled state proof: %w", err)
+		}
+		return encoded, nil
+	case "OPStackCannon":
+		factoryData, faultData, err := DecodeCannonSettledStateProof(proof)
+		if err != nil {
+			return nil, err
+		}
+		return encodeCannonProof(*factoryData, *faultData)
+	case "Arbitrum":
+		decoded, err := DecodeNitroSettledStateProof(proof)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := rlp.EncodeToBytes(decoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode Nitro settled state proof: %w", err)
+		}
+		return encoded, nil
+	}
+	return proof, nil
+}
@@ -0,0 +1,72 @@
+package provers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/polymerdao/fallback_prover/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCannonSettledStateProof(t *testing.T) {
+	disputeGameAddr := common.HexToAddress("0xabcdef1234567890abcdef1234567890abcdef12")
+	messagePasserRoot := common.HexToHash("0xfedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321")
+	l2Header := testutil.CreateTestHeader(t)
+
+	factoryData := DisputeGameFactoryProof{
+		MessagePasserStateRoot:           messagePasserRoot,
+		LatestBlockHash:                  l2Header.Hash(),
+		GameIndex:                        big.NewInt(7),
+		GameId:                           packGameId(0, 1650000000, disputeGameAddr),
+		DisputeFaultGameStorageProof:     [][]byte{{0x01}, {0x02}},
+		RlpEncodedDisputeGameFactoryData: []byte{0x03},
+		DisputeGameFactoryAccountProof:   [][]byte{{0x04}},
+	}
+	faultData := FaultDisputeGameProof{
+		FaultDisputeGameStateRoot:             common.HexToHash("0xabcdef"),
+		FaultDisputeGameRootClaimStorageProof: [][]byte{{0x05}},
+		FaultDisputeGameStatusSlotData: FaultDisputeGameStatusSlot{
+			CreatedAt:   1650000000,
+			ResolvedAt:  1650001000,
+			GameStatus:  uint8(GameStatusDefenderWins),
+			Initialized: true,
+		},
+		FaultDisputeGameStatusStorageProof: [][]byte{{0x06}},
+		RlpEncodedFaultDisputeGameData:     []byte{0x07},
+		FaultDisputeGameAccountProof:       [][]byte{{0x08}},
+	}
+	proof, err := encodeCannonProof(factoryData, faultData)
+	require.NoError(t, err)
+
+	decodedFactoryData, decodedFaultData, err := DecodeCannonSettledStateProof(proof)
+	require.NoError(t, err)
+	assert.Equal(t, factoryData, *decodedFactoryData)
+	assert.Equal(t, faultData, *decodedFaultData)
+
+	// Re-encoding the decoded proof reproduces it byte for byte
+	reencoded, err := EncodeSettledStateProof("OPStackCannon", proof)
+	require.NoError(t, err)
+	assert.Equal(t, proof, reencoded)
+
+	settledRoot, ok, err := SettledOutputRoot("OPStackCannon", proof, l2Header)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ComputeOutputRootV0(l2Header.Root, messagePasserRoot, l2Header.Hash()), settledRoot)
+}
+
+func TestSettledOutputRoot(t *testing.T) {
+	l2Header := testutil.CreateTestHeader(t)
+
+	_, ok, err := SettledOutputRoot("ArbitrumNitro", []byte("proof"), l2Header)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = SettledOutputRoot("OPStackBedrock", []byte("proof"), l2Header)
+	assert.ErrorContains(t, err, "failed to decode Bedrock settled state proof")
+
+	_, _, err = SettledOutputRoot("OPStackCannon", []byte("proof"), l2Header)
+	assert.ErrorContains(t, err, "failed to decode Cannon settled state proof")
+}
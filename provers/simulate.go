@@ -0,0 +1,102 @@
+package provers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	t "github.com/polymerdao/fallback_prover/types"
+)
+
+// RevertError is returned when simulating prove calldata reverts on the destination NativeProver
+type RevertError struct {
+	// Data is the raw revert data returned by the node, if any
+	Data []byte
+	// Message is the error message returned by the node
+	Message string
+	// Decoded is the revert data matched against the bundled ABIs, see RevertDecoder
+	Decoded error
+}
+
+func (e *RevertError) Error() string {
+	if e.Decoded != nil {
+		if _, unknown := e.Decoded.(*UnknownRevertError); !unknown {
+			return fmt.Sprintf("execution reverted: %v", e.Decoded)
+		}
+	}
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("%s (revert data %s)", e.Message, hexutil.Encode(e.Data))
+	}
+	return e.Message
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.Decoded
+}
+
+// Simulate runs the prove calldata against the NativeProver at nativeProverAddress with
+// eth_call and decodes the returned values. A revert is reported as a *RevertError.
+func (np *NativeProver) Simulate(
+	ctx context.Context,
+	client IEthClient,
+	nativeProverAddress common.Address,
+	calldata []byte,
+) (*t.ProveResult, error) {
+	output, err := client.CallContract(ctx, ethereum.CallMsg{
+		To:   &nativeProverAddress,
+		Data: calldata,
+	}, nil)
+	if err != nil {
+		if revertErr := np.revertError(err); revertErr != nil {
+			return nil, revertErr
+		}
+		return nil, fmt.Errorf("failed to call NativeProver: %w", err)
+	}
+
+	return np.DecodeProveResult(calldata, output)
+}
+
+// revertError converts an RPC error carrying revert data into a *RevertError,
+// returning nil for any other error
+func (np *NativeProver) revertError(err error) *RevertError {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil
+	}
+	revertErr := &RevertError{Message: dataErr.Error()}
+	if data, ok := dataErr.ErrorData().(string); ok {
+		revertErr.Data, _ = hexutil.Decode(data)
+	}
+	revertErr.Decoded = np.revertDecoder.Decode(revertErr.Data)
+	return revertErr
+}
+
+// DecodeProveResult decodes the values returned by the proveNative or proveL1Native
+// call encoded in calldata
+func (np *NativeProver) DecodeProveResult(calldata []byte, output []byte) (*t.ProveResult, error) {
+	if len(calldata) < 4 {
+		return nil, fmt.Errorf("calldata too short: %d bytes", len(calldata))
+	}
+	method, err := np.abi.MethodById(calldata[:4])
+	if err != nil {
+		return nil, fmt.Errorf("failed to find NativeProver method: %w", err)
+	}
+	if method.Name != "proveNative" && method.Name != "proveL1Native" {
+		return nil, fmt.Errorf("calldata is for %s, not a prove function", method.Name)
+	}
+
+	values, err := method.Outputs.Unpack(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s result: %w", method.Name, err)
+	}
+	var result t.ProveResult
+	if err := method.Outputs.Copy(&result, values); err != nil {
+		return nil, fmt.Errorf("failed to decode %s result: %w", method.Name, err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,74 @@
+package provers
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/polymerdao/fallback_prover/testutil"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+func TestNativeProver_Simulate(t *testing.T) {
+	prover, err := NewNativeProver()
+	require.NoError(t, err)
+
+	proveArgs := types.ProveScalarArgs{
+		ChainID:      big.NewInt(10),
+		ContractAddr: common.HexToAddress("0x1234"),
+		StorageSlot:  common.HexToHash("0x5678"),
+		StorageValue: common.HexToHash("0x9abc"),
+	}
+	calldata, err := prover.EncodeProveNativeCalldata(
+		types.UpdateL2ConfigArgs{Config: types.L2Configuration{
+			VersionNumber:        big.NewInt(1),
+			FinalityDelaySeconds: big.NewInt(0),
+		}},
+		proveArgs, nil, nil, nil, nil, nil, nil,
+	)
+	require.NoError(t, err)
+
+	output, err := prover.abi.Methods["proveNative"].Outputs.Pack(
+		proveArgs.ChainID,
+		proveArgs.ContractAddr,
+		proveArgs.StorageSlot,
+		proveArgs.StorageValue,
+	)
+	require.NoError(t, err)
+
+	client := &testutil.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+			return output, nil
+		},
+	}
+	result, err := prover.Simulate(context.Background(), client, common.HexToAddress("0x1"), calldata)
+	require.NoError(t, err)
+	assert.Equal(t, proveArgs.ChainID, result.ChainID)
+	assert.Equal(t, proveArgs.ContractAddr, result.StoringContract)
+	assert.Equal(t, proveArgs.StorageSlot, result.StorageSlot)
+	assert.Equal(t, proveArgs.StorageValue, result.StorageValue)
+
+	// Transport errors are not reverts
+	client.CallContractFunc = func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+		return nil, errors.New("connection refused")
+	}
+	_, err = prover.Simulate(context.Background(), client, common.HexToAddress("0x1"), calldata)
+	var revertErr *RevertError
+	assert.False(t, errors.As(err, &revertErr))
+}
+
+func TestNativeProver_DecodeProveResult_NotProve(t *testing.T) {
+	prover, err := NewNativeProver()
+	require.NoError(t, err)
+
+	calldata, err := prover.abi.Pack("owner")
+	require.NoError(t, err)
+	_, err = prover.DecodeProveResult(calldata, nil)
+	require.ErrorContains(t, err, "not a prove function")
+}
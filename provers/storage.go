@@ -67,6 +67,35 @@ func (s *StorageProver) GetStorageProof(
 	return &result, nil
 }
 
+// GetStorageProofs retrieves the proofs for several storage slots of a contract with a single
+// multi-key eth_getProof
+func (s *StorageProver) GetStorageProofs(
+	ctx context.Context,
+	address common.Address,
+	slots []common.Hash,
+	blockNumber *big.Int,
+) (*types.StorageProofResult, error) {
+	keys := make([]string, len(slots))
+	for i, slot := range slots {
+		keys[i] = slot.Hex()
+	}
+
+	var result types.StorageProofResult
+	err := s.rpc.CallContext(ctx, &result, "eth_getProof", address, keys, toBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage proofs for %s: %w", address.Hex(), err)
+	}
+	if len(result.StorageProof) != len(slots) {
+		return nil, fmt.Errorf(
+			"expected %d storage proofs for %s, got %d",
+			len(slots),
+			address.Hex(),
+			len(result.StorageProof),
+		)
+	}
+	return &result, nil
+}
+
 // GenerateStorageProof creates a storage proof for the given contract and slot
 func (s *StorageProver) GenerateStorageProof(
 	ctx context.Context,
@@ -80,6 +109,16 @@ func (s *StorageProver) GenerateStorageProof(
 		return nil, nil, nil, fmt.Errorf("failed to get storage proof: %w", err)
 	}
 
+	return StorageProofBytes(proof, 0)
+}
+
+// StorageProofBytes converts the storage proof at index of an eth_getProof result into the
+// storage proof, RLP encoded account and account proof expected by the NativeProver
+func StorageProofBytes(proof *types.StorageProofResult, index int) ([][]byte, []byte, [][]byte, error) {
+	if proof.Nonce == nil || proof.Balance == nil {
+		return nil, nil, nil, fmt.Errorf("account of %s is missing from the proof", proof.Address.Hex())
+	}
+
 	// Convert account proof to bytes
 	accountProof := make([][]byte, len(proof.AccountProof))
 	for i, p := range proof.AccountProof {
@@ -87,13 +126,13 @@ func (s *StorageProver) GenerateStorageProof(
 	}
 
 	// Get storage proof for the slot
-	if len(proof.StorageProof) == 0 {
-		return nil, nil, nil, fmt.Errorf("no storage proof found for slot %s", storageSlot.Hex())
+	if index >= len(proof.StorageProof) {
+		return nil, nil, nil, fmt.Errorf("no storage proof found at index %d", index)
 	}
 
 	// Convert storage proof to bytes
-	storageProof := make([][]byte, len(proof.StorageProof[0].Proof))
-	for i, p := range proof.StorageProof[0].Proof {
+	storageProof := make([][]byte, len(proof.StorageProof[index].Proof))
+	for i, p := range proof.StorageProof[index].Proof {
 		storageProof[i] = common.FromHex(p)
 	}
 
@@ -0,0 +1,76 @@
+package provers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	t "github.com/polymerdao/fallback_prover/types"
+)
+
+// Submit sends the prove calldata to the NativeProver at nativeProverAddress through the
+// transactor, waits for it to be mined and returns the WorldStateProven event it emitted.
+// A revert during gas estimation is reported as a *RevertError.
+func (np *NativeProver) Submit(
+	ctx context.Context,
+	transactor *Transactor,
+	nativeProverAddress common.Address,
+	calldata []byte,
+) (*t.SubmitResult, error) {
+	receipt, err := transactor.Send(ctx, nativeProverAddress, calldata)
+	if err != nil {
+		if revertErr := np.revertError(err); revertErr != nil {
+			return nil, revertErr
+		}
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("transaction %s reverted in block %s", receipt.TxHash.Hex(), receipt.BlockNumber)
+	}
+
+	event, err := np.ParseWorldStateProven(nativeProverAddress, receipt.Logs)
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", receipt.TxHash.Hex(), err)
+	}
+
+	return &t.SubmitResult{
+		TxHash:      receipt.TxHash,
+		BlockNumber: receipt.BlockNumber,
+		GasUsed:     receipt.GasUsed,
+		Event:       event,
+	}, nil
+}
+
+// ParseWorldStateProven finds the L1WorldStateProven or L2WorldStateProven event emitted
+// by the NativeProver at nativeProverAddress in logs
+func (np *NativeProver) ParseWorldStateProven(
+	nativeProverAddress common.Address,
+	logs []*types.Log,
+) (*t.WorldStateProven, error) {
+	l1Event := np.abi.Events["L1WorldStateProven"]
+	l2Event := np.abi.Events["L2WorldStateProven"]
+
+	for _, l := range logs {
+		if l.Address != nativeProverAddress || len(l.Topics) == 0 || len(l.Data) != common.HashLength {
+			continue
+		}
+		switch {
+		case l.Topics[0] == l2Event.ID && len(l.Topics) == 3:
+			return &t.WorldStateProven{
+				Event:       l2Event.Name,
+				ChainID:     l.Topics[1].Big(),
+				BlockNumber: l.Topics[2].Big(),
+				StateRoot:   common.BytesToHash(l.Data),
+			}, nil
+		case l.Topics[0] == l1Event.ID && len(l.Topics) == 2:
+			return &t.WorldStateProven{
+				Event:       l1Event.Name,
+				BlockNumber: l.Topics[1].Big(),
+				StateRoot:   common.BytesToHash(l.Data),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s or %s event from %s", l1Event.Name, l2Event.Name, nativeProverAddress.Hex())
+}
@@ -0,0 +1,146 @@
+package provers
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	t2 "github.com/polymerdao/fallback_prover/types"
+)
+
+// emitterCode returns runtime bytecode that ignores its calldata and emits a single log
+// with the given topics and a one word data field
+func emitterCode(data common.Hash, topics ...common.Hash) []byte {
+	code := append([]byte{byte(vm.PUSH32)}, data.Bytes()...)
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.MSTORE))
+	for i := len(topics) - 1; i >= 0; i-- {
+		code = append(code, byte(vm.PUSH32))
+		code = append(code, topics[i].Bytes()...)
+	}
+	code = append(code, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG0)+byte(len(topics)), byte(vm.STOP))
+	return code
+}
+
+// revertCode returns runtime bytecode that reverts with the given data
+func revertCode(data []byte) []byte {
+	var code []byte
+	for i, b := range data {
+		code = append(code, byte(vm.PUSH1), b, byte(vm.PUSH1), byte(i), byte(vm.MSTORE8))
+	}
+	return append(code, byte(vm.PUSH1), byte(len(data)), byte(vm.PUSH1), 0, byte(vm.REVERT))
+}
+
+// newSubmitBackend starts a simulated chain with a funded key and the given contract code
+// deployed at nativeProverAddress, mining a block every few milliseconds
+func newSubmitBackend(t *testing.T, nativeProverAddress common.Address, code []byte) (*simulated.Backend, *Transactor) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(params.Ether)},
+		nativeProverAddress:                   {Code: code, Balance: big.NewInt(0)},
+	})
+	t.Cleanup(func() { _ = backend.Close() })
+
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				backend.Commit()
+			}
+		}
+	}()
+
+	return backend, NewTransactor(backend.Client(), key, 10*time.Millisecond)
+}
+
+func TestNativeProver_Submit(t *testing.T) {
+	prover, err := NewNativeProver()
+	require.NoError(t, err)
+
+	nativeProverAddress := common.HexToAddress("0x9876543210abcdef9876543210abcdef98765432")
+	stateRoot := common.HexToHash("0xabcdef")
+	code := emitterCode(
+		stateRoot,
+		prover.abi.Events["L2WorldStateProven"].ID,
+		common.BigToHash(big.NewInt(10)),
+		common.BigToHash(big.NewInt(123)),
+	)
+	_, transactor := newSubmitBackend(t, nativeProverAddress, code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := prover.Submit(ctx, transactor, nativeProverAddress, []byte{0x01, 0x02, 0x03, 0x04})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, common.Hash{}, result.TxHash)
+	assert.NotZero(t, result.GasUsed)
+	assert.Equal(t, &t2.WorldStateProven{
+		Event:       "L2WorldStateProven",
+		ChainID:     big.NewInt(10),
+		BlockNumber: big.NewInt(123),
+		StateRoot:   stateRoot,
+	}, result.Event)
+}
+
+func TestNativeProver_Submit_Revert(t *testing.T) {
+	prover, err := NewNativeProver()
+	require.NoError(t, err)
+
+	revertData := packRevert(t, "NativeProver", "NeedLaterBlock", big.NewInt(100), big.NewInt(164))
+	nativeProverAddress := common.HexToAddress("0x9876543210abcdef9876543210abcdef98765432")
+	_, transactor := newSubmitBackend(t, nativeProverAddress, revertCode(revertData))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = prover.Submit(ctx, transactor, nativeProverAddress, []byte{0x01, 0x02, 0x03, 0x04})
+
+	var revertErr *RevertError
+	require.ErrorAs(t, err, &revertErr)
+	assert.Equal(t, revertData, revertErr.Data)
+	var needLaterBlock *NeedLaterBlockError
+	require.ErrorAs(t, err, &needLaterBlock)
+	assert.Equal(t, big.NewInt(164), needLaterBlock.NextProvableBlockNumber)
+}
+
+func TestNativeProver_ParseWorldStateProven(t *testing.T) {
+	prover, err := NewNativeProver()
+	require.NoError(t, err)
+
+	nativeProverAddress := common.HexToAddress("0x1234")
+	stateRoot := common.HexToHash("0xabcdef")
+	l1Log := &types.Log{
+		Address: nativeProverAddress,
+		Topics:  []common.Hash{prover.abi.Events["L1WorldStateProven"].ID, common.BigToHash(big.NewInt(77))},
+		Data:    stateRoot.Bytes(),
+	}
+
+	// Logs from other contracts are ignored
+	otherLog := *l1Log
+	otherLog.Address = common.HexToAddress("0x5678")
+	_, err = prover.ParseWorldStateProven(nativeProverAddress, []*types.Log{&otherLog})
+	require.Error(t, err)
+
+	event, err := prover.ParseWorldStateProven(nativeProverAddress, []*types.Log{&otherLog, l1Log})
+	require.NoError(t, err)
+	assert.Equal(t, "L1WorldStateProven", event.Event)
+	assert.Nil(t, event.ChainID)
+	assert.Equal(t, big.NewInt(77), event.BlockNumber)
+	assert.Equal(t, stateRoot, event.StateRoot)
+}
@@ -0,0 +1,130 @@
+package provers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// gasLimitBufferPercent is added on top of the estimated gas, since proof verification cost
+// can shift slightly between estimation and inclusion
+const gasLimitBufferPercent = 20
+
+// Transactor signs EIP-1559 transactions with a local key, sends them and waits for their receipts
+type Transactor struct {
+	client       ITxClient
+	key          *ecdsa.PrivateKey
+	from         common.Address
+	pollInterval time.Duration
+}
+
+// NewTransactor creates a Transactor sending from the address of key, polling for
+// receipts every pollInterval
+func NewTransactor(client ITxClient, key *ecdsa.PrivateKey, pollInterval time.Duration) *Transactor {
+	return &Transactor{
+		client:       client,
+		key:          key,
+		from:         crypto.PubkeyToAddress(key.PublicKey),
+		pollInterval: pollInterval,
+	}
+}
+
+// From returns the address transactions are sent from
+func (tr *Transactor) From() common.Address {
+	return tr.from
+}
+
+// Send fills in the nonce, gas limit and EIP-1559 fees for a call to `to` with data,
+// signs and sends it, and waits until it is mined
+func (tr *Transactor) Send(ctx context.Context, to common.Address, data []byte) (*types.Receipt, error) {
+	chainID, err := tr.client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	nonce, err := tr.client.PendingNonceAt(ctx, tr.from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce for %s: %w", tr.from.Hex(), err)
+	}
+
+	head, err := tr.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, fmt.Errorf("chain %s does not support EIP-1559 transactions", chainID)
+	}
+
+	gasTipCap, err := tr.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+	}
+	// Leave room for the base fee to double before the transaction is included
+	gasFeeCap := new(big.Int).Add(gasTipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+
+	gas, err := tr.client.EstimateGas(ctx, ethereum.CallMsg{
+		From:      tr.from,
+		To:        &to,
+		GasFeeCap: gasFeeCap,
+		GasTipCap: gasTipCap,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	gas += gas * gasLimitBufferPercent / 100
+
+	tx, err := types.SignNewTx(tr.key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gas,
+		To:        &to,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	if err := tr.client.SendTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	log.Info("Sent transaction", "hash", tx.Hash(), "from", tr.from, "nonce", nonce, "gas", gas)
+
+	return tr.waitMined(ctx, tx.Hash())
+}
+
+// waitMined polls for the receipt of txHash until it is available or ctx is done
+func (tr *Transactor) waitMined(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(tr.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := tr.client.TransactionReceipt(ctx, txHash)
+		if err == nil {
+			return receipt, nil
+		}
+		// Nodes that are still building their transaction index cannot tell whether it was mined yet
+		if !errors.Is(err, ethereum.NotFound) && !strings.Contains(err.Error(), "transaction indexing is in progress") {
+			return nil, fmt.Errorf("failed to get receipt for %s: %w", txHash.Hex(), err)
+		}
+		log.Debug("Waiting for transaction to be mined", "hash", txHash)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for transaction %s: %w", txHash.Hex(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ProofError reports which proof failed to verify and at which trie node
+type ProofError struct {
+	// Proof names the proof that failed, e.g. "account proof for 0x..."
+	Proof string
+	// Node is the index of the offending node in the proof, or -1 if the
+	// failure is not tied to a single node
+	Node int
+	Err  error
+}
+
+func (e *ProofError) Error() string {
+	if e.Node < 0 {
+		return fmt.Sprintf("invalid %s: %v", e.Proof, e.Err)
+	}
+	return fmt.Sprintf("invalid %s at node %d: %v", e.Proof, e.Node, e.Err)
+}
+
+func (e *ProofError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrNodeHashMismatch is returned when a proof node does not hash to the reference held by its parent
+	ErrNodeHashMismatch = errors.New("node hash mismatch")
+	// ErrProofTooShort is returned when the proof ends before the path to the key is resolved
+	ErrProofTooShort = errors.New("proof ends before reaching the key")
+	// ErrUnusedProofNodes is returned when the proof contains nodes past the one resolving the key
+	ErrUnusedProofNodes = errors.New("proof has trailing nodes")
+	// ErrValueMismatch is returned when the proven value differs from the expected one
+	ErrValueMismatch = errors.New("proven value mismatch")
+)
+
+// walkProof resolves the value stored under keccak256(key) in the trie with the given root,
+// using only the nodes in proof. A nil value means the proof shows the key is absent.
+// On failure the returned index identifies the proof node that could not be verified.
+func walkProof(root common.Hash, key []byte, proof [][]byte) ([]byte, int, error) {
+	if root == types.EmptyRootHash {
+		// An empty trie is proven by an empty proof, some clients return the empty string node
+		if len(proof) == 0 || (len(proof) == 1 && bytes.Equal(proof[0], []byte{rlp.EmptyString[0]})) {
+			return nil, -1, nil
+		}
+		return nil, 0, fmt.Errorf("non-empty proof for the empty trie")
+	}
+
+	path := keyToNibbles(crypto.Keccak256(key))
+	want := root[:]
+	for i := 0; ; i++ {
+		if i >= len(proof) {
+			return nil, i, ErrProofTooShort
+		}
+		if got := crypto.Keccak256(proof[i]); !bytes.Equal(got, want) {
+			return nil, i, fmt.Errorf("%w: expected %x, got %x", ErrNodeHashMismatch, want, got)
+		}
+
+		// Descend through this node and any nodes embedded in it
+		node := proof[i]
+		for {
+			elems, err := decodeNode(node)
+			if err != nil {
+				return nil, i, err
+			}
+
+			var child []byte
+			switch len(elems) {
+			case 17:
+				if len(path) == 0 {
+					value, err := nodeValue(elems[16])
+					if err != nil {
+						return nil, i, err
+					}
+					return value, i, checkLast(i, proof)
+				}
+				child = elems[path[0]]
+				path = path[1:]
+			case 2:
+				compact, _, err := rlp.SplitString(elems[0])
+				if err != nil {
+					return nil, i, fmt.Errorf("invalid short node key: %w", err)
+				}
+				nibbles, leaf := compactToNibbles(compact)
+				if !bytes.HasPrefix(path, nibbles) || (leaf && len(path) != len(nibbles)) {
+					// The path diverges from the key, which proves its absence
+					return nil, i, checkLast(i, proof)
+				}
+				path = path[len(nibbles):]
+				if leaf {
+					value, err := nodeValue(elems[1])
+					if err != nil {
+						return nil, i, err
+					}
+					return value, i, checkLast(i, proof)
+				}
+				child = elems[1]
+			default:
+				return nil, i, fmt.Errorf("invalid node with %d elements", len(elems))
+			}
+
+			kind, content, _, err := rlp.Split(child)
+			if err != nil {
+				return nil, i, fmt.Errorf("invalid child reference: %w", err)
+			}
+			switch {
+			case kind == rlp.List:
+				// Nodes shorter than 32 bytes are embedded in their parent
+				node = child
+				continue
+			case len(content) == 0:
+				return nil, i, checkLast(i, proof)
+			case len(content) == common.HashLength:
+				want = content
+			default:
+				return nil, i, fmt.Errorf("invalid child reference of %d bytes", len(content))
+			}
+			break
+		}
+	}
+}
+
+// checkLast makes sure the node at index i is the last one in the proof
+func checkLast(i int, proof [][]byte) error {
+	if i != len(proof)-1 {
+		return fmt.Errorf("%w: %d nodes after the terminal node", ErrUnusedProofNodes, len(proof)-1-i)
+	}
+	return nil
+}
+
+// decodeNode splits an RLP encoded trie node into the raw encodings of its elements
+func decodeNode(node []byte) ([][]byte, error) {
+	content, _, err := rlp.SplitList(node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node encoding: %w", err)
+	}
+	var elems [][]byte
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node element: %w", err)
+		}
+		elems = append(elems, content[:len(content)-len(rest)])
+		content = rest
+	}
+	return elems, nil
+}
+
+// nodeValue extracts the value held in a leaf or branch node
+func nodeValue(raw []byte) ([]byte, error) {
+	value, _, err := rlp.SplitString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node value: %w", err)
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return value, nil
+}
+
+// keyToNibbles expands a key into its hex nibbles
+func keyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2)
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	return nibbles
+}
+
+// compactToNibbles decodes a hex-prefix encoded node key, reporting whether it belongs to a leaf
+func compactToNibbles(compact []byte) ([]byte, bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	nibbles := keyToNibbles(compact)
+	leaf := nibbles[0] >= 2
+	// An odd flag keeps the second nibble as part of the key
+	if nibbles[0]&1 == 1 {
+		return nibbles[1:], leaf
+	}
+	return nibbles[2:], leaf
+}
@@ -0,0 +1,138 @@
+// Package verify checks Merkle-Patricia account and storage proofs offline,
+// before they are encoded into calldata and submitted on-chain.
+package verify
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	t "github.com/polymerdao/fallback_prover/types"
+)
+
+// account mirrors the RLP layout of an Ethereum account in the state trie
+type account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// AccountProof verifies that accountProof proves rlpAccount is the account stored at
+// address in the state trie with root stateRoot
+func AccountProof(stateRoot common.Hash, address common.Address, rlpAccount []byte, accountProof [][]byte) error {
+	name := fmt.Sprintf("account proof for %s", address.Hex())
+
+	value, node, err := walkProof(stateRoot, address.Bytes(), accountProof)
+	if err != nil {
+		return &ProofError{Proof: name, Node: node, Err: err}
+	}
+	if value == nil {
+		return &ProofError{Proof: name, Node: node, Err: fmt.Errorf("account does not exist in state %s", stateRoot.Hex())}
+	}
+	if !bytes.Equal(value, rlpAccount) {
+		return &ProofError{
+			Proof: name,
+			Node:  node,
+			Err:   fmt.Errorf("%w: proven account 0x%x, encoded account 0x%x", ErrValueMismatch, value, rlpAccount),
+		}
+	}
+	return nil
+}
+
+// StorageProof verifies that storageProof proves value is stored at slot in the storage
+// trie with root storageRoot. A zero value must be proven absent.
+func StorageProof(storageRoot common.Hash, slot common.Hash, value common.Hash, storageProof [][]byte) error {
+	name := fmt.Sprintf("storage proof for slot %s", slot.Hex())
+
+	proven, node, err := walkProof(storageRoot, slot.Bytes(), storageProof)
+	if err != nil {
+		return &ProofError{Proof: name, Node: node, Err: err}
+	}
+
+	var provenValue common.Hash
+	if proven != nil {
+		content, _, err := rlp.SplitString(proven)
+		if err != nil {
+			return &ProofError{Proof: name, Node: node, Err: fmt.Errorf("invalid storage value encoding: %w", err)}
+		}
+		if len(content) > common.HashLength {
+			return &ProofError{Proof: name, Node: node, Err: fmt.Errorf("storage value of %d bytes", len(content))}
+		}
+		provenValue = common.BytesToHash(content)
+	}
+	if provenValue != value {
+		return &ProofError{
+			Proof: name,
+			Node:  node,
+			Err:   fmt.Errorf("%w: proven %s, expected %s", ErrValueMismatch, provenValue.Hex(), value.Hex()),
+		}
+	}
+	return nil
+}
+
+// AccountAndStorage verifies a storage proof together with the account proof of the
+// contract holding it, taking the storage root from the RLP encoded account
+func AccountAndStorage(
+	stateRoot common.Hash,
+	address common.Address,
+	slot common.Hash,
+	value common.Hash,
+	storageProof [][]byte,
+	rlpAccount []byte,
+	accountProof [][]byte,
+) error {
+	var acc account
+	if err := rlp.DecodeBytes(rlpAccount, &acc); err != nil {
+		return fmt.Errorf("failed to decode account %s: %w", address.Hex(), err)
+	}
+	if err := AccountProof(stateRoot, address, rlpAccount, accountProof); err != nil {
+		return err
+	}
+	if err := StorageProof(acc.Root, slot, value, storageProof); err != nil {
+		return fmt.Errorf("account %s: %w", address.Hex(), err)
+	}
+	return nil
+}
+
+// ProofResult verifies an eth_getProof response in full: the account proof against
+// stateRoot and every storage proof against the account's storage root
+func ProofResult(stateRoot common.Hash, result *t.StorageProofResult) error {
+	if result.Nonce == nil || result.Balance == nil {
+		return fmt.Errorf("incomplete account data for %s", result.Address.Hex())
+	}
+	rlpAccount, err := rlp.EncodeToBytes(account{
+		Nonce:    uint64(*result.Nonce),
+		Balance:  result.Balance.ToInt(),
+		Root:     result.StorageHash,
+		CodeHash: result.CodeHash.Bytes(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to RLP encode account %s: %w", result.Address.Hex(), err)
+	}
+	if err := AccountProof(stateRoot, result.Address, rlpAccount, DecodeProof(result.AccountProof)); err != nil {
+		return err
+	}
+	for _, entry := range result.StorageProof {
+		var value common.Hash
+		if entry.Value != nil {
+			value = common.BigToHash(entry.Value.ToInt())
+		}
+		if err := StorageProof(result.StorageHash, entry.Key, value, DecodeProof(entry.Proof)); err != nil {
+			return fmt.Errorf("account %s: %w", result.Address.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// DecodeProof converts the hex encoded nodes of an eth_getProof response to bytes
+func DecodeProof(proof []string) [][]byte {
+	nodes := make([][]byte, len(proof))
+	for i, p := range proof {
+		nodes[i] = common.FromHex(p)
+	}
+	return nodes
+}
@@ -0,0 +1,149 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/polymerdao/fallback_prover/testutil"
+	t2 "github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestState creates a state with enough accounts and slots for multi-node proofs
+func newTestState(t *testing.T) (*testutil.ProofState, common.Address, common.Hash, common.Hash) {
+	state := testutil.NewProofState()
+	for i := 0; i < 64; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		state.SetAccount(addr, uint64(i), big.NewInt(int64(i*1000)), common.HexToHash(fmt.Sprintf("0x%x", i+1)))
+		for j := 0; j < 16; j++ {
+			state.SetStorage(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j+1))))
+		}
+	}
+	address := common.BigToAddress(big.NewInt(7))
+	slot := common.BigToHash(big.NewInt(3))
+	value := common.BigToHash(big.NewInt(6*3 + 1))
+	return state, address, slot, value
+}
+
+func TestAccountAndStorage(t *testing.T) {
+	state, address, slot, value := newTestState(t)
+	storageProof, rlpAccount, accountProof := state.ProofBytes(t, address, slot)
+	require.Greater(t, len(accountProof), 1)
+	require.Greater(t, len(storageProof), 1)
+
+	err := AccountAndStorage(state.Root(t), address, slot, value, storageProof, rlpAccount, accountProof)
+	require.NoError(t, err)
+}
+
+func TestAccountAndStorage_AbsentSlot(t *testing.T) {
+	state, address, _, _ := newTestState(t)
+	slot := common.HexToHash("0xdeadbeef")
+	storageProof, rlpAccount, accountProof := state.ProofBytes(t, address, slot)
+
+	err := AccountAndStorage(state.Root(t), address, slot, common.Hash{}, storageProof, rlpAccount, accountProof)
+	require.NoError(t, err)
+
+	err = AccountAndStorage(state.Root(t), address, slot, common.HexToHash("0x1"), storageProof, rlpAccount, accountProof)
+	require.ErrorIs(t, err, ErrValueMismatch)
+}
+
+func TestAccountAndStorage_WrongValue(t *testing.T) {
+	state, address, slot, _ := newTestState(t)
+	storageProof, rlpAccount, accountProof := state.ProofBytes(t, address, slot)
+
+	err := AccountAndStorage(
+		state.Root(t),
+		address,
+		slot,
+		common.HexToHash("0x1234"),
+		storageProof,
+		rlpAccount,
+		accountProof,
+	)
+	var proofErr *ProofError
+	require.ErrorAs(t, err, &proofErr)
+	assert.ErrorIs(t, err, ErrValueMismatch)
+	assert.Equal(t, len(storageProof)-1, proofErr.Node)
+	assert.Contains(t, proofErr.Proof, "storage proof")
+}
+
+func TestAccountProof_TamperedNode(t *testing.T) {
+	state, address, slot, _ := newTestState(t)
+	_, rlpAccount, accountProof := state.ProofBytes(t, address, slot)
+
+	// Flip a byte in the second node so it no longer hashes to the root's reference
+	accountProof[1] = common.CopyBytes(accountProof[1])
+	accountProof[1][len(accountProof[1])-1] ^= 0xff
+
+	err := AccountProof(state.Root(t), address, rlpAccount, accountProof)
+	var proofErr *ProofError
+	require.ErrorAs(t, err, &proofErr)
+	assert.ErrorIs(t, err, ErrNodeHashMismatch)
+	assert.Equal(t, 1, proofErr.Node)
+	assert.Contains(t, proofErr.Proof, address.Hex())
+}
+
+func TestAccountProof_WrongRoot(t *testing.T) {
+	state, address, slot, _ := newTestState(t)
+	_, rlpAccount, accountProof := state.ProofBytes(t, address, slot)
+
+	err := AccountProof(common.HexToHash("0x1234"), address, rlpAccount, accountProof)
+	var proofErr *ProofError
+	require.ErrorAs(t, err, &proofErr)
+	assert.ErrorIs(t, err, ErrNodeHashMismatch)
+	assert.Equal(t, 0, proofErr.Node)
+}
+
+func TestAccountProof_WrongAccount(t *testing.T) {
+	state, address, slot, _ := newTestState(t)
+	_, _, accountProof := state.ProofBytes(t, address, slot)
+	_, otherAccount, _ := state.ProofBytes(t, common.BigToAddress(big.NewInt(8)), slot)
+
+	err := AccountProof(state.Root(t), address, otherAccount, accountProof)
+	require.ErrorIs(t, err, ErrValueMismatch)
+}
+
+func TestAccountProof_TruncatedAndTrailing(t *testing.T) {
+	state, address, slot, _ := newTestState(t)
+	_, rlpAccount, accountProof := state.ProofBytes(t, address, slot)
+
+	err := AccountProof(state.Root(t), address, rlpAccount, accountProof[:len(accountProof)-1])
+	require.ErrorIs(t, err, ErrProofTooShort)
+
+	extended := append(append([][]byte{}, accountProof...), accountProof[0])
+	err = AccountProof(state.Root(t), address, rlpAccount, extended)
+	require.ErrorIs(t, err, ErrUnusedProofNodes)
+}
+
+func TestStorageProof_EmptyTrie(t *testing.T) {
+	state := testutil.NewProofState()
+	address := common.HexToAddress("0x1234")
+	state.SetAccount(address, 0, big.NewInt(0), common.Hash{})
+	slot := common.HexToHash("0x1")
+	storageProof, _, _ := state.ProofBytes(t, address, slot)
+
+	require.NoError(t, StorageProof(state.StorageRoot(t, address), slot, common.Hash{}, storageProof))
+}
+
+func TestProofResult(t *testing.T) {
+	state, address, slot, _ := newTestState(t)
+	otherSlot := common.BigToHash(big.NewInt(9))
+
+	raw, err := json.Marshal(state.GetProof(t, address, slot, otherSlot))
+	require.NoError(t, err)
+	var result t2.StorageProofResult
+	require.NoError(t, json.Unmarshal(raw, &result))
+
+	require.NoError(t, ProofResult(state.Root(t), &result))
+
+	result.StorageProof[1].Proof = result.StorageProof[0].Proof
+	err = ProofResult(state.Root(t), &result)
+	var proofErr *ProofError
+	require.True(t, errors.As(err, &proofErr))
+	assert.Contains(t, err.Error(), otherSlot.Hex())
+}
@@ -0,0 +1,136 @@
+package fallback_prover
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// newRegistryProver connects to the L1 node of conf and returns a RegistryProver for its registry
+func newRegistryProver(conf *RegistryConfig) (*provers.RegistryProver, error) {
+	l1RPC, err := rpc.Dial(conf.L1HTTPPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to L1 RPC: %w", err)
+	}
+	return provers.NewRegistryProver(ethclient.NewClient(l1RPC), l1RPC, conf.RegistryAddress), nil
+}
+
+// GetRegistryChains lists the chains configured in the registry of conf, with their
+// configurations and update history
+func GetRegistryChains(ctx context.Context, conf *RegistryConfig) (*types.RegistryChains, error) {
+	registryProver, err := newRegistryProver(conf)
+	if err != nil {
+		return nil, err
+	}
+	chains, err := registryProver.GetRegistryChains(ctx, conf.FromBlock, conf.LogBlockRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry chains: %w", err)
+	}
+	return chains, nil
+}
+
+// GetRegistryAccess reads the owner, paused state, irrevocable grantees and roles of the registry
+// of conf
+func GetRegistryAccess(ctx context.Context, conf *RegistryConfig) (*types.RegistryAccess, error) {
+	registryProver, err := newRegistryProver(conf)
+	if err != nil {
+		return nil, err
+	}
+	access, err := registryProver.GetRegistryAccess(ctx, conf.FromBlock, conf.LogBlockRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry access: %w", err)
+	}
+	return access, nil
+}
+
+// WriteRegistryChains writes the registry chains as an indented tree
+func WriteRegistryChains(w io.Writer, chains *types.RegistryChains) error {
+	t := &treeWriter{w: w}
+	t.line("registry %s at L1 block %d, logs from block %d", chains.Registry, chains.BlockNumber, chains.FromBlock)
+	t.indent(func() {
+		for _, chain := range chains.Chains {
+			if chain.L2Configuration != nil {
+				t.line("chain %d: %s", chain.ChainID, chain.ConfigType)
+			} else {
+				t.line("chain %d", chain.ChainID)
+			}
+			t.indent(func() {
+				if config := chain.L2Configuration; config != nil {
+					t.line("L2 configuration")
+					t.indent(func() {
+						t.line("prover: %s", config.Prover)
+						t.line("addresses: %s", joinStrings(config.Addresses))
+						t.line("storage slots: %s", joinStrings(config.StorageSlots))
+						t.line("version: %s", config.VersionNumber)
+						t.line("finality delay: %ss", config.FinalityDelaySeconds)
+					})
+					t.configUpdates("L2 configuration updates", chain.L2ConfigurationUpdates)
+				}
+				if config := chain.L1Configuration; config != nil {
+					t.line("L1 configuration")
+					t.indent(func() {
+						t.line("block hash oracle: %s", config.BlockHashOracle)
+						t.line("settlement blocks delay: %s", config.SettlementBlocksDelay)
+						t.line("settlement registry: %s", config.SettlementRegistry)
+						t.line("L2 config mapping slot: %s", config.SettlementRegistryL2ConfigMappingSlot)
+						t.line("L1 config mapping slot: %s", config.SettlementRegistryL1ConfigMappingSlot)
+					})
+					t.configUpdates("L1 configuration updates", chain.L1ConfigurationUpdates)
+				}
+			})
+		}
+	})
+	return t.err
+}
+
+// WriteRegistryAccess writes the registry access control as an indented tree
+func WriteRegistryAccess(w io.Writer, access *types.RegistryAccess) error {
+	t := &treeWriter{w: w}
+	t.line("registry %s at L1 block %d, logs from block %d", access.Registry, access.BlockNumber, access.FromBlock)
+	t.indent(func() {
+		t.line("owner: %s", access.Owner)
+		t.line("paused: %t", access.Paused)
+		t.line("irrevocable grantees")
+		t.indent(func() {
+			for _, grantee := range access.Grantees {
+				t.line(
+					"chain %d: %s at block %d, tx %s",
+					grantee.ChainID,
+					grantee.Grantee,
+					grantee.BlockNumber,
+					grantee.TxHash,
+				)
+			}
+		})
+		t.line("roles")
+		t.indent(func() {
+			for _, role := range access.Roles {
+				name := role.Role.String()
+				if role.Name != "" {
+					name = fmt.Sprintf("%s %s", role.Name, role.Role)
+				}
+				t.line("%s, admin role %s", name, role.AdminRole)
+				t.indent(func() {
+					for _, member := range role.Members {
+						t.line("%s", member)
+					}
+				})
+			}
+		})
+	})
+	return t.err
+}
+
+func (t *treeWriter) configUpdates(name string, updates []types.RegistryConfigUpdate) {
+	t.line("%s", name)
+	t.indent(func() {
+		for _, update := range updates {
+			t.line("%s at block %d, tx %s", update.ConfigHash, update.BlockNumber, update.TxHash)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package fallback_prover
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	types2 "github.com/polymerdao/fallback_prover/types"
+)
+
+func TestWriteRegistryChains(t *testing.T) {
+	chains := &types2.RegistryChains{
+		Registry:    common.HexToAddress("0x01"),
+		FromBlock:   10,
+		BlockNumber: 100,
+		Chains: []types2.RegistryChain{
+			{
+				ChainID: 1,
+				L1Configuration: &types2.L1Configuration{
+					BlockHashOracle:                       common.HexToAddress("0x02"),
+					SettlementBlocksDelay:                 big.NewInt(25),
+					SettlementRegistry:                    common.HexToAddress("0x01"),
+					SettlementRegistryL2ConfigMappingSlot: big.NewInt(2),
+					SettlementRegistryL1ConfigMappingSlot: big.NewInt(5),
+				},
+				L1ConfigurationUpdates: []types2.RegistryConfigUpdate{
+					{ConfigHash: common.HexToHash("0xaa"), BlockNumber: 20, TxHash: common.HexToHash("0xbb")},
+				},
+			},
+			{
+				ChainID:    10,
+				ConfigType: "OPStackCannon",
+				L2Configuration: &types2.L2Configuration{
+					Prover:               common.HexToAddress("0x03"),
+					Addresses:            []common.Address{common.HexToAddress("0x04"), common.HexToAddress("0x05")},
+					StorageSlots:         []*big.Int{big.NewInt(1), big.NewInt(2)},
+					VersionNumber:        big.NewInt(3),
+					FinalityDelaySeconds: big.NewInt(604800),
+					L2Type:               types2.OPStackCannon,
+				},
+				L2ConfigurationUpdates: []types2.RegistryConfigUpdate{
+					{ConfigHash: common.HexToHash("0xcc"), BlockNumber: 30, TxHash: common.HexToHash("0xdd")},
+				},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteRegistryChains(&out, chains))
+	assert.Equal(t, `registry 0x0000000000000000000000000000000000000001 at L1 block 100, logs from block 10
+  chain 1
+    L1 configuration
+      block hash oracle: 0x0000000000000000000000000000000000000002
+      settlement blocks delay: 25
+      settlement registry: 0x0000000000000000000000000000000000000001
+      L2 config mapping slot: 2
+      L1 config mapping slot: 5
+    L1 configuration updates
+      0x00000000000000000000000000000000000000000000000000000000000000aa at block 20, tx 0x00000000000000000000000000000000000000000000000000000000000000bb
+  chain 10: OPStackCannon
+    L2 configuration
+      prover: 0x0000000000000000000000000000000000000003
+      addresses: 0x0000000000000000000000000000000000000004, 0x0000000000000000000000000000000000000005
+      storage slots: 1, 2
+      version: 3
+      finality delay: 604800s
+    L2 configuration updates
+      0x00000000000000000000000000000000000000000000000000000000000000cc at block 30, tx 0x00000000000000000000000000000000000000000000000000000000000000dd
+`, out.String())
+}
+
+func TestWriteRegistryAccess(t *testing.T) {
+	access := &types2.RegistryAccess{
+		Registry:    common.HexToAddress("0x01"),
+		BlockNumber: 100,
+		Owner:       common.HexToAddress("0x02"),
+		Paused:      true,
+		Grantees: []types2.RegistryGrantee{
+			{ChainID: 10, Grantee: common.HexToAddress("0x03"), BlockNumber: 5, TxHash: common.HexToHash("0xaa")},
+		},
+		Roles: []types2.RegistryRole{
+			{Name: "DEFAULT_ADMIN_ROLE", Members: []common.Address{common.HexToAddress("0x04")}},
+			{Role: common.HexToHash("0x0f"), Members: []common.Address{}},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteRegistryAccess(&out, access))
+	assert.Equal(t, `registry 0x0000000000000000000000000000000000000001 at L1 block 100, logs from block 0
+  owner: 0x0000000000000000000000000000000000000002
+  paused: true
+  irrevocable grantees
+    chain 10: 0x0000000000000000000000000000000000000003 at block 5, tx 0x00000000000000000000000000000000000000000000000000000000000000aa
+  roles
+    DEFAULT_ADMIN_ROLE 0x0000000000000000000000000000000000000000000000000000000000000000, admin role 0x0000000000000000000000000000000000000000000000000000000000000000
+      0x0000000000000000000000000000000000000004
+    0x000000000000000000000000000000000000000000000000000000000000000f, admin role 0x0000000000000000000000000000000000000000000000000000000000000000
+`, out.String())
+}
@@ -0,0 +1,395 @@
+package fallback_prover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+const (
+	// ServiceNamespace is the JSON-RPC namespace of the proof service methods, e.g. prover_proveNative
+	ServiceNamespace = "prover"
+	// shutdownTimeout bounds how long in-flight requests may take once the service is stopped
+	shutdownTimeout = 10 * time.Second
+)
+
+// ServiceConfig contains the configuration for the long-running proof service
+type ServiceConfig struct {
+	ListenAddr      string
+	L1HTTPPath      string
+	RegistryAddress common.Address
+	// L2RPCs maps the chain ID of every source and destination L2 to its RPC URL
+	L2RPCs map[uint64]string
+	// OptimismPortals maps the chain ID of OPStackCannon source L2s to their OptimismPortal2
+	OptimismPortals map[uint64]common.Address
+	// AllowUnverifiedNitroProofs enables settled state proofs for Arbitrum Nitro source L2s
+	AllowUnverifiedNitroProofs bool
+	// NativeProvers maps the chain ID of destination L2s to their NativeProver, whose
+	// L1_CONFIGURATION() gives the registry and L1 block hash oracle to prove for
+	NativeProvers     map[uint64]common.Address
+	EpochPollingFreq  uint
+	EpochPollingTries uint
+	// SettledStateTTL is how long each warm prover reuses its latest settled state
+	SettledStateTTL time.Duration
+}
+
+// ProveNativeRequest holds the parameters of the proveNative service method
+type ProveNativeRequest struct {
+	SrcChainID      uint64         `json:"srcChainId"`
+	DstChainID      uint64         `json:"dstChainId"`
+	Address         common.Address `json:"address"`
+	StorageSlot     common.Hash    `json:"storageSlot"`
+	L2BlockNumber   *big.Int       `json:"l2BlockNumber,omitempty"`
+	WaitForNewEpoch bool           `json:"waitForNewEpoch,omitempty"`
+}
+
+// ProveNativeL1Request holds the parameters of the proveNativeL1 service method
+type ProveNativeL1Request struct {
+	DstChainID      uint64         `json:"dstChainId"`
+	Address         common.Address `json:"address"`
+	StorageSlot     common.Hash    `json:"storageSlot"`
+	WaitForNewEpoch bool           `json:"waitForNewEpoch,omitempty"`
+}
+
+// ProveResponse is returned by the proveNative and proveNativeL1 service methods
+type ProveResponse struct {
+	Calldata   string `json:"calldata"`
+	SrcChainID uint64 `json:"srcChainId,omitempty"`
+	DstChainID uint64 `json:"dstChainId"`
+	// Settled is the settled source L2 state the proof was generated against; nil for L1 proofs
+	Settled *types.SettledState `json:"settled,omitempty"`
+}
+
+// WarmProverStatus describes a prover kept warm by the service
+type WarmProverStatus struct {
+	SrcChainID uint64    `json:"srcChainId,omitempty"`
+	DstChainID uint64    `json:"dstChainId"`
+	ConfigType string    `json:"configType,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// Index and RootAddress are the latest settled state cached by the prover, resolved at SettledAt
+	Index       *big.Int       `json:"index,omitempty"`
+	RootAddress common.Address `json:"rootAddress,omitempty"`
+	SettledAt   *time.Time     `json:"settledAt,omitempty"`
+}
+
+// ServiceStatus is returned by the status service method
+type ServiceStatus struct {
+	Version   string             `json:"version"`
+	StartedAt time.Time          `json:"startedAt"`
+	ChainIDs  []uint64           `json:"chainIds"`
+	Provers   []WarmProverStatus `json:"provers"`
+	L1Provers []WarmProverStatus `json:"l1Provers"`
+}
+
+// chainPair identifies a warm Prover by its source and destination chain IDs
+type chainPair struct {
+	src uint64
+	dst uint64
+}
+
+// warmEntry holds a prover that is being, or has been, initialized. ready is closed once
+// value or err is set.
+type warmEntry[T any] struct {
+	ready     chan struct{}
+	value     T
+	err       error
+	createdAt time.Time
+}
+
+// ProofService generates proof calldata with provers that are kept warm per chain pair, so
+// RPC connections, ABIs, registry lookups and the latest settled state are shared between requests
+type ProofService struct {
+	ctx       context.Context
+	conf      *ServiceConfig
+	version   string
+	startedAt time.Time
+
+	newProver   func(ctx context.Context, conf *ProveConfig) (*Prover, error)
+	newL1Prover func(ctx context.Context, conf *ProveL1Config) (*L1Prover, error)
+
+	mu        sync.Mutex
+	provers   map[chainPair]*warmEntry[*Prover]
+	l1Provers map[uint64]*warmEntry[*L1Prover]
+}
+
+// NewProofService creates a service whose provers are initialized under ctx, independently
+// of the request that first needs them
+func NewProofService(ctx context.Context, conf *ServiceConfig, version string) *ProofService {
+	return &ProofService{
+		ctx:         ctx,
+		conf:        conf,
+		version:     version,
+		startedAt:   time.Now(),
+		newProver:   NewProver,
+		newL1Prover: NewL1Prover,
+		provers:     make(map[chainPair]*warmEntry[*Prover]),
+		l1Provers:   make(map[uint64]*warmEntry[*L1Prover]),
+	}
+}
+
+// Serve runs the proof service as a JSON-RPC server over HTTP until ctx is cancelled.
+// In-flight requests are cancelled with ctx.
+func Serve(ctx context.Context, conf *ServiceConfig, version string) error {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName(ServiceNamespace, NewProofService(ctx, conf, version)); err != nil {
+		return fmt.Errorf("failed to register proof service: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", conf.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", conf.ListenAddr, err)
+	}
+	httpServer := &http.Server{
+		Handler:     server,
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.Serve(listener)
+	}()
+	log.Info("Serving proof service", "addr", listener.Addr(), "namespace", ServiceNamespace)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("proof service stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down proof service: %w", err)
+	}
+	return nil
+}
+
+// ProveNative generates NativeProver.proveNative() calldata for a storage slot on the source L2
+func (s *ProofService) ProveNative(ctx context.Context, req ProveNativeRequest) (*ProveResponse, error) {
+	prover, err := s.prover(ctx, req.SrcChainID, req.DstChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	calldata, settled, err := prover.GenerateProveNativeCalldata(ctx, &ProveParams{
+		Address:           req.Address,
+		StorageSlot:       req.StorageSlot,
+		L2BlockNumber:     req.L2BlockNumber,
+		WaitForNewEpoch:   req.WaitForNewEpoch,
+		EpochPollingFreq:  s.conf.EpochPollingFreq,
+		EpochPollingTries: s.conf.EpochPollingTries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proveNative calldata: %w", err)
+	}
+
+	return &ProveResponse{
+		Calldata:   calldata,
+		SrcChainID: req.SrcChainID,
+		DstChainID: req.DstChainID,
+		Settled:    settled,
+	}, nil
+}
+
+// ProveNativeL1 generates NativeProver.proveL1Native() calldata for a storage slot on L1
+func (s *ProofService) ProveNativeL1(ctx context.Context, req ProveNativeL1Request) (*ProveResponse, error) {
+	prover, err := s.l1Prover(ctx, req.DstChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	calldata, err := prover.GenerateProveL1Calldata(ctx, &ProveParams{
+		Address:           req.Address,
+		StorageSlot:       req.StorageSlot,
+		WaitForNewEpoch:   req.WaitForNewEpoch,
+		EpochPollingFreq:  s.conf.EpochPollingFreq,
+		EpochPollingTries: s.conf.EpochPollingTries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proveL1Native calldata: %w", err)
+	}
+
+	return &ProveResponse{
+		Calldata:   calldata,
+		DstChainID: req.DstChainID,
+	}, nil
+}
+
+// Status reports the configured chains and the provers that are currently warm
+func (s *ProofService) Status() *ServiceStatus {
+	status := &ServiceStatus{
+		Version:   s.version,
+		StartedAt: s.startedAt,
+		Provers:   []WarmProverStatus{},
+		L1Provers: []WarmProverStatus{},
+	}
+	for chainID := range s.conf.L2RPCs {
+		status.ChainIDs = append(status.ChainIDs, chainID)
+	}
+	sort.Slice(status.ChainIDs, func(i, j int) bool { return status.ChainIDs[i] < status.ChainIDs[j] })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for pair, entry := range s.provers {
+		if !entry.done() || entry.err != nil {
+			continue
+		}
+		proverStatus := WarmProverStatus{
+			SrcChainID: pair.src,
+			DstChainID: pair.dst,
+			ConfigType: entry.value.l2Config.ConfigType,
+			CreatedAt:  entry.createdAt,
+		}
+		if settled := entry.value.cachedSettled(); settled != nil {
+			proverStatus.Index = settled.index
+			proverStatus.RootAddress = settled.rootAddress
+			proverStatus.SettledAt = &settled.resolvedAt
+		}
+		status.Provers = append(status.Provers, proverStatus)
+	}
+	for dst, entry := range s.l1Provers {
+		if !entry.done() || entry.err != nil {
+			continue
+		}
+		status.L1Provers = append(status.L1Provers, WarmProverStatus{
+			DstChainID: dst,
+			CreatedAt:  entry.createdAt,
+		})
+	}
+	sort.Slice(status.Provers, func(i, j int) bool {
+		a, b := status.Provers[i], status.Provers[j]
+		return a.SrcChainID < b.SrcChainID || (a.SrcChainID == b.SrcChainID && a.DstChainID < b.DstChainID)
+	})
+	sort.Slice(status.L1Provers, func(i, j int) bool {
+		return status.L1Provers[i].DstChainID < status.L1Provers[j].DstChainID
+	})
+	return status
+}
+
+// prover returns the warm Prover for the chain pair, initializing it on first use
+func (s *ProofService) prover(ctx context.Context, src, dst uint64) (*Prover, error) {
+	srcRPC, err := s.l2RPC(src)
+	if err != nil {
+		return nil, err
+	}
+	dstRPC, err := s.l2RPC(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	entry, ok := s.provers[chainPair{src, dst}]
+	if !ok {
+		entry = &warmEntry[*Prover]{ready: make(chan struct{})}
+		s.provers[chainPair{src, dst}] = entry
+		go func() {
+			log.Info("Initializing prover", "srcL2ChainID", src, "dstL2ChainID", dst)
+			prover, err := s.newProver(s.ctx, &ProveConfig{
+				SrcL2ChainID:    src,
+				DstL2ChainID:    dst,
+				L1HTTPPath:      s.conf.L1HTTPPath,
+				SrcL2RPC:        srcRPC,
+				DstL2RPC:        dstRPC,
+				RegistryAddress: s.conf.RegistryAddress,
+				SettledStateTTL: s.conf.SettledStateTTL,
+
+				OptimismPortalAddress:      s.conf.OptimismPortals[src],
+				AllowUnverifiedNitroProofs: s.conf.AllowUnverifiedNitroProofs,
+				NativeProverAddress:        s.conf.NativeProvers[dst],
+			})
+			finishEntry(s, entry, prover, err, func() { delete(s.provers, chainPair{src, dst}) })
+		}()
+	}
+	s.mu.Unlock()
+
+	return wait(ctx, entry)
+}
+
+// l1Prover returns the warm L1Prover for the destination chain, initializing it on first use
+func (s *ProofService) l1Prover(ctx context.Context, dst uint64) (*L1Prover, error) {
+	dstRPC, err := s.l2RPC(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	entry, ok := s.l1Provers[dst]
+	if !ok {
+		entry = &warmEntry[*L1Prover]{ready: make(chan struct{})}
+		s.l1Provers[dst] = entry
+		go func() {
+			log.Info("Initializing L1 prover", "dstL2ChainID", dst)
+			prover, err := s.newL1Prover(s.ctx, &ProveL1Config{
+				DstL2ChainID:    dst,
+				L1HTTPPath:      s.conf.L1HTTPPath,
+				DstL2RPC:        dstRPC,
+				RegistryAddress: s.conf.RegistryAddress,
+
+				NativeProverAddress: s.conf.NativeProvers[dst],
+			})
+			finishEntry(s, entry, prover, err, func() { delete(s.l1Provers, dst) })
+		}()
+	}
+	s.mu.Unlock()
+
+	return wait(ctx, entry)
+}
+
+// finishEntry publishes the result of initializing a prover. Failed entries are evicted so the
+// next request retries.
+func finishEntry[T any](s *ProofService, entry *warmEntry[T], value T, err error, evict func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.value, entry.err, entry.createdAt = value, err, time.Now()
+	if err != nil {
+		log.Error("Failed to initialize prover", "err", err)
+		evict()
+	}
+	close(entry.ready)
+}
+
+func (s *ProofService) l2RPC(chainID uint64) (string, error) {
+	url, ok := s.conf.L2RPCs[chainID]
+	if !ok {
+		return "", fmt.Errorf("no RPC configured for chain %d", chainID)
+	}
+	return url, nil
+}
+
+// done reports whether the entry has finished initializing
+func (e *warmEntry[T]) done() bool {
+	select {
+	case <-e.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the entry is initialized or ctx is done. Initialization carries on for
+// other requests when ctx is cancelled.
+func wait[T any](ctx context.Context, entry *warmEntry[T]) (T, error) {
+	select {
+	case <-entry.ready:
+		if entry.err != nil {
+			var zero T
+			return zero, fmt.Errorf("failed to initialize prover: %w", entry.err)
+		}
+		return entry.value, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
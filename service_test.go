@@ -0,0 +1,160 @@
+package fallback_prover
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/polymerdao/fallback_prover/provers"
+)
+
+func newTestService(t *testing.T) *ProofService {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return NewProofService(ctx, &ServiceConfig{
+		L1HTTPPath: "http://l1",
+		L2RPCs: map[uint64]string{
+			10:   "http://op",
+			8453: "http://base",
+		},
+	}, "test")
+}
+
+func TestProofService_SharesWarmProver(t *testing.T) {
+	service := newTestService(t)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	expected := &Prover{}
+	service.newProver = func(ctx context.Context, conf *ProveConfig) (*Prover, error) {
+		calls.Add(1)
+		assert.Equal(t, "http://op", conf.SrcL2RPC)
+		assert.Equal(t, "http://base", conf.DstL2RPC)
+		<-release
+		return expected, nil
+	}
+
+	// A request that gives up while the prover is initializing does not cancel it for the others
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancelledErr := make(chan error, 1)
+	go func() {
+		_, err := service.prover(cancelled, 10, 8453)
+		cancelledErr <- err
+	}()
+
+	var wg sync.WaitGroup
+	results := make([]*Prover, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			prover, err := service.prover(context.Background(), 10, 8453)
+			assert.NoError(t, err)
+			results[i] = prover
+		}(i)
+	}
+
+	cancel()
+	require.ErrorIs(t, <-cancelledErr, context.Canceled)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, prover := range results {
+		assert.Same(t, expected, prover)
+	}
+
+	prover, err := service.prover(context.Background(), 10, 8453)
+	require.NoError(t, err)
+	assert.Same(t, expected, prover)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestProofService_RetriesFailedInit(t *testing.T) {
+	service := newTestService(t)
+
+	var calls atomic.Int32
+	service.newL1Prover = func(ctx context.Context, conf *ProveL1Config) (*L1Prover, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("registry unavailable")
+		}
+		return &L1Prover{}, nil
+	}
+
+	_, err := service.l1Prover(context.Background(), 8453)
+	require.ErrorContains(t, err, "registry unavailable")
+
+	prover, err := service.l1Prover(context.Background(), 8453)
+	require.NoError(t, err)
+	assert.NotNil(t, prover)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestProofService_UnknownChain(t *testing.T) {
+	service := newTestService(t)
+	service.newProver = func(ctx context.Context, conf *ProveConfig) (*Prover, error) {
+		t.Fatal("prover must not be initialized for an unknown chain")
+		return nil, nil
+	}
+
+	_, err := service.ProveNative(context.Background(), ProveNativeRequest{SrcChainID: 10, DstChainID: 1})
+	require.ErrorContains(t, err, "no RPC configured for chain 1")
+}
+
+func TestProofService_JSONRPC(t *testing.T) {
+	srcAddress := common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678")
+	srcStorageSlot := common.HexToHash("0x01")
+
+	service := newTestService(t)
+	mockProver, _ := newMockProver(t, srcAddress, srcStorageSlot)
+	service.newProver = func(ctx context.Context, conf *ProveConfig) (*Prover, error) {
+		return mockProver, nil
+	}
+
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	require.NoError(t, server.RegisterName(ServiceNamespace, service))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	client, err := rpc.Dial(httpServer.URL)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	var response ProveResponse
+	require.NoError(t, client.Call(&response, "prover_proveNative", ProveNativeRequest{
+		SrcChainID:  10,
+		DstChainID:  8453,
+		Address:     srcAddress,
+		StorageSlot: srcStorageSlot,
+	}))
+
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+	calldata := common.FromHex(response.Calldata)
+	require.GreaterOrEqual(t, len(calldata), 4)
+	assert.Equal(t, nativeProver.GetABI().Methods["proveNative"].ID, calldata[:4])
+	assert.Equal(t, uint64(10), response.SrcChainID)
+	assert.Equal(t, uint64(8453), response.DstChainID)
+	require.NotNil(t, response.Settled)
+	assert.Equal(t, int64(9), response.Settled.Index.Int64())
+	assert.Equal(t, int64(12345), response.Settled.L2BlockNumber.Int64())
+
+	var status ServiceStatus
+	require.NoError(t, client.Call(&status, "prover_status"))
+	assert.Equal(t, []uint64{10, 8453}, status.ChainIDs)
+	require.Len(t, status.Provers, 1)
+	assert.Equal(t, uint64(10), status.Provers[0].SrcChainID)
+	assert.Equal(t, "OPStackCannon", status.Provers[0].ConfigType)
+	assert.Equal(t, int64(9), status.Provers[0].Index.Int64())
+	assert.NotNil(t, status.Provers[0].SettledAt)
+	assert.Empty(t, status.L1Provers)
+}
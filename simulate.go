@@ -0,0 +1,34 @@
+package fallback_prover
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// SimulateCalldata dry-runs hex encoded proveNative or proveL1Native calldata against the
+// NativeProver deployed on the destination L2 with eth_call and returns the decoded result.
+// A revert is reported as a *provers.RevertError.
+func SimulateCalldata(
+	ctx context.Context,
+	dstL2RPC string,
+	nativeProverAddress common.Address,
+	calldata string,
+) (*types.ProveResult, error) {
+	dstL2Client, err := ethclient.DialContext(ctx, dstL2RPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to destination L2 RPC: %w", err)
+	}
+	defer dstL2Client.Close()
+
+	nativeProver, err := provers.NewNativeProver()
+	if err != nil {
+		return nil, err
+	}
+
+	return nativeProver.Simulate(ctx, dstL2Client, nativeProverAddress, common.FromHex(calldata))
+}
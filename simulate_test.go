@@ -0,0 +1,87 @@
+package fallback_prover
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/testutil"
+	types2 "github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulateCalldata(t *testing.T) {
+	nativeProverAddress := common.HexToAddress("0x9876543210abcdef9876543210abcdef98765432")
+	proveArgs := types2.ProveL1ScalarArgs{
+		ContractAddr:     common.HexToAddress("0x1234567890abcdef1234567890abcdef12345678"),
+		StorageSlot:      common.HexToHash("0xabcdef"),
+		StorageValue:     common.HexToHash("0x123"),
+		L1WorldStateRoot: common.HexToHash("0x456789"),
+	}
+
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+	calldata, err := nativeProver.EncodeProveL1NativeCalldata(proveArgs, []byte{0x01}, nil, []byte{0x02}, nil)
+	require.NoError(t, err)
+
+	output, err := nativeProver.GetABI().Methods["proveL1Native"].Outputs.Pack(
+		big.NewInt(1),
+		proveArgs.ContractAddr,
+		proveArgs.StorageSlot,
+		proveArgs.StorageValue,
+	)
+	require.NoError(t, err)
+
+	server := testutil.NewRPCServer(t, map[string]testutil.RPCHandler{
+		"eth_call": func(params []json.RawMessage) (interface{}, error) {
+			var msg struct {
+				To    common.Address `json:"to"`
+				Input hexutil.Bytes  `json:"input"`
+			}
+			require.NoError(t, json.Unmarshal(params[0], &msg))
+			assert.Equal(t, nativeProverAddress, msg.To)
+			assert.Equal(t, calldata, []byte(msg.Input))
+			return hexutil.Bytes(output), nil
+		},
+	})
+
+	result, err := SimulateCalldata(context.Background(), server.URL, nativeProverAddress, hexutil.Encode(calldata))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), result.ChainID)
+	assert.Equal(t, proveArgs.ContractAddr, result.StoringContract)
+	assert.Equal(t, proveArgs.StorageSlot, result.StorageSlot)
+	assert.Equal(t, proveArgs.StorageValue, result.StorageValue)
+}
+
+func TestSimulateCalldata_Revert(t *testing.T) {
+	nativeProver, err := provers.NewNativeProver()
+	require.NoError(t, err)
+	calldata, err := nativeProver.EncodeProveL1NativeCalldata(types2.ProveL1ScalarArgs{}, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	outdatedBlock := nativeProver.GetABI().Errors["OutdatedBlock"]
+	revertArgs, err := outdatedBlock.Inputs.Pack(big.NewInt(10), big.NewInt(20))
+	require.NoError(t, err)
+	revertData := append(common.CopyBytes(outdatedBlock.ID[:4]), revertArgs...)
+
+	server := testutil.NewRPCServer(t, map[string]testutil.RPCHandler{
+		"eth_call": func(params []json.RawMessage) (interface{}, error) {
+			return nil, &testutil.RPCError{Code: 3, Message: "execution reverted", Data: hexutil.Encode(revertData)}
+		},
+	})
+
+	_, err = SimulateCalldata(context.Background(), server.URL, common.HexToAddress("0x1"), hexutil.Encode(calldata))
+	var revertErr *provers.RevertError
+	require.ErrorAs(t, err, &revertErr)
+	assert.Equal(t, revertData, revertErr.Data)
+
+	var outdatedErr *provers.OutdatedBlockError
+	require.ErrorAs(t, err, &outdatedErr)
+	assert.Equal(t, big.NewInt(10), outdatedErr.InputBlockNumber)
+	assert.Equal(t, big.NewInt(20), outdatedErr.LatestBlockNumber)
+}
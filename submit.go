@@ -0,0 +1,80 @@
+package fallback_prover
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/polymerdao/fallback_prover/provers"
+	"github.com/polymerdao/fallback_prover/types"
+)
+
+// receiptPollInterval is how often SubmitCalldata checks whether the transaction was mined
+const receiptPollInterval = 2 * time.Second
+
+// LoadSigningKey loads the key used to submit prove transactions, either from a file holding
+// a raw hex encoded private key or from an encrypted keystore file and its password file.
+// Exactly one of keyFile and keystoreFile must be set.
+func LoadSigningKey(keyFile, keystoreFile, passwordFile string) (*ecdsa.PrivateKey, error) {
+	switch {
+	case keyFile != "" && keystoreFile != "":
+		return nil, fmt.Errorf("only one of a private key file and a keystore file can be used")
+	case keyFile != "":
+		key, err := crypto.LoadECDSA(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load private key file: %w", err)
+		}
+		return key, nil
+	case keystoreFile != "":
+		keyJSON, err := os.ReadFile(keystoreFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore file: %w", err)
+		}
+		var password string
+		if passwordFile != "" {
+			rawPassword, err := os.ReadFile(passwordFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read keystore password file: %w", err)
+			}
+			password = strings.TrimRight(string(rawPassword), "\r\n")
+		}
+		key, err := keystore.DecryptKey(keyJSON, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystore file: %w", err)
+		}
+		return key.PrivateKey, nil
+	default:
+		return nil, fmt.Errorf("a private key file or a keystore file is required")
+	}
+}
+
+// SubmitCalldata signs hex encoded proveNative or proveL1Native calldata with key, sends it to
+// the NativeProver on the destination L2 and waits for the WorldStateProven event
+func SubmitCalldata(
+	ctx context.Context,
+	dstL2RPC string,
+	key *ecdsa.PrivateKey,
+	nativeProverAddress common.Address,
+	calldata string,
+) (*types.SubmitResult, error) {
+	dstL2Client, err := ethclient.DialContext(ctx, dstL2RPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to destination L2 RPC: %w", err)
+	}
+	defer dstL2Client.Close()
+
+	nativeProver, err := provers.NewNativeProver()
+	if err != nil {
+		return nil, err
+	}
+
+	transactor := provers.NewTransactor(dstL2Client, key, receiptPollInterval)
+	return nativeProver.Submit(ctx, transactor, nativeProverAddress, common.FromHex(calldata))
+}
@@ -0,0 +1,51 @@
+package fallback_prover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSigningKey(t *testing.T) {
+	dir := t.TempDir()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, crypto.SaveECDSA(keyFile, key))
+
+	keyJSON, err := keystore.EncryptKey(
+		&keystore.Key{Id: uuid.New(), Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key},
+		"secret",
+		keystore.LightScryptN,
+		keystore.LightScryptP,
+	)
+	require.NoError(t, err)
+	keystoreFile := filepath.Join(dir, "keystore.json")
+	require.NoError(t, os.WriteFile(keystoreFile, keyJSON, 0o600))
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+
+	loaded, err := LoadSigningKey(keyFile, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, key.D, loaded.D)
+
+	loaded, err = LoadSigningKey("", keystoreFile, passwordFile)
+	require.NoError(t, err)
+	assert.Equal(t, key.D, loaded.D)
+
+	_, err = LoadSigningKey("", keystoreFile, "")
+	require.ErrorContains(t, err, "failed to decrypt keystore file")
+
+	_, err = LoadSigningKey(keyFile, keystoreFile, passwordFile)
+	require.Error(t, err)
+
+	_, err = LoadSigningKey("", "", "")
+	require.Error(t, err)
+}
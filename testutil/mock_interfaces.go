@@ -13,9 +13,13 @@ import (
 type MockRegistryProver struct {
 	GetL2ConfigurationFunc          func(ctx context.Context, chainID uint64) (*t.L2ConfigInfo, error)
 	GetL1BlockHashOracleFunc        func(ctx context.Context, chainID uint64) (common.Address, error)
-	GetL2ConfigurationForUpdateFunc func(ctx context.Context, chainID uint64) (*t.L2Configuration, error)
-	GetRegistryStorageProofFunc     func(ctx context.Context, chainID uint64) ([][]byte, []byte, [][]byte, error)
-	GenerateUpdateL2ConfigArgsFunc  func(ctx context.Context, chainID uint64) (*t.UpdateL2ConfigArgs, error)
+	GetL2ConfigurationForUpdateFunc func(ctx context.Context, chainID uint64, blockNumber *big.Int) (*t.L2Configuration, error)
+	GetRegistryStorageProofFunc     func(ctx context.Context, chainID uint64, l1Header *types.Header) ([][]byte, []byte, [][]byte, error)
+	GenerateUpdateL2ConfigArgsFunc  func(ctx context.Context, chainID uint64, l1Header *types.Header) (*t.UpdateL2ConfigArgs, error)
+
+	GetL1ConfigurationForUpdateFunc     func(ctx context.Context, chainID uint64, blockNumber *big.Int) (*t.L1Configuration, error)
+	GetRegistryL1ConfigStorageProofFunc func(ctx context.Context, chainID uint64, l1Header *types.Header) ([][]byte, []byte, [][]byte, error)
+	GenerateUpdateL1ConfigArgsFunc      func(ctx context.Context, chainID uint64, l1Header *types.Header) (*t.UpdateL1ConfigArgs, error)
 }
 
 func (m *MockRegistryProver) GetL2Configuration(ctx context.Context, chainID uint64) (*t.L2ConfigInfo, error) {
@@ -35,9 +39,10 @@ func (m *MockRegistryProver) GetL1BlockHashOracle(ctx context.Context, chainID u
 func (m *MockRegistryProver) GetL2ConfigurationForUpdate(
 	ctx context.Context,
 	chainID uint64,
+	blockNumber *big.Int,
 ) (*t.L2Configuration, error) {
 	if m.GetL2ConfigurationForUpdateFunc != nil {
-		return m.GetL2ConfigurationForUpdateFunc(ctx, chainID)
+		return m.GetL2ConfigurationForUpdateFunc(ctx, chainID, blockNumber)
 	}
 	return nil, nil
 }
@@ -45,9 +50,10 @@ func (m *MockRegistryProver) GetL2ConfigurationForUpdate(
 func (m *MockRegistryProver) GetRegistryStorageProof(
 	ctx context.Context,
 	chainID uint64,
+	l1Header *types.Header,
 ) ([][]byte, []byte, [][]byte, error) {
 	if m.GetRegistryStorageProofFunc != nil {
-		return m.GetRegistryStorageProofFunc(ctx, chainID)
+		return m.GetRegistryStorageProofFunc(ctx, chainID, l1Header)
 	}
 	return nil, nil, nil, nil
 }
@@ -55,9 +61,43 @@ func (m *MockRegistryProver) GetRegistryStorageProof(
 func (m *MockRegistryProver) GenerateUpdateL2ConfigArgs(
 	ctx context.Context,
 	chainID uint64,
+	l1Header *types.Header,
 ) (*t.UpdateL2ConfigArgs, error) {
 	if m.GenerateUpdateL2ConfigArgsFunc != nil {
-		return m.GenerateUpdateL2ConfigArgsFunc(ctx, chainID)
+		return m.GenerateUpdateL2ConfigArgsFunc(ctx, chainID, l1Header)
+	}
+	return nil, nil
+}
+
+func (m *MockRegistryProver) GetL1ConfigurationForUpdate(
+	ctx context.Context,
+	chainID uint64,
+	blockNumber *big.Int,
+) (*t.L1Configuration, error) {
+	if m.GetL1ConfigurationForUpdateFunc != nil {
+		return m.GetL1ConfigurationForUpdateFunc(ctx, chainID, blockNumber)
+	}
+	return nil, nil
+}
+
+func (m *MockRegistryProver) GetRegistryL1ConfigStorageProof(
+	ctx context.Context,
+	chainID uint64,
+	l1Header *types.Header,
+) ([][]byte, []byte, [][]byte, error) {
+	if m.GetRegistryL1ConfigStorageProofFunc != nil {
+		return m.GetRegistryL1ConfigStorageProofFunc(ctx, chainID, l1Header)
+	}
+	return nil, nil, nil, nil
+}
+
+func (m *MockRegistryProver) GenerateUpdateL1ConfigArgs(
+	ctx context.Context,
+	chainID uint64,
+	l1Header *types.Header,
+) (*t.UpdateL1ConfigArgs, error) {
+	if m.GenerateUpdateL1ConfigArgsFunc != nil {
+		return m.GenerateUpdateL1ConfigArgsFunc(ctx, chainID, l1Header)
 	}
 	return nil, nil
 }
@@ -87,6 +127,7 @@ type MockStorageProver struct {
 	GetStorageAtFunc         func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (string, error)
 	GetStorageProofFunc      func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (*t.StorageProofResult, error)
 	GenerateStorageProofFunc func(ctx context.Context, contractAddr common.Address, storageSlot common.Hash, blockNumber *big.Int) ([][]byte, []byte, [][]byte, error)
+	GetStorageProofsFunc     func(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*t.StorageProofResult, error)
 }
 
 func (m *MockStorageProver) GetStorageAt(
@@ -113,6 +154,18 @@ func (m *MockStorageProver) GetStorageProof(
 	return nil, nil
 }
 
+func (m *MockStorageProver) GetStorageProofs(
+	ctx context.Context,
+	address common.Address,
+	slots []common.Hash,
+	blockNumber *big.Int,
+) (*t.StorageProofResult, error) {
+	if m.GetStorageProofsFunc != nil {
+		return m.GetStorageProofsFunc(ctx, address, slots, blockNumber)
+	}
+	return nil, nil
+}
+
 func (m *MockStorageProver) GenerateStorageProof(
 	ctx context.Context,
 	contractAddr common.Address,
@@ -127,56 +180,86 @@ func (m *MockStorageProver) GenerateStorageProof(
 
 // MockOPStackBedrockProver is a mock implementation of the provers.ISettledStateProver interface
 type MockOPStackBedrockProver struct {
-	FindLatestResolvedFunc        func(ctx context.Context, config *t.L2ConfigInfo) (*big.Int, common.Address, error)
-	GenerateSettledStateProofFunc func(ctx context.Context, l1BlockNumber, outputIndex *big.Int, rootAddress common.Address, config *t.L2ConfigInfo) ([]byte, *types.Header, error)
+	FindLatestResolvedFunc        func(ctx context.Context, config *t.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error)
+	FindResolvedAtOrAfterFunc     func(ctx context.Context, config *t.L2ConfigInfo, l2BlockNumber *big.Int, l1BlockNumber *big.Int) (*big.Int, common.Address, error)
+	GenerateSettledStateProofFunc func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *t.L2ConfigInfo) ([]byte, *types.Header, error)
 }
 
 func (m *MockOPStackBedrockProver) FindLatestResolved(
 	ctx context.Context,
 	config *t.L2ConfigInfo,
+	l1BlockNumber *big.Int,
 ) (*big.Int, common.Address, error) {
 	if m.FindLatestResolvedFunc != nil {
-		return m.FindLatestResolvedFunc(ctx, config)
+		return m.FindLatestResolvedFunc(ctx, config, l1BlockNumber)
+	}
+	return big.NewInt(0), common.Address{}, nil
+}
+
+func (m *MockOPStackBedrockProver) FindResolvedAtOrAfter(
+	ctx context.Context,
+	config *t.L2ConfigInfo,
+	l2BlockNumber *big.Int,
+	l1BlockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	if m.FindResolvedAtOrAfterFunc != nil {
+		return m.FindResolvedAtOrAfterFunc(ctx, config, l2BlockNumber, l1BlockNumber)
 	}
 	return big.NewInt(0), common.Address{}, nil
 }
 
 func (m *MockOPStackBedrockProver) GenerateSettledStateProof(
 	ctx context.Context,
-	l1BlockNumber, outputIndex *big.Int,
+	l1Header *types.Header,
+	outputIndex *big.Int,
 	rootAddress common.Address,
 	config *t.L2ConfigInfo,
 ) ([]byte, *types.Header, error) {
 	if m.GenerateSettledStateProofFunc != nil {
-		return m.GenerateSettledStateProofFunc(ctx, l1BlockNumber, outputIndex, rootAddress, config)
+		return m.GenerateSettledStateProofFunc(ctx, l1Header, outputIndex, rootAddress, config)
 	}
 	return nil, nil, nil
 }
 
 // MockOPStackCannonProver is a mock implementation of the provers.ISettledStateProver interface
 type MockOPStackCannonProver struct {
-	FindLatestResolvedFunc        func(ctx context.Context, config *t.L2ConfigInfo) (*big.Int, common.Address, error)
-	GenerateSettledStateProofFunc func(ctx context.Context, l1BlockNumber, outputIndex *big.Int, rootAddress common.Address, config *t.L2ConfigInfo) ([]byte, *types.Header, error)
+	FindLatestResolvedFunc        func(ctx context.Context, config *t.L2ConfigInfo, l1BlockNumber *big.Int) (*big.Int, common.Address, error)
+	FindResolvedAtOrAfterFunc     func(ctx context.Context, config *t.L2ConfigInfo, l2BlockNumber *big.Int, l1BlockNumber *big.Int) (*big.Int, common.Address, error)
+	GenerateSettledStateProofFunc func(ctx context.Context, l1Header *types.Header, outputIndex *big.Int, rootAddress common.Address, config *t.L2ConfigInfo) ([]byte, *types.Header, error)
 }
 
 func (m *MockOPStackCannonProver) FindLatestResolved(
 	ctx context.Context,
 	config *t.L2ConfigInfo,
+	l1BlockNumber *big.Int,
 ) (*big.Int, common.Address, error) {
 	if m.FindLatestResolvedFunc != nil {
-		return m.FindLatestResolvedFunc(ctx, config)
+		return m.FindLatestResolvedFunc(ctx, config, l1BlockNumber)
+	}
+	return big.NewInt(0), common.Address{}, nil
+}
+
+func (m *MockOPStackCannonProver) FindResolvedAtOrAfter(
+	ctx context.Context,
+	config *t.L2ConfigInfo,
+	l2BlockNumber *big.Int,
+	l1BlockNumber *big.Int,
+) (*big.Int, common.Address, error) {
+	if m.FindResolvedAtOrAfterFunc != nil {
+		return m.FindResolvedAtOrAfterFunc(ctx, config, l2BlockNumber, l1BlockNumber)
 	}
 	return big.NewInt(0), common.Address{}, nil
 }
 
 func (m *MockOPStackCannonProver) GenerateSettledStateProof(
 	ctx context.Context,
-	l1BlockNumber, outputIndex *big.Int,
+	l1Header *types.Header,
+	outputIndex *big.Int,
 	rootAddress common.Address,
 	config *t.L2ConfigInfo,
 ) ([]byte, *types.Header, error) {
 	if m.GenerateSettledStateProofFunc != nil {
-		return m.GenerateSettledStateProofFunc(ctx, l1BlockNumber, outputIndex, rootAddress, config)
+		return m.GenerateSettledStateProofFunc(ctx, l1Header, outputIndex, rootAddress, config)
 	}
 	return nil, nil, nil
 }
@@ -17,6 +17,7 @@ type MockEthClient struct {
 	CallContractFunc  func(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
 	BlockByHashFunc   func(ctx context.Context, hash common.Hash) (*types.Block, error)
 	BlockByNumberFunc func(ctx context.Context, number *big.Int) (*types.Block, error)
+	FilterLogsFunc    func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
 }
 
 func (m *MockEthClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
@@ -40,6 +41,13 @@ func (m *MockEthClient) BlockByNumber(ctx context.Context, number *big.Int) (*ty
 	return nil, nil
 }
 
+func (m *MockEthClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if m.FilterLogsFunc != nil {
+		return m.FilterLogsFunc(ctx, q)
+	}
+	return nil, nil
+}
+
 // MockRPCClient is a mock implementation of the IRPCClient for testing
 type MockRPCClient struct {
 	CallContextFunc      func(ctx context.Context, result interface{}, method string, args ...interface{}) error
@@ -0,0 +1,94 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// RPCHandler serves a single JSON-RPC method from its raw params
+type RPCHandler func(params []json.RawMessage) (interface{}, error)
+
+// RPCError is a JSON-RPC error returned by an RPCHandler, e.g. an execution revert
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// NewRPCServer starts a local JSON-RPC stand-in serving the given methods over HTTP.
+// Single and batch requests are supported; unknown methods return a method-not-found error.
+// The server is closed when the test finishes.
+func NewRPCServer(t *testing.T, handlers map[string]RPCHandler) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(raw) > 0 && raw[0] == '[' {
+			var requests []rpcRequest
+			if err := json.Unmarshal(raw, &requests); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			responses := make([]rpcResponse, len(requests))
+			for i, req := range requests {
+				responses[i] = serveRPC(handlers, req)
+			}
+			_ = json.NewEncoder(w).Encode(responses)
+			return
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(serveRPC(handlers, req))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func serveRPC(handlers map[string]RPCHandler, req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	handler, ok := handlers[req.Method]
+	if !ok {
+		resp.Error = &RPCError{Code: -32601, Message: "the method " + req.Method + " does not exist/is not available"}
+		return resp
+	}
+
+	result, err := handler(req.Params)
+	if err != nil {
+		if rpcErr, ok := err.(*RPCError); ok {
+			resp.Error = rpcErr
+		} else {
+			resp.Error = &RPCError{Code: -32000, Message: err.Error()}
+		}
+		return resp
+	}
+	resp.Result = result
+	return resp
+}
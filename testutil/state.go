@@ -0,0 +1,197 @@
+package testutil
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	types2 "github.com/polymerdao/fallback_prover/types"
+	"github.com/stretchr/testify/require"
+)
+
+// ProofState is an in-memory world state that serves real Merkle-Patricia proofs in the
+// shape of an eth_getProof response
+type ProofState struct {
+	accounts map[common.Address]*proofAccount
+}
+
+type proofAccount struct {
+	nonce    uint64
+	balance  *big.Int
+	codeHash common.Hash
+	storage  map[common.Hash]common.Hash
+}
+
+// stateAccount mirrors the RLP layout of an account in the state trie
+type stateAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// orderedProof collects proof nodes in the order the trie emits them, root first
+type orderedProof [][]byte
+
+func (p *orderedProof) Put(_ []byte, value []byte) error {
+	*p = append(*p, common.CopyBytes(value))
+	return nil
+}
+
+func (p *orderedProof) Delete([]byte) error {
+	return nil
+}
+
+// NewProofState creates an empty ProofState
+func NewProofState() *ProofState {
+	return &ProofState{accounts: make(map[common.Address]*proofAccount)}
+}
+
+func (s *ProofState) account(address common.Address) *proofAccount {
+	acc, ok := s.accounts[address]
+	if !ok {
+		acc = &proofAccount{
+			balance:  big.NewInt(0),
+			codeHash: types.EmptyCodeHash,
+			storage:  make(map[common.Hash]common.Hash),
+		}
+		s.accounts[address] = acc
+	}
+	return acc
+}
+
+// SetAccount creates or updates the non-storage fields of an account
+func (s *ProofState) SetAccount(address common.Address, nonce uint64, balance *big.Int, codeHash common.Hash) {
+	acc := s.account(address)
+	acc.nonce = nonce
+	acc.balance = balance
+	acc.codeHash = codeHash
+}
+
+// SetStorage sets a storage slot of an account, creating the account if needed
+func (s *ProofState) SetStorage(address common.Address, slot, value common.Hash) {
+	s.account(address).storage[slot] = value
+}
+
+// StorageRoot returns the storage root of an account
+func (s *ProofState) StorageRoot(t *testing.T, address common.Address) common.Hash {
+	return s.storageTrie(t, s.account(address)).Hash()
+}
+
+// Root returns the state root
+func (s *ProofState) Root(t *testing.T) common.Hash {
+	return s.stateTrie(t).Hash()
+}
+
+// GetProof returns an eth_getProof style result for the account and slots
+func (s *ProofState) GetProof(t *testing.T, address common.Address, slots ...common.Hash) map[string]interface{} {
+	acc := s.account(address)
+
+	var accountProof orderedProof
+	require.NoError(t, s.stateTrie(t).Prove(crypto.Keccak256(address.Bytes()), &accountProof))
+
+	storage := s.storageTrie(t, acc)
+	storageProofs := make([]map[string]interface{}, 0, len(slots))
+	for _, slot := range slots {
+		var proof orderedProof
+		require.NoError(t, storage.Prove(crypto.Keccak256(slot.Bytes()), &proof))
+		storageProofs = append(storageProofs, map[string]interface{}{
+			"key":   slot.Hex(),
+			"value": hexutil.EncodeBig(acc.storage[slot].Big()),
+			"proof": encodeProof(proof),
+		})
+	}
+
+	return map[string]interface{}{
+		"address":      address.Hex(),
+		"accountProof": encodeProof(accountProof),
+		"balance":      hexutil.EncodeBig(acc.balance),
+		"codeHash":     acc.codeHash.Hex(),
+		"nonce":        hexutil.EncodeUint64(acc.nonce),
+		"storageHash":  storage.Hash().Hex(),
+		"storageProof": storageProofs,
+	}
+}
+
+// GetProofResult returns the same proof as GetProof decoded into a StorageProofResult
+func (s *ProofState) GetProofResult(
+	t *testing.T,
+	address common.Address,
+	slots ...common.Hash,
+) types2.StorageProofResult {
+	raw, err := json.Marshal(s.GetProof(t, address, slots...))
+	require.NoError(t, err)
+
+	var result types2.StorageProofResult
+	require.NoError(t, json.Unmarshal(raw, &result))
+	return result
+}
+
+// ProofBytes returns the raw account proof, RLP encoded account and storage proof for a single slot
+func (s *ProofState) ProofBytes(
+	t *testing.T,
+	address common.Address,
+	slot common.Hash,
+) ([][]byte, []byte, [][]byte) {
+	acc := s.account(address)
+	storage := s.storageTrie(t, acc)
+
+	var storageProof orderedProof
+	require.NoError(t, storage.Prove(crypto.Keccak256(slot.Bytes()), &storageProof))
+	var accountProof orderedProof
+	require.NoError(t, s.stateTrie(t).Prove(crypto.Keccak256(address.Bytes()), &accountProof))
+
+	rlpAccount, err := rlp.EncodeToBytes(stateAccount{
+		Nonce:    acc.nonce,
+		Balance:  acc.balance,
+		Root:     storage.Hash(),
+		CodeHash: acc.codeHash.Bytes(),
+	})
+	require.NoError(t, err)
+
+	return storageProof, rlpAccount, accountProof
+}
+
+func (s *ProofState) storageTrie(t *testing.T, acc *proofAccount) *trie.Trie {
+	tr := trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	for slot, value := range acc.storage {
+		if value == (common.Hash{}) {
+			continue
+		}
+		encoded, err := rlp.EncodeToBytes(common.TrimLeftZeroes(value.Bytes()))
+		require.NoError(t, err)
+		tr.MustUpdate(crypto.Keccak256(slot.Bytes()), encoded)
+	}
+	return tr
+}
+
+func (s *ProofState) stateTrie(t *testing.T) *trie.Trie {
+	tr := trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	for address, acc := range s.accounts {
+		encoded, err := rlp.EncodeToBytes(stateAccount{
+			Nonce:    acc.nonce,
+			Balance:  acc.balance,
+			Root:     s.storageTrie(t, acc).Hash(),
+			CodeHash: acc.codeHash.Bytes(),
+		})
+		require.NoError(t, err)
+		tr.MustUpdate(crypto.Keccak256(address.Bytes()), encoded)
+	}
+	return tr
+}
+
+func encodeProof(proof [][]byte) []string {
+	encoded := make([]string, len(proof))
+	for i, node := range proof {
+		encoded[i] = hexutil.Encode(node)
+	}
+	return encoded
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ProofBundle is the calldata of a NativeProver proof together with every input it was
+// generated from, so that it can be indexed, audited and encoded again
+type ProofBundle struct {
+	// Method is the NativeProver function the calldata calls, proveNative or proveL1Native
+	Method   string        `json:"method"`
+	Calldata hexutil.Bytes `json:"calldata"`
+	// L1Header is the L1 origin the proof is anchored to
+	L1Header BundleHeader `json:"l1Header"`
+
+	// The source L2 and its settled state, only set for proveNative
+	SrcChainID *big.Int      `json:"srcChainId,omitempty"`
+	ConfigType string        `json:"configType,omitempty"`
+	L2Header   *BundleHeader `json:"l2Header,omitempty"`
+	Settled    *SettledState `json:"settled,omitempty"`
+	// OutputRoot is the settled OP Stack output root, rebuilt from the settled state proof
+	OutputRoot        *common.Hash      `json:"outputRoot,omitempty"`
+	SettledStateProof hexutil.Bytes     `json:"settledStateProof,omitempty"`
+	UpdateArgs        *BundleUpdateArgs `json:"updateArgs,omitempty"`
+
+	// Storage is the proven storage slot, against the L2 header for proveNative and the L1
+	// header for proveL1Native
+	Storage BundleStorageProof `json:"storage"`
+}
+
+// BundleHeader is a block header of a ProofBundle
+type BundleHeader struct {
+	Number    *big.Int      `json:"number"`
+	Hash      common.Hash   `json:"hash"`
+	StateRoot common.Hash   `json:"stateRoot"`
+	Timestamp uint64        `json:"timestamp"`
+	RLP       hexutil.Bytes `json:"rlp"`
+}
+
+// NewBundleHeader returns the bundle form of header and its RLP encoding
+func NewBundleHeader(header *types.Header, rlpEncoded []byte) BundleHeader {
+	return BundleHeader{
+		Number:    header.Number,
+		Hash:      header.Hash(),
+		StateRoot: header.Root,
+		Timestamp: header.Time,
+		RLP:       rlpEncoded,
+	}
+}
+
+// BundleStorageProof is the proof of a storage slot of a ProofBundle
+type BundleStorageProof struct {
+	Address           common.Address  `json:"address"`
+	StorageSlot       common.Hash     `json:"storageSlot"`
+	StorageValue      common.Hash     `json:"storageValue"`
+	StorageProof      []hexutil.Bytes `json:"storageProof"`
+	RlpEncodedAccount hexutil.Bytes   `json:"rlpEncodedAccount"`
+	AccountProof      []hexutil.Bytes `json:"accountProof"`
+}
+
+// BundleUpdateArgs is the UpdateL2ConfigArgs of a ProofBundle with its proofs hex encoded
+type BundleUpdateArgs struct {
+	Config                        L2Configuration `json:"config"`
+	L1StorageProof                []hexutil.Bytes `json:"l1StorageProof"`
+	RlpEncodedRegistryAccountData hexutil.Bytes   `json:"rlpEncodedRegistryAccountData"`
+	L1RegistryProof               []hexutil.Bytes `json:"l1RegistryProof"`
+}
+
+// NewBundleUpdateArgs returns the bundle form of args
+func NewBundleUpdateArgs(args *UpdateL2ConfigArgs) *BundleUpdateArgs {
+	return &BundleUpdateArgs{
+		Config:                        args.Config,
+		L1StorageProof:                HexProof(args.L1StorageProof),
+		RlpEncodedRegistryAccountData: args.RlpEncodedRegistryAccountData,
+		L1RegistryProof:               HexProof(args.L1RegistryProof),
+	}
+}
+
+// Args returns the UpdateL2ConfigArgs the bundle form was made from
+func (b *BundleUpdateArgs) Args() UpdateL2ConfigArgs {
+	return UpdateL2ConfigArgs{
+		Config:                        b.Config,
+		L1StorageProof:                RawProof(b.L1StorageProof),
+		RlpEncodedRegistryAccountData: b.RlpEncodedRegistryAccountData,
+		L1RegistryProof:               RawProof(b.L1RegistryProof),
+	}
+}
+
+// HexProof converts the nodes of a Merkle proof to their hex encoded JSON form
+func HexProof(proof [][]byte) []hexutil.Bytes {
+	nodes := make([]hexutil.Bytes, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// RawProof converts the nodes of a hex encoded Merkle proof back to bytes
+func RawProof(proof []hexutil.Bytes) [][]byte {
+	nodes := make([][]byte, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return nodes
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// RegistryConfigUpdate is an L2ChainConfigurationUpdated or L1ChainConfigurationUpdated log of
+// the Registry
+type RegistryConfigUpdate struct {
+	ConfigHash  common.Hash `json:"configHash"`
+	BlockNumber uint64      `json:"blockNumber"`
+	TxHash      common.Hash `json:"txHash"`
+}
+
+// RegistryChain is a chain configured in the Registry, with its configurations as of the L1 block
+// they were read at and the updates that set them, oldest first
+type RegistryChain struct {
+	ChainID uint64 `json:"chainId"`
+	// ConfigType is the name of the L2 type of L2Configuration
+	ConfigType             string                 `json:"configType,omitempty"`
+	L2Configuration        *L2Configuration       `json:"l2Configuration,omitempty"`
+	L2ConfigurationUpdates []RegistryConfigUpdate `json:"l2ConfigurationUpdates"`
+	L1Configuration        *L1Configuration       `json:"l1Configuration,omitempty"`
+	L1ConfigurationUpdates []RegistryConfigUpdate `json:"l1ConfigurationUpdates"`
+}
+
+// RegistryChains lists the chains of a Registry found in its logs from FromBlock, with the
+// state read at BlockNumber
+type RegistryChains struct {
+	Registry    common.Address  `json:"registry"`
+	FromBlock   uint64          `json:"fromBlock"`
+	BlockNumber uint64          `json:"blockNumber"`
+	Chains      []RegistryChain `json:"chains"`
+}
+
+// RegistryGrantee is a NewIrrevocableGrantee log of the Registry
+type RegistryGrantee struct {
+	ChainID     uint64         `json:"chainId"`
+	Grantee     common.Address `json:"grantee"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxHash      common.Hash    `json:"txHash"`
+}
+
+// RegistryRole is an access control role of the Registry and the accounts holding it
+type RegistryRole struct {
+	Role common.Hash `json:"role"`
+	// Name is set for the roles the Registry ABI names, e.g. DEFAULT_ADMIN_ROLE
+	Name      string           `json:"name,omitempty"`
+	AdminRole common.Hash      `json:"adminRole"`
+	Members   []common.Address `json:"members"`
+}
+
+// RegistryAccess describes who may change a Registry, from its logs from FromBlock and the state
+// read at BlockNumber
+type RegistryAccess struct {
+	Registry    common.Address `json:"registry"`
+	FromBlock   uint64         `json:"fromBlock"`
+	BlockNumber uint64         `json:"blockNumber"`
+	Owner       common.Address `json:"owner"`
+	Paused      bool           `json:"paused"`
+	// Grantees are the irrevocable chain ID grants, in log order
+	Grantees []RegistryGrantee `json:"grantees"`
+	Roles    []RegistryRole    `json:"roles"`
+}
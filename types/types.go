@@ -32,23 +32,24 @@ type StorageProofEntry struct {
 	Proof []string     `json:"proof"`
 }
 
-// L2Type represents the type of L2 chain
+// L2Type represents the type of L2 chain, matching the Registry contract enum
 type L2Type uint8
 
 const (
-	Nitro L2Type = iota
+	Unknown L2Type = iota
 	OPStackBedrock
 	OPStackCannon
+	Nitro
 )
 
 // L2Configuration represents the L2 chain configuration
 type L2Configuration struct {
-	Prover               common.Address
-	Addresses            []common.Address
-	StorageSlots         []*big.Int
-	VersionNumber        *big.Int
-	FinalityDelaySeconds *big.Int
-	L2Type               L2Type
+	Prover               common.Address   `json:"prover"`
+	Addresses            []common.Address `json:"addresses"`
+	StorageSlots         []*big.Int       `json:"storageSlots"`
+	VersionNumber        *big.Int         `json:"versionNumber"`
+	FinalityDelaySeconds *big.Int         `json:"finalityDelaySeconds"`
+	L2Type               L2Type           `json:"l2Type"`
 }
 
 // UpdateL2ConfigArgs represents the arguments needed for updating an L2 configuration
@@ -75,3 +76,77 @@ type ProveL1ScalarArgs struct {
 	StorageValue     common.Hash
 	L1WorldStateRoot common.Hash
 }
+
+// L1Configuration represents the L1 chain configuration, as kept in the Registry and as the
+// L1_CONFIGURATION() a NativeProver verifies proofs with
+type L1Configuration struct {
+	BlockHashOracle                       common.Address `json:"blockHashOracle"`
+	SettlementBlocksDelay                 *big.Int       `json:"settlementBlocksDelay"`
+	SettlementRegistry                    common.Address `json:"settlementRegistry"`
+	SettlementRegistryL2ConfigMappingSlot *big.Int       `json:"settlementRegistryL2ConfigMappingSlot"`
+	SettlementRegistryL1ConfigMappingSlot *big.Int       `json:"settlementRegistryL1ConfigMappingSlot"`
+}
+
+// UpdateL1ConfigArgs represents the arguments needed for updating an L1 configuration
+type UpdateL1ConfigArgs struct {
+	Config                        L1Configuration
+	L1StorageProof                [][]byte
+	RlpEncodedRegistryAccountData []byte
+	L1RegistryProof               [][]byte
+}
+
+// ProveNativeCall holds the arguments of a NativeProver.proveNative() call
+type ProveNativeCall struct {
+	UpdateArgs                UpdateL2ConfigArgs
+	ProveArgs                 ProveScalarArgs
+	RlpEncodedL1Header        []byte
+	RlpEncodedL2Header        []byte
+	SettledStateProof         []byte
+	L2StorageProof            [][]byte
+	RlpEncodedContractAccount []byte
+	L2AccountProof            [][]byte
+}
+
+// ProveL1NativeCall holds the arguments of a NativeProver.proveL1Native() call
+type ProveL1NativeCall struct {
+	Args                      ProveL1ScalarArgs
+	RlpEncodedL1Header        []byte
+	L1StorageProof            [][]byte
+	RlpEncodedContractAccount []byte
+	L1AccountProof            [][]byte
+}
+
+// ProveResult holds the values returned by the NativeProver prove functions
+type ProveResult struct {
+	ChainID         *big.Int
+	StoringContract common.Address
+	StorageSlot     common.Hash
+	StorageValue    common.Hash
+}
+
+// WorldStateProven holds an L1WorldStateProven or L2WorldStateProven event emitted by the NativeProver
+type WorldStateProven struct {
+	Event string
+	// ChainID is the chain whose state was proven; nil for L1WorldStateProven
+	ChainID     *big.Int
+	BlockNumber *big.Int
+	StateRoot   common.Hash
+}
+
+// SubmitResult describes a mined prove transaction
+type SubmitResult struct {
+	TxHash      common.Hash
+	BlockNumber *big.Int
+	GasUsed     uint64
+	Event       *WorldStateProven
+}
+
+// SettledState identifies the settled L2 state a proof was generated against
+type SettledState struct {
+	// Index is the output index, dispute game index or rollup node number that was proven
+	Index         *big.Int       `json:"index"`
+	RootAddress   common.Address `json:"rootAddress"`
+	L1BlockNumber *big.Int       `json:"l1BlockNumber"`
+	L2BlockNumber *big.Int       `json:"l2BlockNumber"`
+	L2BlockHash   common.Hash    `json:"l2BlockHash"`
+}